@@ -100,14 +100,15 @@ const (
 	INTLOLA Archive = "intlola"
 
 	//Jars
-	ANT        Jar = "ant"
-	ANT_JUNIT  Jar = "ant_junit"
-	CHECKSTYLE Jar = "checkstyle"
-	FINDBUGS   Jar = "findbugs"
-	GSON       Jar = "gson"
-	JPF        Jar = "jpf"
-	JPF_RUN    Jar = "jpf_run"
-	JUNIT      Jar = "junit"
+	ANT            Jar = "ant"
+	ANT_JUNIT      Jar = "ant_junit"
+	CHECKSTYLE     Jar = "checkstyle"
+	FINDBUGS       Jar = "findbugs"
+	GSON           Jar = "gson"
+	JPF            Jar = "jpf"
+	JPF_RUN        Jar = "jpf_run"
+	JUNIT          Jar = "junit"
+	JUNIT_PLATFORM Jar = "junit_platform"
 
 	//Scripts
 	DIFF2HTML Sh = "diff2html"
@@ -0,0 +1,572 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Archive records a project whose data has been moved to cold storage.
+	//Only the project's submissions and the final snapshot of each of their
+	//files, along with its results, are kept in the active database.
+	//All of the project's data is stored in a gzip compressed stream of
+	//bson documents at Path until the project is restored.
+	Archive struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Path      string        `bson:"path"`
+		Time      int64         `bson:"time"`
+		Size      int64         `bson:"size"`
+	}
+
+	//Retention specifies how long a project is kept online before it is archived.
+	//Projects are archived Days days after their last snapshot, a value of 0
+	//disables automatic archiving.
+	Retention struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Days      int           `bson:"days"`
+	}
+
+	//Usage describes the storage used by a project in the active database.
+	//Size is the uncompressed size in bytes of the project's snapshots.
+	Usage struct {
+		Submissions int
+		Snapshots   int
+		Results     int
+		Reports     int
+		Size        int64
+	}
+
+	//archiveEntry is a single document in an Archive's stream.
+	//Grid files are stored with their Collection set to GRIDFS_NAME.
+	archiveEntry struct {
+		Collection  string      `bson:"collection"`
+		Doc         interface{} `bson:"doc,omitempty"`
+		GridId      interface{} `bson:"gridid,omitempty"`
+		ContentType string      `bson:"contenttype,omitempty"`
+		Data        []byte      `bson:"data,omitempty"`
+	}
+
+	//rawArchiveEntry is used to decode an archiveEntry before its document.
+	rawArchiveEntry struct {
+		Collection  string      `bson:"collection"`
+		Doc         bson.Raw    `bson:"doc,omitempty"`
+		GridId      interface{} `bson:"gridid,omitempty"`
+		ContentType string      `bson:"contenttype,omitempty"`
+		Data        []byte      `bson:"data,omitempty"`
+	}
+
+	//archiveWriter writes archiveEntries to a gzip compressed temporary file
+	//which is only moved to its path once it has been completely written.
+	archiveWriter struct {
+		p string
+		f *os.File
+		w *gzip.Writer
+	}
+)
+
+const (
+	ARCHIVE_EXT = ".gz"
+	DAY         = 24 * 60 * 60 * 1000
+)
+
+var (
+	archiveDir string
+)
+
+//ArchiveDir retrieves the directory in which archives are stored.
+func ArchiveDir() (string, error) {
+	if archiveDir != "" {
+		return archiveDir, nil
+	}
+	b, e := util.BaseDir()
+	if e != nil {
+		return "", e
+	}
+	return filepath.Join(b, "archives"), nil
+}
+
+//SetArchiveDir sets the directory in which archives are stored.
+func SetArchiveDir(d string) {
+	archiveDir = d
+}
+
+//NewRetention
+func NewRetention(pid bson.ObjectId, days int) *Retention {
+	return &Retention{Id: bson.NewObjectId(), ProjectId: pid, Days: days}
+}
+
+//ProjectArchive retrieves the Archive of the project matching pid.
+func ProjectArchive(pid bson.ObjectId) (*Archive, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	m := bson.M{PROJECTID: pid}
+	var a *Archive
+	if e = s.FindOne(ARCHIVES, m, nil, &a); e != nil {
+		return nil, &GetError{"archive", e, m}
+	}
+	return a, nil
+}
+
+//ProjectRetention retrieves the Retention of the project matching pid.
+func ProjectRetention(pid bson.ObjectId) (*Retention, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	m := bson.M{PROJECTID: pid}
+	var r *Retention
+	if e = s.FindOne(RETENTION, m, nil, &r); e != nil {
+		return nil, &GetError{"retention", e, m}
+	}
+	return r, nil
+}
+
+//SetRetention replaces the Retention of the project matching pid.
+func SetRetention(pid bson.ObjectId, days int) error {
+	if days < 0 {
+		return fmt.Errorf("invalid retention period %d", days)
+	}
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	m := bson.M{PROJECTID: pid}
+	if e = s.RemoveAll(RETENTION, m); e != nil {
+		return &RemoveError{"retention", e, m}
+	}
+	return Add(RETENTION, NewRetention(pid, days))
+}
+
+//ApplyRetention archives all projects which have not received a snapshot
+//within their Retention period. It returns the ids of the archived projects.
+func ApplyRetention() ([]bson.ObjectId, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	m := bson.M{DAYS: bson.M{GT: 0}}
+	var rs []*Retention
+	if e = s.Find(RETENTION, m, nil, 0, nil, &rs); e != nil {
+		return nil, &GetError{"retention", e, m}
+	}
+	var ids []bson.ObjectId
+	for _, r := range rs {
+		if Contains(ARCHIVES, bson.M{PROJECTID: r.ProjectId}) {
+			continue
+		}
+		t, e := lastSnapshot(r.ProjectId)
+		if e != nil {
+			return ids, e
+		}
+		if t == 0 || util.CurMilis()-t < int64(r.Days)*DAY {
+			continue
+		}
+		if _, e = ArchiveProject(r.ProjectId); e != nil {
+			return ids, e
+		}
+		ids = append(ids, r.ProjectId)
+	}
+	return ids, nil
+}
+
+//lastSnapshot retrieves the time of the project matching pid's most recent snapshot.
+func lastSnapshot(pid bson.ObjectId) (int64, error) {
+	ids, e := submissionIds(pid)
+	if e != nil || len(ids) == 0 {
+		return 0, e
+	}
+	fs, e := Files(bson.M{SUBID: bson.M{IN: ids}}, bson.M{TIME: 1}, 1, "-"+TIME)
+	if e != nil || len(fs) == 0 {
+		return 0, e
+	}
+	return fs[0].Time, nil
+}
+
+//submissionIds retrieves the ids of the project matching pid's submissions.
+func submissionIds(pid bson.ObjectId) ([]bson.ObjectId, error) {
+	ss, e := Submissions(bson.M{PROJECTID: pid}, bson.M{ID: 1})
+	if e != nil {
+		return nil, e
+	}
+	ids := make([]bson.ObjectId, len(ss))
+	for i, s := range ss {
+		ids[i] = s.Id
+	}
+	return ids, nil
+}
+
+//ProjectUsage calculates the storage used by the project matching pid.
+func ProjectUsage(pid bson.ObjectId) (*Usage, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	ids, e := submissionIds(pid)
+	if e != nil {
+		return nil, e
+	}
+	u := &Usage{Submissions: len(ids)}
+	if len(ids) == 0 {
+		return u, nil
+	}
+	fs, e := Files(bson.M{SUBID: bson.M{IN: ids}}, bson.M{BLOB: 1}, 0)
+	if e != nil {
+		return nil, e
+	}
+	u.Snapshots = len(fs)
+	fids := make([]bson.ObjectId, len(fs))
+	var hs []string
+	for i, f := range fs {
+		fids[i] = f.Id
+		if f.Blob != "" {
+			hs = append(hs, f.Blob)
+		}
+	}
+	rm := bson.M{FILEID: bson.M{IN: fids}}
+	if u.Results, e = s.Count(RESULTS, rm); e != nil {
+		return nil, &GetError{"results", e, rm}
+	}
+	rm[GRIDFS] = true
+	if u.Reports, e = s.Count(RESULTS, rm); e != nil {
+		return nil, &GetError{"results", e, rm}
+	}
+	var bs []*Blob
+	bm := bson.M{ID: bson.M{IN: hs}}
+	if e = s.Find(BLOBS, bm, bson.M{SIZE: 1}, 0, nil, &bs); e != nil {
+		return nil, &GetError{"blobs", e, bm}
+	}
+	sizes := make(map[string]int, len(bs))
+	for _, b := range bs {
+		sizes[b.Id] = b.Size
+	}
+	for _, h := range hs {
+		u.Size += int64(sizes[h])
+	}
+	return u, nil
+}
+
+//ArchiveProject moves the data of the project matching pid to a compressed
+//archive in ArchiveDir. The project's submissions and the final snapshot of
+//each of their files are kept in the active database so that the project can
+//still be summarised.
+func ArchiveProject(pid bson.ObjectId) (*Archive, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	if Contains(ARCHIVES, bson.M{PROJECTID: pid}) {
+		return nil, fmt.Errorf("project %s is already archived", pid.Hex())
+	}
+	if _, e = Project(bson.M{ID: pid}, bson.M{ID: 1}); e != nil {
+		return nil, e
+	}
+	d, e := ArchiveDir()
+	if e != nil {
+		return nil, e
+	}
+	a := &Archive{Id: bson.NewObjectId(), ProjectId: pid, Path: filepath.Join(d, pid.Hex()+ARCHIVE_EXT), Time: util.CurMilis()}
+	w, e := newArchiveWriter(a.Path)
+	if e != nil {
+		return nil, e
+	}
+	ss, e := Submissions(bson.M{PROJECTID: pid}, nil)
+	if e != nil {
+		w.Abort()
+		return nil, e
+	}
+	var old []bson.ObjectId
+	for _, sub := range ss {
+		if e = w.Write(&archiveEntry{Collection: SUBMISSIONS, Doc: sub}); e != nil {
+			w.Abort()
+			return nil, e
+		}
+		fs, e := Files(bson.M{SUBID: sub.Id}, nil, 0, TIME)
+		if e != nil {
+			w.Abort()
+			return nil, e
+		}
+		for _, f := range fs {
+			if e = archiveFile(s, w, f); e != nil {
+				w.Abort()
+				return nil, e
+			}
+		}
+		old = append(old, oldSnapshots(fs)...)
+	}
+	if e = w.Close(); e != nil {
+		return nil, e
+	}
+	i, e := os.Stat(a.Path)
+	if e != nil {
+		return nil, e
+	}
+	a.Size = i.Size()
+	if e = Add(ARCHIVES, a); e != nil {
+		return nil, e
+	}
+	for _, id := range old {
+		if e = RemoveFileById(id); e != nil {
+			return nil, e
+		}
+	}
+	if _, e = RemoveOrphanBlobs(); e != nil {
+		return nil, e
+	}
+	return a, nil
+}
+
+//archiveFile writes f, its results and their reports to w.
+func archiveFile(s Repository, w *archiveWriter, f *project.File) error {
+	c := *f
+	c.Blob = ""
+	if e := w.Write(&archiveEntry{Collection: FILES, Doc: &c}); e != nil {
+		return e
+	}
+	for _, id := range f.Results {
+		if _, ok := id.(bson.ObjectId); !ok {
+			continue
+		}
+		var r bson.M
+		if e := s.FindOne(RESULTS, bson.M{ID: id}, nil, &r); e == NotFound {
+			continue
+		} else if e != nil {
+			return &GetError{"result", e, id}
+		}
+		if e := w.Write(&archiveEntry{Collection: RESULTS, Doc: r}); e != nil {
+			return e
+		}
+		if r[GRIDFS] != true {
+			continue
+		}
+		ct, d, e := s.ReadGridFile(id)
+		if e == NotFound {
+			continue
+		} else if e != nil {
+			return &GetError{"grid file", e, id}
+		}
+		if e = w.Write(&archiveEntry{Collection: GRIDFS_NAME, GridId: id, ContentType: ct, Data: d}); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+//oldSnapshots retrieves the ids of all but the final snapshot of each
+//file in fs which must be sorted by time.
+func oldSnapshots(fs []*project.File) []bson.ObjectId {
+	last := make(map[string]bson.ObjectId)
+	for _, f := range fs {
+		last[string(f.Type)+":"+f.Name] = f.Id
+	}
+	var old []bson.ObjectId
+	for _, f := range fs {
+		if last[string(f.Type)+":"+f.Name] != f.Id {
+			old = append(old, f.Id)
+		}
+	}
+	return old
+}
+
+//RestoreProject restores the data of the archived project matching pid
+//to the active database and removes its archive.
+func RestoreProject(pid bson.ObjectId) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	a, e := ProjectArchive(pid)
+	if e != nil {
+		return e
+	}
+	f, e := os.Open(a.Path)
+	if e != nil {
+		return e
+	}
+	defer f.Close()
+	r, e := gzip.NewReader(f)
+	if e != nil {
+		return e
+	}
+	defer r.Close()
+	for {
+		var ae *rawArchiveEntry
+		if e = readDoc(r, &ae); e == io.EOF {
+			break
+		} else if e != nil {
+			return e
+		}
+		if e = restoreEntry(s, ae); e != nil && e != DuplicateId {
+			return e
+		}
+	}
+	//Issues and test outcomes aren't archived so they are derived from the restored results.
+	ids, e := submissionIds(pid)
+	if e != nil {
+		return e
+	}
+	if _, e = RebuildIssues(bson.M{SUBID: bson.M{IN: ids}}); e != nil {
+		return e
+	}
+	if _, e = RebuildTestOutcomes(bson.M{SUBID: bson.M{IN: ids}}); e != nil {
+		return e
+	}
+	for _, id := range ids {
+		if e = TrackIssues(id); e != nil {
+			return e
+		}
+	}
+	if e = RemoveById(ARCHIVES, a.Id); e != nil {
+		return e
+	}
+	return os.Remove(a.Path)
+}
+
+//restoreEntry adds the document or grid file stored in ae to s if it is not already present.
+func restoreEntry(s Repository, ae *rawArchiveEntry) error {
+	switch ae.Collection {
+	case GRIDFS_NAME:
+		if _, _, e := s.ReadGridFile(ae.GridId); e == nil {
+			return nil
+		}
+		return s.WriteGridFile(ae.GridId, ae.ContentType, ae.Data)
+	case FILES:
+		var f *project.File
+		if e := ae.Doc.Unmarshal(&f); e != nil {
+			return e
+		}
+		if Contains(FILES, bson.M{ID: f.Id}) {
+			return nil
+		}
+		return AddFile(f)
+	default:
+		var d bson.M
+		if e := ae.Doc.Unmarshal(&d); e != nil {
+			return e
+		}
+		return s.Insert(ae.Collection, d)
+	}
+}
+
+//removeArchives removes the Archives and Retentions of the projects matching m.
+func removeArchives(m bson.M) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	var as []*Archive
+	if e = s.Find(ARCHIVES, m, nil, 0, nil, &as); e != nil {
+		return &GetError{"archives", e, m}
+	}
+	for _, a := range as {
+		if e = os.Remove(a.Path); e != nil && !os.IsNotExist(e) {
+			return e
+		}
+		if e = RemoveById(ARCHIVES, a.Id); e != nil {
+			return e
+		}
+	}
+	if e = s.RemoveAll(RETENTION, m); e != nil {
+		return &RemoveError{"retention", e, m}
+	}
+	return nil
+}
+
+//newArchiveWriter creates a new archive which will be stored at p once it is closed.
+func newArchiveWriter(p string) (*archiveWriter, error) {
+	if e := os.MkdirAll(filepath.Dir(p), util.DPERM); e != nil {
+		return nil, e
+	}
+	f, e := ioutil.TempFile(filepath.Dir(p), filepath.Base(p)+".")
+	if e != nil {
+		return nil, e
+	}
+	return &archiveWriter{p: p, f: f, w: gzip.NewWriter(f)}, nil
+}
+
+//Write
+func (a *archiveWriter) Write(ae *archiveEntry) error {
+	d, e := bson.Marshal(ae)
+	if e != nil {
+		return e
+	}
+	_, e = a.w.Write(d)
+	return e
+}
+
+//Close completes the archive and moves it to its path.
+//The archive is discarded if it could not be completed.
+func (a *archiveWriter) Close() error {
+	e := a.w.Close()
+	if fe := a.f.Close(); e == nil {
+		e = fe
+	}
+	if e == nil {
+		e = os.Rename(a.f.Name(), a.p)
+	}
+	if e != nil {
+		os.Remove(a.f.Name())
+	}
+	return e
+}
+
+//Abort discards the archive.
+func (a *archiveWriter) Abort() {
+	a.w.Close()
+	a.f.Close()
+	os.Remove(a.f.Name())
+}
+
+//readDoc reads a single bson document from r into ret.
+func readDoc(r io.Reader, ret interface{}) error {
+	l := make([]byte, 4)
+	if _, e := io.ReadFull(r, l); e != nil {
+		return e
+	}
+	n := int(binary.LittleEndian.Uint32(l))
+	if n < 5 {
+		return fmt.Errorf("invalid document length %d", n)
+	}
+	d := make([]byte, n)
+	copy(d, l)
+	if _, e := io.ReadFull(r, d[4:]); e != nil {
+		return e
+	}
+	return bson.Unmarshal(d, ret)
+}
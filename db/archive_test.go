@@ -0,0 +1,174 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestArchiveProject(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	d, e := ioutil.TempDir("", "archives")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(d)
+	SetArchiveDir(d)
+	defer SetArchiveDir("")
+	p := project.New("Triangle", "user", "Java", "")
+	if e = Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	s := project.NewSubmission(p.Id, "user", project.FILE_MODE, 1000)
+	if e = Add(SUBMISSIONS, s); e != nil {
+		t.Error(e)
+	}
+	fs := make([]*project.File, 3)
+	for i := range fs {
+		fs[i], e = project.NewFile(s.Id, fileInfo, append(fileData, bytes.Repeat([]byte("//Edited\n"), i)...))
+		if e != nil {
+			t.Error(e)
+		}
+		fs[i].Time += int64(i)
+		if e = AddFile(fs[i]); e != nil {
+			t.Error(e)
+		}
+		if e = AddResult(javacResult(fs[i].Id, true), "javac"); e != nil {
+			t.Error(e)
+		}
+	}
+	u, e := ProjectUsage(p.Id)
+	if e != nil {
+		t.Error(e)
+	}
+	if u.Submissions != 1 || u.Snapshots != 3 || u.Results != 3 || u.Reports != 3 {
+		t.Errorf("Invalid usage %v.", u)
+	}
+	a, e := ArchiveProject(p.Id)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if _, e = ArchiveProject(p.Id); e == nil {
+		t.Error("Expected error archiving archived project.")
+	}
+	if is, e := ioutil.ReadDir(d); e != nil {
+		t.Error(e)
+	} else if len(is) != 1 || filepath.Join(d, is[0].Name()) != a.Path {
+		t.Errorf("Expected only archive %s in %s but found %d files.", a.Path, d, len(is))
+	}
+	if c, _ := Count(FILES, bson.M{SUBID: s.Id}); c != 1 {
+		t.Errorf("Expected 1 file after archiving but found %d.", c)
+	}
+	if c, _ := Count(RESULTS, bson.M{}); c != 1 {
+		t.Errorf("Expected 1 result after archiving but found %d.", c)
+	}
+	if !Contains(FILES, bson.M{ID: fs[2].Id}) {
+		t.Error("Final snapshot not kept.")
+	}
+	if e = RestoreProject(p.Id); e != nil {
+		t.Fatal(e)
+	}
+	if Contains(ARCHIVES, bson.M{PROJECTID: p.Id}) {
+		t.Error("Archive not removed.")
+	}
+	ls, e := Files(bson.M{SUBID: s.Id}, nil, 0, TIME)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(ls) != len(fs) {
+		t.Fatalf("Expected %d files after restoring but found %d.", len(fs), len(ls))
+	}
+	for i, f := range ls {
+		if !bytes.Equal(f.Data, fs[i].Data) {
+			t.Errorf("Data not equal for snapshot %d.", i)
+		}
+		id, _ := f.Results["javac"].(bson.ObjectId)
+		r, e := JavacResult(bson.M{ID: id}, nil)
+		if e != nil {
+			t.Error(e)
+		} else if r.Report == nil {
+			t.Errorf("Report not restored for snapshot %d.", i)
+		}
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	d, e := ioutil.TempDir("", "archives")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(d)
+	SetArchiveDir(d)
+	defer SetArchiveDir("")
+	ps := []*project.Project{project.New("Old", "user", "Java", ""), project.New("New", "user", "Java", "")}
+	ts := []int64{1000, util.CurMilis()}
+	for i, p := range ps {
+		if e = Add(PROJECTS, p); e != nil {
+			t.Error(e)
+		}
+		s := project.NewSubmission(p.Id, "user", project.FILE_MODE, ts[i])
+		if e = Add(SUBMISSIONS, s); e != nil {
+			t.Error(e)
+		}
+		f, e := project.NewFile(s.Id, fileInfo, fileData)
+		if e != nil {
+			t.Error(e)
+		}
+		f.Time = ts[i]
+		if e = AddFile(f); e != nil {
+			t.Error(e)
+		}
+		if e = SetRetention(p.Id, 30); e != nil {
+			t.Error(e)
+		}
+	}
+	ids, e := ApplyRetention()
+	if e != nil {
+		t.Error(e)
+	}
+	if len(ids) != 1 || ids[0] != ps[0].Id {
+		t.Errorf("Expected only %s to be archived but got %v.", ps[0].Id, ids)
+	}
+}
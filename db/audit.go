@@ -0,0 +1,113 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Audit records a change made to Impendulo's data by a user.
+	//Audits are only ever added, never modified or removed, so that they
+	//form a complete trail of administrative actions.
+	//Before and After contain the values of the changed fields of the
+	//Target document in Collection before and after the change.
+	Audit struct {
+		Id         bson.ObjectId `bson:"_id"`
+		User       string        `bson:"user"`
+		Action     string        `bson:"action"`
+		Collection string        `bson:"collection,omitempty"`
+		Target     interface{}   `bson:"target,omitempty"`
+		Before     bson.M        `bson:"before,omitempty"`
+		After      bson.M        `bson:"after,omitempty"`
+		Time       int64         `bson:"time"`
+	}
+)
+
+//NewAudit
+func NewAudit(u, action, n string, target interface{}, before, after bson.M) *Audit {
+	return &Audit{
+		Id: bson.NewObjectId(), User: u, Action: action, Collection: n,
+		Target: target, Before: before, After: after, Time: util.CurMilis(),
+	}
+}
+
+//AddAudit adds a to the audit trail.
+func AddAudit(a *Audit) error {
+	return Add(AUDITS, a)
+}
+
+//Audits retrieves up to limit Audits matching m, most recent first.
+func Audits(m, sl interface{}, limit int) ([]*Audit, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var as []*Audit
+	if e = s.Find(AUDITS, m, sl, limit, []string{"-" + TIME}, &as); e != nil {
+		return nil, &GetError{"audits", e, m}
+	}
+	return as, nil
+}
+
+//AuditedUpdate sets the fields in sm on the document in collection n matching id
+//and records the change, made by user u via action, in the audit trail.
+func AuditedUpdate(u, action, n string, id interface{}, sm bson.M) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	m := bson.M{ID: id}
+	sl := bson.M{}
+	for k := range sm {
+		sl[k] = 1
+	}
+	var before bson.M
+	if e = s.FindOne(n, m, sl, &before); e != nil {
+		return &GetError{n, e, m}
+	}
+	delete(before, ID)
+	if e = Update(n, m, bson.M{SET: sm}); e != nil {
+		return e
+	}
+	return AddAudit(NewAudit(u, action, n, id, before, sm))
+}
+
+//AuditDocument retrieves the document in collection n matching id so that it can be
+//recorded as the Before or After of an Audit. File data and user credentials are excluded.
+func AuditDocument(n string, id interface{}) (bson.M, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	m := bson.M{ID: id}
+	var d bson.M
+	if e = s.FindOne(n, m, bson.M{DATA: 0, PWORD: 0, SALT: 0}, &d); e != nil {
+		return nil, &GetError{n, e, m}
+	}
+	delete(d, ID)
+	return d, nil
+}
@@ -0,0 +1,104 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/user"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestAuditedUpdate(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	p := project.New("Triangle", "user", "Java", "")
+	if e = Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	if e = AuditedUpdate("admin", "editproject", PROJECTS, p.Id, bson.M{NAME: "Square"}); e != nil {
+		t.Error(e)
+	}
+	if n, _ := ProjectName(p.Id); n != "Square" {
+		t.Errorf("Expected project name Square but got %s.", n)
+	}
+	if e = AddAudit(NewAudit("teacher", "runtools", PROJECTS, p.Id, nil, nil)); e != nil {
+		t.Error(e)
+	}
+	as, e := Audits(bson.M{USER: "admin"}, nil, 0)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(as) != 1 {
+		t.Fatalf("Expected 1 audit but found %d.", len(as))
+	}
+	a := as[0]
+	if a.Action != "editproject" || a.Collection != PROJECTS || a.Target != p.Id {
+		t.Errorf("Invalid audit %v.", a)
+	}
+	if a.Before[NAME] != "Triangle" || a.After[NAME] != "Square" {
+		t.Errorf("Invalid audit values %v -> %v.", a.Before, a.After)
+	}
+	if as, _ = Audits(bson.M{TARGET: p.Id}, nil, 0); len(as) != 2 {
+		t.Errorf("Expected 2 audits for project but found %d.", len(as))
+	}
+	if e = AuditedUpdate("admin", "editproject", PROJECTS, bson.NewObjectId(), bson.M{NAME: "Circle"}); e == nil {
+		t.Error("Expected error updating non-existent project.")
+	}
+}
+
+func TestAuditDocument(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	u := user.New("student", "secret")
+	if e = Add(USERS, u); e != nil {
+		t.Error(e)
+	}
+	d, e := AuditDocument(USERS, u.Name)
+	if e != nil {
+		t.Error(e)
+	}
+	if _, ok := d[PWORD]; ok {
+		t.Error("Password recorded in audit document.")
+	}
+	if _, ok := d[ACCESS]; !ok {
+		t.Errorf("Invalid audit document %v.", d)
+	}
+	if e = RemoveById(USERS, u.Name); e != nil {
+		t.Error(e)
+	}
+	if _, e = AuditDocument(USERS, u.Name); e == nil {
+		t.Error("Expected error retrieving removed user.")
+	}
+}
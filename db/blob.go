@@ -0,0 +1,420 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+)
+
+type (
+	//Blob stores the compressed contents of a file snapshot.
+	//Blobs are content addressed by the SHA-1 hash of their uncompressed
+	//contents so identical snapshots share a single Blob.
+	//A Blob with a Base stores a delta against the contents of its Base
+	//instead of its full contents. Time is when the Blob was last stored or reused.
+	Blob struct {
+		Id    string `bson:"_id"`
+		Base  string `bson:"base,omitempty"`
+		Depth int    `bson:"depth"`
+		Size  int    `bson:"size"`
+		Time  int64  `bson:"time,omitempty"`
+		Data  []byte `bson:"data"`
+	}
+)
+
+const (
+	//DELTA_DEPTH is the default maximum length of a chain of delta encoded blobs.
+	DELTA_DEPTH = 16
+	//BLOB_GRACE is the default time in milliseconds for which a Blob which has been
+	//stored or reused is kept even if no file refers to it yet.
+	BLOB_GRACE = 60 * 60 * 1000
+)
+
+var (
+	deltaDepth = DELTA_DEPTH
+	blobGrace  = int64(BLOB_GRACE)
+)
+
+//SetDeltaDepth sets the maximum length of a chain of delta encoded blobs.
+//A depth of 0 disables delta encoding.
+func SetDeltaDepth(d int) {
+	deltaDepth = d
+}
+
+//SetBlobGrace sets the time in milliseconds for which unreferenced Blobs are kept
+//after they have been stored or reused.
+func SetBlobGrace(g int64) {
+	blobGrace = g
+}
+
+//BlobHash calculates the hash used to address d.
+func BlobHash(d []byte) string {
+	h := sha1.New()
+	h.Write(d)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//AddBlob stores d in a Blob if it is not already present and returns its hash.
+//If base is the hash of an existing Blob, d is stored as a delta against it
+//when this is worthwhile. Blobs which are reused are touched before they are used
+//so that RemoveOrphanBlobs keeps them until the caller has referred to them.
+func AddBlob(d []byte, base string) (string, error) {
+	h := BlobHash(d)
+	if touchBlob(h) == nil {
+		return h, nil
+	}
+	b := &Blob{Id: h, Size: len(d), Time: util.CurMilis()}
+	c := d
+	if base != "" && base != h && deltaDepth > 0 && touchBlob(base) == nil {
+		if bb, e := blob(base, nil); e == nil && bb.Depth < deltaDepth {
+			if dl := delta(bb.Data, d); len(dl) < len(d)/2 {
+				c = dl
+				b.Base = base
+				b.Depth = bb.Depth + 1
+			}
+		}
+	}
+	var e error
+	if b.Data, e = compress(c); e != nil {
+		return "", &AddError{"blob " + h, e}
+	}
+	s, e := Active()
+	if e != nil {
+		return "", e
+	}
+	if e = s.Insert(BLOBS, b); e != nil && e != DuplicateId {
+		return "", &AddError{BLOBS, e}
+	}
+	return h, nil
+}
+
+//touchBlob marks the Blob matching h and the Blobs it is delta encoded against
+//as recently used. It fails if any of them are no longer present.
+func touchBlob(h string) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	t := util.CurMilis()
+	for h != "" {
+		if e = s.Update(BLOBS, bson.M{ID: h}, bson.M{SET: bson.M{TIME: t}}); e != nil {
+			return &GetError{"blob", e, h}
+		}
+		var b *Blob
+		if e = s.FindOne(BLOBS, bson.M{ID: h}, bson.M{BASE: 1}, &b); e != nil {
+			return &GetError{"blob", e, h}
+		}
+		h = b.Base
+	}
+	return nil
+}
+
+//BlobData retrieves the uncompressed contents of the Blob matching h.
+func BlobData(h string) ([]byte, error) {
+	b, e := blob(h, nil)
+	if e != nil {
+		return nil, e
+	}
+	return b.Data, nil
+}
+
+//blob retrieves the Blob matching h with its Data decompressed and, if it is
+//a delta, applied to its Base. Blobs which have already been retrieved
+//are looked up in c if it is not nil.
+func blob(h string, c map[string]*Blob) (*Blob, error) {
+	if b, ok := c[h]; ok {
+		return b, nil
+	}
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var b *Blob
+	if e = s.FindOne(BLOBS, bson.M{ID: h}, nil, &b); e != nil {
+		return nil, &GetError{"blob", e, h}
+	}
+	if b.Data, e = decompress(b.Data); e != nil {
+		return nil, &GetError{"blob", e, h}
+	}
+	if b.Base != "" {
+		bb, e := blob(b.Base, c)
+		if e != nil {
+			return nil, e
+		}
+		if b.Data, e = applyDelta(bb.Data, b.Data); e != nil {
+			return nil, &GetError{"blob", e, h}
+		}
+	}
+	if c != nil {
+		c[h] = b
+	}
+	return b, nil
+}
+
+//AddFile stores f's data in a Blob and adds f to the active database.
+//The data is delta encoded against the previous snapshot of f if one exists.
+func AddFile(f *project.File) error {
+	if f.Data == nil {
+		return Add(FILES, f)
+	}
+	h, e := AddBlob(f.Data, previousBlob(f))
+	if e != nil {
+		return e
+	}
+	c := *f
+	c.Data = nil
+	c.Blob = h
+	if e = Add(FILES, &c); e != nil {
+		return e
+	}
+	f.Blob = h
+	return nil
+}
+
+//UpdateFileData stores f's data in a new Blob and updates f in the active database
+//to refer to it. Any other changes specified by c are also applied.
+func UpdateFileData(f *project.File, c bson.M) error {
+	h, e := AddBlob(f.Data, f.Blob)
+	if e != nil {
+		return e
+	}
+	if c == nil {
+		c = bson.M{}
+	}
+	c[BLOB] = h
+	if e = Update(FILES, bson.M{ID: f.Id}, bson.M{SET: c, UNSET: bson.M{DATA: ""}}); e != nil {
+		return e
+	}
+	f.Blob = h
+	return nil
+}
+
+//previousBlob retrieves the Blob hash of the snapshot preceding f.
+func previousBlob(f *project.File) string {
+	m := bson.M{SUBID: f.SubId, NAME: f.Name, TYPE: f.Type, TIME: bson.M{LTE: f.Time}, BLOB: bson.M{EXISTS: true}}
+	fs, e := Files(m, bson.M{BLOB: 1}, 1, "-"+TIME)
+	if e != nil || len(fs) == 0 {
+		return ""
+	}
+	return fs[0].Blob
+}
+
+//loadData loads f's data from its Blob if it has not been loaded.
+func loadData(f *project.File, c map[string]*Blob) error {
+	if f.Data != nil || f.Blob == "" {
+		return nil
+	}
+	b, e := blob(f.Blob, c)
+	if e != nil {
+		return e
+	}
+	f.Data = b.Data
+	return nil
+}
+
+//dataSelector adapts a file selector so that a file's Blob is retrieved
+//whenever its data is. It also reports whether the selector retrieves data.
+func dataSelector(sl interface{}) (interface{}, bool) {
+	m, ok := sl.(bson.M)
+	if !ok || len(m) == 0 {
+		return sl, sl == nil || ok
+	}
+	if v, ok := m[DATA]; ok {
+		a := bson.M{BLOB: v}
+		for k, v := range m {
+			a[k] = v
+		}
+		return a, v != 0
+	}
+	for _, v := range m {
+		if v != 0 {
+			return sl, false
+		}
+	}
+	return sl, true
+}
+
+//MigrateFiles moves the data of files which are stored inline into Blobs.
+//The ids of the files are collected before any of them are migrated so that
+//updating the files does not affect which files are visited.
+//It returns the number of files migrated.
+func MigrateFiles() (int, error) {
+	s, e := Active()
+	if e != nil {
+		return 0, e
+	}
+	m := bson.M{DATA: bson.M{EXISTS: true}}
+	var ids []*project.File
+	if e = s.Find(FILES, m, bson.M{ID: 1}, 0, []string{SUBID, NAME, TIME}, &ids); e != nil {
+		return 0, &GetError{"files", e, m}
+	}
+	var p *project.File
+	for i, id := range ids {
+		var f *project.File
+		if e = s.FindOne(FILES, bson.M{ID: id.Id}, bson.M{SUBID: 1, NAME: 1, TYPE: 1, DATA: 1}, &f); e != nil {
+			return i, &GetError{"file", e, id.Id}
+		}
+		var base string
+		if p != nil && p.SubId == f.SubId && p.Name == f.Name && p.Type == f.Type {
+			base = p.Blob
+		}
+		if f.Blob, e = AddBlob(f.Data, base); e != nil {
+			return i, e
+		}
+		if e = Update(FILES, bson.M{ID: f.Id}, bson.M{SET: bson.M{BLOB: f.Blob}, UNSET: bson.M{DATA: ""}}); e != nil {
+			return i, e
+		}
+		f.Data = nil
+		p = f
+	}
+	return len(ids), nil
+}
+
+//RemoveOrphanBlobs removes all Blobs which are neither referred to by a file,
+//including files in the trash, nor used as the base of such a Blob. Blobs which
+//have been stored or reused within the grace period are kept, along with their bases,
+//since the files referring to them may not have been added yet. It returns the number of Blobs removed.
+func RemoveOrphanBlobs() (int, error) {
+	s, e := Active()
+	if e != nil {
+		return 0, e
+	}
+	old := util.CurMilis() - blobGrace
+	var bs []*Blob
+	if e = s.Find(BLOBS, bson.M{}, bson.M{ID: 1, BASE: 1, TIME: 1}, 0, nil, &bs); e != nil {
+		return 0, &GetError{"blobs", e, bson.M{}}
+	}
+	m := bson.M{BLOB: bson.M{EXISTS: true}}
+	var hs []string
+	if e = s.Distinct(FILES, m, BLOB, &hs); e != nil {
+		return 0, &GetError{"blob hashes", e, m}
+	}
+	tm := bson.M{COLLECTION: FILES, DOC + "." + BLOB: bson.M{EXISTS: true}}
+	var ths []string
+	if e = s.Distinct(TRASHED, tm, DOC+"."+BLOB, &ths); e != nil {
+		return 0, &GetError{"blob hashes", e, tm}
+	}
+	hs = append(hs, ths...)
+	bases := make(map[string]string, len(bs))
+	for _, b := range bs {
+		bases[b.Id] = b.Base
+		if b.Time > old {
+			hs = append(hs, b.Id)
+		}
+	}
+	used := make(map[string]bool, len(hs))
+	for _, h := range hs {
+		for ; h != "" && !used[h]; h = bases[h] {
+			used[h] = true
+		}
+	}
+	n := 0
+	for _, b := range bs {
+		if used[b.Id] {
+			continue
+		}
+		//Blobs touched since they were listed are no longer old enough to be removed.
+		if e = s.Remove(BLOBS, bson.M{ID: b.Id, TIME: bson.M{NOT: bson.M{GT: old}}}); e == NotFound {
+			continue
+		} else if e != nil {
+			return n, &RemoveError{BLOBS, e, b.Id}
+		}
+		n++
+	}
+	return n, nil
+}
+
+//compress compresses d using zlib.
+func compress(d []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	if _, e := w.Write(d); e != nil {
+		return nil, e
+	}
+	if e := w.Close(); e != nil {
+		return nil, e
+	}
+	return b.Bytes(), nil
+}
+
+//decompress decompresses zlib compressed data.
+func decompress(d []byte) ([]byte, error) {
+	r, e := zlib.NewReader(bytes.NewReader(d))
+	if e != nil {
+		return nil, e
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+//delta calculates a delta which transforms base into d.
+//Snapshots usually differ from their predecessors in a single region so
+//the delta consists of the lengths of the prefix and suffix d shares
+//with base, encoded as uvarints, followed by the bytes in between them.
+func delta(base, d []byte) []byte {
+	p := 0
+	for p < len(base) && p < len(d) && base[p] == d[p] {
+		p++
+	}
+	s := 0
+	for s < len(base)-p && s < len(d)-p && base[len(base)-1-s] == d[len(d)-1-s] {
+		s++
+	}
+	b := make([]byte, 2*binary.MaxVarintLen64, 2*binary.MaxVarintLen64+len(d)-p-s)
+	n := binary.PutUvarint(b, uint64(p))
+	n += binary.PutUvarint(b[n:], uint64(s))
+	return append(b[:n], d[p:len(d)-s]...)
+}
+
+//applyDelta reconstructs data from base and a delta calculated by delta.
+func applyDelta(base, dl []byte) ([]byte, error) {
+	p, n := binary.Uvarint(dl)
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid delta prefix")
+	}
+	s, m := binary.Uvarint(dl[n:])
+	if m <= 0 {
+		return nil, fmt.Errorf("invalid delta suffix")
+	}
+	if p+s > uint64(len(base)) {
+		return nil, fmt.Errorf("delta of length %d does not match base of length %d", p+s, len(base))
+	}
+	mid := dl[n+m:]
+	d := make([]byte, 0, int(p)+len(mid)+int(s))
+	d = append(d, base[:p]...)
+	d = append(d, mid...)
+	return append(d, base[uint64(len(base))-s:]...), nil
+}
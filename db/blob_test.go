@@ -0,0 +1,189 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"bytes"
+
+	"github.com/godfried/impendulo/project"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestDelta(t *testing.T) {
+	bases := [][]byte{[]byte{}, fileData, grandPrix, []byte("public class A{}")}
+	ds := [][]byte{[]byte{}, fileData, grandPrix, []byte("public class B{}"), append(fileData, grandPrix...)}
+	for _, b := range bases {
+		for _, d := range ds {
+			dl := delta(b, d)
+			r, e := applyDelta(b, dl)
+			if e != nil {
+				t.Error(e)
+			}
+			if !bytes.Equal(r, d) {
+				t.Errorf("Delta of %q against %q produced %q.", d, b, r)
+			}
+		}
+	}
+	if _, e := applyDelta([]byte("short"), delta(fileData, fileData)); e == nil {
+		t.Error("Expected error applying delta to wrong base.")
+	}
+}
+
+func TestCompress(t *testing.T) {
+	for _, d := range [][]byte{[]byte{}, fileData, grandPrix} {
+		c, e := compress(d)
+		if e != nil {
+			t.Error(e)
+		}
+		r, e := decompress(c)
+		if e != nil {
+			t.Error(e)
+		}
+		if !bytes.Equal(r, d) {
+			t.Error("Decompressed data not equal.")
+		}
+	}
+}
+
+func TestBlobFiles(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	sid := bson.NewObjectId()
+	fs := make([]*project.File, 5)
+	for i := range fs {
+		fs[i], e = project.NewFile(sid, fileInfo, append(fileData, bytes.Repeat([]byte("//Edited\n"), i/2)...))
+		if e != nil {
+			t.Error(e)
+		}
+		fs[i].Time += int64(i)
+		if e = AddFile(fs[i]); e != nil {
+			t.Error(e)
+		}
+	}
+	if fs[0].Blob != fs[1].Blob {
+		t.Error("Identical snapshots not deduplicated.")
+	}
+	c, e := Count(BLOBS, bson.M{})
+	if e != nil {
+		t.Error(e)
+	}
+	if c != 3 {
+		t.Errorf("Expected 3 blobs but found %d.", c)
+	}
+	ls, e := Files(bson.M{SUBID: sid}, nil, 0, TIME)
+	if e != nil {
+		t.Error(e)
+	}
+	for i, f := range ls {
+		if !bytes.Equal(f.Data, fs[i].Data) {
+			t.Errorf("Data not equal for snapshot %d.", i)
+		}
+	}
+	f, e := File(bson.M{ID: fs[4].Id}, bson.M{DATA: 0})
+	if e != nil {
+		t.Error(e)
+	}
+	if f.Data != nil {
+		t.Error("Data retrieved when not selected.")
+	}
+	f, e = File(bson.M{ID: fs[4].Id}, bson.M{DATA: 1})
+	if e != nil {
+		t.Error(e)
+	}
+	if !bytes.Equal(f.Data, fs[4].Data) {
+		t.Error("Selected data not equal.")
+	}
+}
+
+func TestMigrateFiles(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	f, e := project.NewFile(bson.NewObjectId(), fileInfo, fileData)
+	if e != nil {
+		t.Error(e)
+	}
+	if e = Add(FILES, f); e != nil {
+		t.Error(e)
+	}
+	n, e := MigrateFiles()
+	if e != nil {
+		t.Error(e)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 migrated file but got %d.", n)
+	}
+	if Contains(FILES, bson.M{DATA: bson.M{EXISTS: true}}) {
+		t.Error("File data not migrated.")
+	}
+	m, e := File(bson.M{ID: f.Id}, nil)
+	if e != nil {
+		t.Error(e)
+	}
+	if !bytes.Equal(m.Data, f.Data) {
+		t.Error("Migrated data not equal.")
+	}
+}
+
+func TestRemoveOrphanBlobs(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	base, e := AddBlob(fileData, "")
+	if e != nil {
+		t.Error(e)
+	}
+	h, e := AddBlob(append(fileData, []byte("//Edited\n")...), base)
+	if e != nil {
+		t.Error(e)
+	}
+	n, e := RemoveOrphanBlobs()
+	if e != nil {
+		t.Error(e)
+	}
+	if n != 0 || !Contains(BLOBS, bson.M{ID: h}) || !Contains(BLOBS, bson.M{ID: base}) {
+		t.Error("Recently stored blobs removed.")
+	}
+	SetBlobGrace(0)
+	defer SetBlobGrace(BLOB_GRACE)
+	if n, e = RemoveOrphanBlobs(); e != nil {
+		t.Error(e)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 removed blobs but got %d.", n)
+	}
+}
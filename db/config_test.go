@@ -36,11 +36,10 @@ import (
 func TestJUnitTest(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	test := junit.NewTest(bson.NewObjectId(), "name", junit.DEFAULT, &tool.Target{}, junitData, junitData)
 	if e = AddJUnitTest(test); e != nil {
 		t.Error(e)
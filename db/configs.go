@@ -25,6 +25,14 @@
 package db
 
 import (
+	"github.com/godfried/impendulo/tool/benchmark"
+	"github.com/godfried/impendulo/tool/checkstyle"
+	"github.com/godfried/impendulo/tool/diagnostic"
+	"github.com/godfried/impendulo/tool/external"
+	"github.com/godfried/impendulo/tool/findbugs"
+	"github.com/godfried/impendulo/tool/gcc"
+	"github.com/godfried/impendulo/tool/iotest"
+	"github.com/godfried/impendulo/tool/javac"
 	"github.com/godfried/impendulo/tool/jpf"
 	"github.com/godfried/impendulo/tool/junit"
 	mk "github.com/godfried/impendulo/tool/make"
@@ -34,13 +42,12 @@ import (
 
 //JPFConfig retrieves a JPF configuration matching m from the active database.
 func JPFConfig(m, sl interface{}) (*jpf.Config, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var c *jpf.Config
-	if e = s.DB("").C(JPF).Find(m).Select(sl).One(&c); e != nil {
+	if e = s.FindOne(JPF, m, sl, &c); e != nil {
 		return nil, &GetError{"jpf config file", e, m}
 	}
 	return c, nil
@@ -48,28 +55,64 @@ func JPFConfig(m, sl interface{}) (*jpf.Config, error) {
 
 //AddJPF overwrites a project's JPF configuration with the provided configuration.
 func AddJPFConfig(cfg *jpf.Config) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	c := s.DB("").C(JPF)
-	c.RemoveAll(bson.M{PROJECTID: cfg.ProjectId})
-	if e = c.Insert(cfg); e != nil {
+	s.RemoveAll(JPF, replaced(cfg.ProjectId, cfg.Pending))
+	if e = s.Insert(JPF, cfg); e != nil {
 		return &AddError{cfg.String(), e}
 	}
 	return nil
 }
 
+//FindbugsConfig retrieves a Findbugs configuration matching m from the active database.
+func FindbugsConfig(m, sl interface{}) (*findbugs.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var c *findbugs.Config
+	if e = s.FindOne(FINDBUGS, m, sl, &c); e != nil {
+		return nil, &GetError{"findbugs config", e, m}
+	}
+	return c, nil
+}
+
+//FindbugsConfigs retrieves all Findbugs configurations matching m from the active database.
+func FindbugsConfigs(m, sl interface{}) ([]*findbugs.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var cs []*findbugs.Config
+	if e = s.Find(FINDBUGS, m, sl, 0, nil, &cs); e != nil {
+		return nil, &GetError{"findbugs configs", e, m}
+	}
+	return cs, nil
+}
+
+//AddFindbugsConfig overwrites a project's Findbugs configuration with the provided configuration.
+func AddFindbugsConfig(c *findbugs.Config) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(FINDBUGS, bson.M{PROJECTID: c.ProjectId})
+	if e = s.Insert(FINDBUGS, c); e != nil {
+		return &AddError{c.String(), e}
+	}
+	return nil
+}
+
 //PMDRules retrieves PMD rules matching m from the db.
 func PMDRules(m, sl interface{}) (*pmd.Rules, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *pmd.Rules
-	if e = s.DB("").C(PMD).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(PMD, m, sl, &r); e != nil {
 		return nil, &GetError{"pmd rules", e, m}
 	}
 	return r, nil
@@ -77,29 +120,64 @@ func PMDRules(m, sl interface{}) (*pmd.Rules, error) {
 
 //AddPMDRules overwrites a project's current PMD rules with the provided rules.
 func AddPMDRules(r *pmd.Rules) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	c := s.DB("").C(PMD)
-	m := bson.M{PROJECTID: r.ProjectId}
-	c.RemoveAll(m)
-	if e = c.Insert(r); e != nil {
+	s.RemoveAll(PMD, bson.M{PROJECTID: r.ProjectId})
+	if e = s.Insert(PMD, r); e != nil {
 		return &AddError{"pmd rules", e}
 	}
 	return nil
 }
 
+//CheckstyleConfig retrieves a Checkstyle configuration matching m from the db.
+func CheckstyleConfig(m, sl interface{}) (*checkstyle.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var c *checkstyle.Config
+	if e = s.FindOne(CHECKSTYLE, m, sl, &c); e != nil {
+		return nil, &GetError{"checkstyle config", e, m}
+	}
+	return c, nil
+}
+
+//CheckstyleConfigs retrieves all Checkstyle configurations matching m from the db.
+func CheckstyleConfigs(m, sl interface{}) ([]*checkstyle.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var cs []*checkstyle.Config
+	if e = s.Find(CHECKSTYLE, m, sl, 0, nil, &cs); e != nil {
+		return nil, &GetError{"checkstyle configs", e, m}
+	}
+	return cs, nil
+}
+
+//AddCheckstyleConfig overwrites a project's current Checkstyle configuration with the provided configuration.
+func AddCheckstyleConfig(c *checkstyle.Config) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(CHECKSTYLE, bson.M{PROJECTID: c.ProjectId})
+	if e = s.Insert(CHECKSTYLE, c); e != nil {
+		return &AddError{"checkstyle config", e}
+	}
+	return nil
+}
+
 //JUnitTest retrieves a test matching the m from the active database.
 func JUnitTest(m, sl interface{}) (*junit.Test, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var t *junit.Test
-	if e = s.DB("").C(TESTS).Find(m).Select(sl).One(&t); e != nil {
+	if e = s.FindOne(TESTS, m, sl, &t); e != nil {
 		return nil, &GetError{"test", e, m}
 	}
 	return t, nil
@@ -107,13 +185,12 @@ func JUnitTest(m, sl interface{}) (*junit.Test, error) {
 
 //JUnitTests retrieves all tests matching m from the active database.
 func JUnitTests(m, sl interface{}) ([]*junit.Test, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var t []*junit.Test
-	if e = s.DB("").C(TESTS).Find(m).Select(sl).All(&t); e != nil {
+	if e = s.Find(TESTS, m, sl, 0, nil, &t); e != nil {
 		return nil, &GetError{"tests", e, m}
 	}
 	return t, nil
@@ -121,43 +198,244 @@ func JUnitTests(m, sl interface{}) ([]*junit.Test, error) {
 
 //AddJUnitTest overwrites one of a project's JUnit tests with the new JUnit test
 //if it has the same name as the new test. Otherwise the new test is just added to the project's tests.
+//A pending test only overwrites a pending test, the live test is replaced when it is published.
 func AddJUnitTest(t *junit.Test) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	c := s.DB("").C(TESTS)
-	c.RemoveAll(bson.M{PROJECTID: t.ProjectId, NAME: t.Name})
-	if e = c.Insert(t); e != nil {
+	m := replaced(t.ProjectId, t.Pending)
+	m[NAME] = t.Name
+	s.RemoveAll(TESTS, m)
+	if e = s.Insert(TESTS, t); e != nil {
 		return &AddError{t.Name, e}
 	}
 	return nil
 }
 
 func Makefile(m, sl interface{}) (*mk.Makefile, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var mf *mk.Makefile
-	if e = s.DB("").C(MAKE).Find(m).Select(sl).One(&mf); e != nil {
+	if e = s.FindOne(MAKE, m, sl, &mf); e != nil {
 		return nil, &GetError{"makefile", e, m}
 	}
 	return mf, nil
 }
 
 func AddMakefile(mf *mk.Makefile) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	c := s.DB("").C(MAKE)
-	c.RemoveAll(bson.M{PROJECTID: mf.ProjectId})
-	if e = c.Insert(mf); e != nil {
+	s.RemoveAll(MAKE, replaced(mf.ProjectId, mf.Pending))
+	if e = s.Insert(MAKE, mf); e != nil {
 		return &AddError{"makefile", e}
 	}
 	return nil
 }
+
+//JavacConfig retrieves a javac configuration matching m from the active database.
+func JavacConfig(m, sl interface{}) (*javac.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var c *javac.Config
+	if e = s.FindOne(JAVAC, m, sl, &c); e != nil {
+		return nil, &GetError{"javac config", e, m}
+	}
+	return c, nil
+}
+
+//JavacConfigs retrieves all javac configurations matching m from the active database.
+func JavacConfigs(m, sl interface{}) ([]*javac.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var cs []*javac.Config
+	if e = s.Find(JAVAC, m, sl, 0, nil, &cs); e != nil {
+		return nil, &GetError{"javac configs", e, m}
+	}
+	return cs, nil
+}
+
+//AddJavacConfig overwrites a project's javac configuration with the provided configuration.
+func AddJavacConfig(c *javac.Config) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(JAVAC, replaced(c.ProjectId, c.Pending))
+	if e = s.Insert(JAVAC, c); e != nil {
+		return &AddError{c.String(), e}
+	}
+	return nil
+}
+
+//GCCConfig retrieves a gcc configuration matching m from the active database.
+func GCCConfig(m, sl interface{}) (*gcc.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var c *gcc.Config
+	if e = s.FindOne(GCC, m, sl, &c); e != nil {
+		return nil, &GetError{"gcc config", e, m}
+	}
+	return c, nil
+}
+
+//GCCConfigs retrieves all gcc configurations matching m from the active database.
+func GCCConfigs(m, sl interface{}) ([]*gcc.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var cs []*gcc.Config
+	if e = s.Find(GCC, m, sl, 0, nil, &cs); e != nil {
+		return nil, &GetError{"gcc configs", e, m}
+	}
+	return cs, nil
+}
+
+//AddGCCConfig overwrites a project's gcc configuration with the provided configuration.
+func AddGCCConfig(c *gcc.Config) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(GCC, replaced(c.ProjectId, c.Pending))
+	if e = s.Insert(GCC, c); e != nil {
+		return &AddError{c.String(), e}
+	}
+	return nil
+}
+
+//Explanations retrieves all compiler explanations matching m from the active database
+//in the order they were added.
+func Explanations(m, sl interface{}) ([]*diagnostic.Explanation, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var xs []*diagnostic.Explanation
+	if e = s.Find(EXPLANATIONS, m, sl, 0, []string{TIME}, &xs); e != nil {
+		return nil, &GetError{"compiler explanations", e, m}
+	}
+	return xs, nil
+}
+
+//AddExplanation overwrites a project's compiler explanation if it has the same
+//kind as the new explanation. Otherwise the explanation is just added to the project's catalogue.
+func AddExplanation(x *diagnostic.Explanation) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(EXPLANATIONS, bson.M{PROJECTID: x.ProjectId, KIND: x.Kind})
+	if e = s.Insert(EXPLANATIONS, x); e != nil {
+		return &AddError{x.Kind, e}
+	}
+	return nil
+}
+
+//ExternalTool retrieves an external tool configuration matching m from the active database.
+func ExternalTool(m, sl interface{}) (*external.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var c *external.Config
+	if e = s.FindOne(EXTERNAL, m, sl, &c); e != nil {
+		return nil, &GetError{"external tool", e, m}
+	}
+	return c, nil
+}
+
+//ExternalTools retrieves all external tool configurations matching m from the active database.
+func ExternalTools(m, sl interface{}) ([]*external.Config, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var cs []*external.Config
+	if e = s.Find(EXTERNAL, m, sl, 0, []string{NAME}, &cs); e != nil {
+		return nil, &GetError{"external tools", e, m}
+	}
+	return cs, nil
+}
+
+//AddExternalTool overwrites a project's external tool configuration if it has the same
+//name as the new configuration. Otherwise the configuration is just added to the project's tools.
+func AddExternalTool(c *external.Config) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(EXTERNAL, bson.M{PROJECTID: c.ProjectId, NAME: c.Name})
+	if e = s.Insert(EXTERNAL, c); e != nil {
+		return &AddError{c.Name, e}
+	}
+	return nil
+}
+
+//IOTestCases retrieves all input/output test cases matching m from the active database.
+func IOTestCases(m, sl interface{}) ([]*iotest.Case, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var cs []*iotest.Case
+	if e = s.Find(IOTESTS, m, sl, 0, []string{NAME}, &cs); e != nil {
+		return nil, &GetError{"input/output test cases", e, m}
+	}
+	return cs, nil
+}
+
+//AddIOTestCase overwrites a project's input/output test case if it has the same
+//name as the new case. Otherwise the case is just added to the project's cases.
+//A pending case only overwrites a pending case, the live case is replaced when it is published.
+func AddIOTestCase(c *iotest.Case) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	m := replaced(c.ProjectId, c.Pending)
+	m[NAME] = c.Name
+	s.RemoveAll(IOTESTS, m)
+	if e = s.Insert(IOTESTS, c); e != nil {
+		return &AddError{c.Name, e}
+	}
+	return nil
+}
+
+//Workloads retrieves all benchmark workloads matching m from the active database.
+func Workloads(m, sl interface{}) ([]*benchmark.Workload, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var ws []*benchmark.Workload
+	if e = s.Find(BENCHMARKS, m, sl, 0, []string{NAME}, &ws); e != nil {
+		return nil, &GetError{"benchmark workloads", e, m}
+	}
+	return ws, nil
+}
+
+//AddWorkload overwrites a project's benchmark workload if it has the same
+//name as the new workload. Otherwise the workload is just added to the project's workloads.
+func AddWorkload(w *benchmark.Workload) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(BENCHMARKS, bson.M{PROJECTID: w.ProjectId, NAME: w.Name})
+	if e = s.Insert(BENCHMARKS, w); e != nil {
+		return &AddError{w.Name, e}
+	}
+	return nil
+}
@@ -26,18 +26,42 @@ package db
 
 const (
 	//Mongodb collection name.
-	USERS       = "users"
-	SUBMISSIONS = "submissions"
-	FILES       = "files"
-	RESULTS     = "results"
-	TESTS       = "tests"
-	PROJECTS    = "projects"
-	SKELETONS   = "skeletons"
-	JPF         = "jpf"
-	PMD         = "pmd"
-	MAKE        = "make"
+	USERS        = "users"
+	SUBMISSIONS  = "submissions"
+	FILES        = "files"
+	RESULTS      = "results"
+	TESTS        = "tests"
+	PROJECTS     = "projects"
+	SKELETONS    = "skeletons"
+	JPF          = "jpf"
+	PMD          = "pmd"
+	CHECKSTYLE   = "checkstyle"
+	FINDBUGS     = "findbugs"
+	JAVAC        = "javac"
+	GCC          = "gcc"
+	EXPLANATIONS = "explanations"
+	MAKE         = "make"
+	BLOBS        = "blobs"
+	ARCHIVES     = "archives"
+	RETENTION    = "retention"
+	TRASH        = "trash"
+	TRASHED      = "trashed"
+	AUDITS       = "audits"
+	EXTERNAL     = "external"
+	IOTESTS      = "iotests"
+	BENCHMARKS   = "benchmarks"
+	REFERENCES   = "references"
+	VALIDATIONS  = "validations"
+	ISSUES       = "issues"
+	ISSUETRACKS  = "issuetracks"
+	TESTOUTCOMES = "testoutcomes"
+	SIMILARITIES = "similarities"
 	//Mongodb command
 	SET    = "$set"
+	UNSET  = "$unset"
+	INC    = "$inc"
+	PUSH   = "$push"
+	PULL   = "$pull"
 	OR     = "$or"
 	AND    = "$and"
 	NOT    = "$not"
@@ -59,7 +83,9 @@ const (
 	BACKUP_DB    = "impendulo_backup"
 	DEFAULT_CONN = ADDRESS + DEFAULT_DB
 	DEBUG_CONN   = "mongodb://localhost/impendulo_debug"
-	TEST_CONN    = "mongodb://localhost/impendulo_test"
+	FILE_SCHEME  = "file://"
+	//TEST_CONN stores test data in the embedded file backend so that tests don't need a mongodb server.
+	TEST_CONN = FILE_SCHEME + "/tmp/" + TEST_DB
 	//Field names
 	TARGET      = "target"
 	ID          = "_id"
@@ -85,4 +111,33 @@ const (
 	ACCESS      = "access"
 	DESCRIPTION = "description"
 	COMMENTS    = "comments"
+	BLOB        = "blob"
+	BASE        = "base"
+	SIZE        = "size"
+	PATH        = "path"
+	GRIDFS      = "gridfs"
+	DAYS        = "days"
+	EXPIRES     = "expires"
+	TRASHID     = "trashid"
+	COLLECTION  = "collection"
+	DOC         = "doc"
+	ACTION      = "action"
+	WHOLE       = "wholeproject"
+	RESULTID    = "resultid"
+	START       = "start"
+	TOOL        = "tool"
+	RULE        = "rule"
+	CATEGORY    = "category"
+	SEVERITY    = "severity"
+	FIXED       = "fixed"
+	LIFETIME    = "lifetime"
+	SIMILARITY  = "similarity"
+	MATCHES     = "matches"
+	PENDING     = "pending"
+	REPLACES    = "replaces"
+	CHECKS      = "checks"
+	ERROR       = "error"
+	CASE        = "case"
+	FLAKY       = "flaky"
+	KIND        = "kind"
 )
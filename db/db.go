@@ -22,107 +22,57 @@
 //(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
 //SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 
-//Package db provides an interface to mgo which allows us to store and retrieve Impendulo
-//data from mongodb.
+//Package db provides an interface to the Repository in which Impendulo's data is stored.
+//Impendulo data can be stored in mongodb or in an embedded file based backend.
 package db
 
 import (
 	"fmt"
 
-	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
 )
 
-var (
-	sessionChan chan *mgo.Session
-	requestChan chan bool
-)
-
-//Setup creates a mongodb session.
-//This must be called before using any other db functions.
-func Setup(c string) error {
-	s, e := mgo.Dial(c)
-	if e != nil {
-		return e
-	}
-	sessionChan = make(chan *mgo.Session)
-	requestChan = make(chan bool)
-	go serveSession(s)
-	return nil
-}
-
-//serveSession manages the active session.
-func serveSession(s *mgo.Session) {
-	for {
-		r, ok := <-requestChan
-		if !ok || !r {
-			break
-		}
-		if s == nil {
-			sessionChan <- nil
-		} else {
-			sessionChan <- s.Clone()
-		}
-	}
-	if s != nil {
-		s.Close()
-	}
-	close(requestChan)
-	close(sessionChan)
-}
-
-//Session retrieves the current active session.
-func Session() (*mgo.Session, error) {
-	requestChan <- true
-	s, ok := <-sessionChan
-	if s == nil || !ok {
-		return nil, fmt.Errorf("could not retrieve session")
-	}
-	return s, nil
-}
-
-//Close shuts down the current session.
-func Close() {
-	requestChan <- false
-}
-
 //DeleteDB removes a db.
 func DeleteDB(db string) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	return s.DB(db).DropDatabase()
+	return s.DeleteDB(db)
 }
 
-//CopyDB is used to copy the contents of one database to a new location.
+//CopyDB replaces the contents of database t with a copy of database f.
 func CopyDB(f, t string) error {
-	if e := DeleteDB(f); e != nil {
-		return e
-	}
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	return s.Run(bson.D{{"copydb", "1"}, {"fromdb", f}, {"todb", t}}, nil)
+	return s.CopyDB(f, t)
 }
 
 //CloneCollection
 func CloneCollection(o, c string) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	return s.DB("").Run(bson.D{{"cloneCollection", c}, {"from", o}}, nil)
+	return s.CloneCollection(o, c)
+}
+
+//DataCollections retrieves the names of all collections in which Impendulo stores data,
+//including the collections used by GridFS.
+func DataCollections() []string {
+	return []string{
+		USERS, PROJECTS, SUBMISSIONS, FILES, RESULTS, BLOBS, TESTS, SKELETONS, JPF, PMD, CHECKSTYLE, FINDBUGS,
+		JAVAC, GCC, EXPLANATIONS, MAKE, EXTERNAL, IOTESTS, BENCHMARKS, REFERENCES, VALIDATIONS, ARCHIVES,
+		RETENTION, TRASH, TRASHED, AUDITS, ISSUES, ISSUETRACKS, SIMILARITIES, TESTOUTCOMES,
+		GRIDFS_NAME + ".files", GRIDFS_NAME + ".chunks",
+	}
 }
 
-//CloneData
+//CloneData copies all of Impendulo's collections from database o into the active database.
 func CloneData(o string) error {
-	cs := []string{USERS, PROJECTS, SUBMISSIONS, FILES, TESTS, JPF, PMD}
-	for _, c := range cs {
+	for _, c := range DataCollections() {
 		if e := CloneCollection(o, c); e != nil {
 			return e
 		}
@@ -132,12 +82,11 @@ func CloneData(o string) error {
 
 //Add adds a document to the specified collection.
 func Add(n string, i interface{}) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	if e = s.DB("").C(n).Insert(i); e != nil {
+	if e = s.Insert(n, i); e != nil {
 		return &AddError{n, e}
 	}
 	return nil
@@ -145,37 +94,46 @@ func Add(n string, i interface{}) error {
 
 //RemoveById removes a document matching the given id in collection n from the active database.
 func RemoveById(n string, id interface{}) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	if e = s.DB("").C(n).RemoveId(id); e != nil {
+	if e = s.Remove(n, bson.M{ID: id}); e != nil {
 		return &RemoveError{n, e, id}
 	}
 	return nil
 }
 
+//RemoveAll removes all documents matching m in collection n from the active database.
+func RemoveAll(n string, m interface{}) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	if e = s.RemoveAll(n, m); e != nil {
+		return &RemoveError{n, e, m}
+	}
+	return nil
+}
+
 //Update updates documents from the collection n matching m with the changes specified by c.
 func Update(n string, m, c interface{}) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	if e = s.DB("").C(n).Update(m, c); e != nil {
+	if e = s.Update(n, m, c); e != nil {
 		return fmt.Errorf("error %q: updating %q matching %q to %q", e, n, m, c)
 	}
 	return nil
 }
 
 func UpdateAll(n string, m, c interface{}) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	if _, e = s.DB("").C(n).UpdateAll(m, c); e != nil {
+	if e = s.UpdateAll(n, m, c); e != nil {
 		return fmt.Errorf("error %q: updating %q matching %q to %q", e, n, m, c)
 	}
 	return nil
@@ -189,12 +147,11 @@ func Contains(n string, m interface{}) bool {
 
 //Count calculates the amount of items in the collection col which match matcher.
 func Count(n string, m interface{}) (int, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return -1, e
 	}
-	defer s.Close()
-	count, e := s.DB("").C(n).Find(m).Count()
+	count, e := s.Count(n, m)
 	if e != nil {
 		return -1, &GetError{n + " count", e, m}
 	}
@@ -202,19 +159,17 @@ func Count(n string, m interface{}) (int, error) {
 }
 
 func Collections(db string) ([]string, error) {
-	s, e := mgo.Dial(ADDRESS + db)
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
-	return s.DB("").CollectionNames()
+	return s.Collections(db)
 }
 
 func Databases() ([]string, error) {
-	s, e := mgo.Dial(ADDRESS)
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
-	return s.DatabaseNames()
+	return s.Databases()
 }
@@ -32,24 +32,43 @@ import (
 	"testing"
 )
 
+func TestDataCollections(t *testing.T) {
+	exp := []string{
+		USERS, PROJECTS, SUBMISSIONS, FILES, RESULTS, BLOBS, TESTS, SKELETONS, JPF, PMD, CHECKSTYLE, FINDBUGS,
+		JAVAC, GCC, EXPLANATIONS, MAKE, EXTERNAL, IOTESTS, BENCHMARKS, REFERENCES, VALIDATIONS, ARCHIVES,
+		RETENTION, TRASH, TRASHED, AUDITS, ISSUES, ISSUETRACKS, SIMILARITIES, TESTOUTCOMES,
+		GRIDFS_NAME + ".files", GRIDFS_NAME + ".chunks",
+	}
+	cs := make(map[string]bool)
+	for _, c := range DataCollections() {
+		cs[c] = true
+	}
+	for _, c := range exp {
+		if !cs[c] {
+			t.Errorf("Collection %s is not cloned.", c)
+		}
+	}
+	if len(cs) != len(exp) {
+		t.Errorf("Expected %d collections but found %d.", len(exp), len(cs))
+	}
+}
+
 func TestSetup(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 }
 
 func TestCount(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	num := 100
 	n, e := Count(PROJECTS, bson.M{})
 	if e != nil {
@@ -53,6 +53,9 @@ type (
 
 var (
 	DuplicateFile = errors.New("db already contains this file")
+	DuplicateId   = errors.New("db already contains a document with this id")
+	NotFound      = errors.New("not found")
+	NoRepository  = errors.New("no active repository, db.Setup must be called first")
 )
 
 func (g *GetError) Error() string {
@@ -0,0 +1,564 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	//FileRepository is an embedded Repository which stores each database in
+	//a directory. A collection is stored as a journal file of consecutive bson
+	//documents: each change appends the documents it inserted or modified and a
+	//DELETED record for each document it removed. The journal is compacted once it
+	//holds more stale than current records. Grid files are stored in a GRIDFS_NAME
+	//subdirectory. Collections are held in memory once loaded, so a FileRepository
+	//is suited to single machine installations and tests rather than large deployments.
+	FileRepository struct {
+		root, db string
+		cols     map[string][]bson.M
+		//records is the number of records in each loaded collection's journal.
+		records map[string]int
+		sync.Mutex
+	}
+
+	//gridFile is the format in which a FileRepository stores grid files.
+	gridFile struct {
+		ContentType string `bson:"contenttype"`
+		Data        []byte `bson:"data"`
+	}
+)
+
+const (
+	COLLECTION_EXT = ".bson"
+	//DELETED is the key of a journal record which removes the document whose id it holds.
+	DELETED = "$deleted"
+	//MIN_RECORDS is the journal size below which a collection is never compacted.
+	MIN_RECORDS = 64
+)
+
+//NewFileRepository creates a FileRepository for the database stored in directory p.
+func NewFileRepository(p string) (*FileRepository, error) {
+	p, e := filepath.Abs(p)
+	if e != nil {
+		return nil, e
+	}
+	if e = os.MkdirAll(p, os.ModePerm); e != nil {
+		return nil, e
+	}
+	return &FileRepository{
+		root:    filepath.Dir(p),
+		db:      filepath.Base(p),
+		cols:    make(map[string][]bson.M),
+		records: make(map[string]int),
+	}, nil
+}
+
+//collection retrieves the documents in collection n, loading them from disk if necessary.
+//The collection's journal is replayed in order. An incomplete record at the end of the journal,
+//left by an interrupted write, is ignored.
+func (r *FileRepository) collection(n string) ([]bson.M, error) {
+	if c, ok := r.cols[n]; ok {
+		return c, nil
+	}
+	d, e := ioutil.ReadFile(r.collectionPath(r.db, n))
+	if e != nil && !os.IsNotExist(e) {
+		return nil, e
+	}
+	var c []bson.M
+	is := make(map[string]int)
+	rs := 0
+	for len(d) >= 4 {
+		l := int(binary.LittleEndian.Uint32(d))
+		if l < 5 {
+			return nil, fmt.Errorf("corrupt collection %s", n)
+		}
+		if l > len(d) {
+			break
+		}
+		var m bson.M
+		if e = bson.Unmarshal(d[:l], &m); e != nil {
+			return nil, e
+		}
+		d = d[l:]
+		rs++
+		if id, ok := m[DELETED]; ok {
+			if i, ok := is[idKey(id)]; ok {
+				c[i] = nil
+				delete(is, idKey(id))
+			}
+			continue
+		}
+		if i, ok := is[idKey(m[ID])]; ok {
+			c[i] = m
+			continue
+		}
+		is[idKey(m[ID])] = len(c)
+		c = append(c, m)
+	}
+	nc := make([]bson.M, 0, len(is))
+	for _, m := range c {
+		if m != nil {
+			nc = append(nc, m)
+		}
+	}
+	r.cols[n], r.records[n] = nc, rs
+	return nc, nil
+}
+
+//idKey creates a key which identifies a document's id.
+func idKey(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+//write stores the new contents c of collection n by appending the changed documents ch
+//and DELETED records for the ids in rm to its journal. The journal is rewritten with only the
+//current documents instead once it holds more than twice as many records as there are documents.
+func (r *FileRepository) write(n string, c, ch []bson.M, rm []interface{}) error {
+	rs := r.records[n] + len(ch) + len(rm)
+	if rs > MIN_RECORDS && rs > 2*len(c) {
+		return r.save(n, c)
+	}
+	var d []byte
+	for _, m := range ch {
+		b, e := bson.Marshal(m)
+		if e != nil {
+			return e
+		}
+		d = append(d, b...)
+	}
+	for _, id := range rm {
+		b, e := bson.Marshal(bson.M{DELETED: id})
+		if e != nil {
+			return e
+		}
+		d = append(d, b...)
+	}
+	if e := appendFile(r.collectionPath(r.db, n), d); e != nil {
+		return e
+	}
+	r.cols[n], r.records[n] = c, rs
+	return nil
+}
+
+//save rewrites the journal of collection n with only the documents c.
+func (r *FileRepository) save(n string, c []bson.M) error {
+	var d []byte
+	for _, m := range c {
+		b, e := bson.Marshal(m)
+		if e != nil {
+			return e
+		}
+		d = append(d, b...)
+	}
+	p := r.collectionPath(r.db, n)
+	if e := writeFile(p, d); e != nil {
+		return e
+	}
+	r.cols[n], r.records[n] = c, len(c)
+	return nil
+}
+
+//collectionPath
+func (r *FileRepository) collectionPath(db, n string) string {
+	return filepath.Join(r.root, db, n+COLLECTION_EXT)
+}
+
+//gridPath
+func (r *FileRepository) gridPath(id interface{}) string {
+	var n string
+	if oid, ok := id.(bson.ObjectId); ok {
+		n = oid.Hex()
+	} else {
+		n = hex.EncodeToString([]byte(fmt.Sprint(id)))
+	}
+	return filepath.Join(r.root, r.db, GRIDFS_NAME, n+COLLECTION_EXT)
+}
+
+//find retrieves the documents in collection n matching m.
+func (r *FileRepository) find(n string, m interface{}) ([]bson.M, error) {
+	q, e := toDoc(m)
+	if e != nil {
+		return nil, e
+	}
+	c, e := r.collection(n)
+	if e != nil {
+		return nil, e
+	}
+	var ds []bson.M
+	for _, d := range c {
+		if matches(d, q) {
+			ds = append(ds, d)
+		}
+	}
+	return ds, nil
+}
+
+//FindOne
+func (r *FileRepository) FindOne(n string, m, sl, ret interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+	ds, e := r.find(n, m)
+	if e != nil {
+		return e
+	}
+	if len(ds) == 0 {
+		return NotFound
+	}
+	s, e := toDoc(sl)
+	if e != nil {
+		return e
+	}
+	return fromDoc(selectFields(ds[0], s), ret)
+}
+
+//Find
+func (r *FileRepository) Find(n string, m, sl interface{}, limit int, sort []string, ret interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+	ds, e := r.find(n, m)
+	if e != nil {
+		return e
+	}
+	s, e := toDoc(sl)
+	if e != nil {
+		return e
+	}
+	sortDocs(ds, sort)
+	if limit > 0 && limit < len(ds) {
+		ds = ds[:limit]
+	}
+	for i, d := range ds {
+		ds[i] = selectFields(d, s)
+	}
+	return fromDocs(ds, ret)
+}
+
+//Count
+func (r *FileRepository) Count(n string, m interface{}) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+	ds, e := r.find(n, m)
+	if e != nil {
+		return -1, e
+	}
+	return len(ds), nil
+}
+
+//Distinct
+func (r *FileRepository) Distinct(n string, m interface{}, k string, ret interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+	ds, e := r.find(n, m)
+	if e != nil {
+		return e
+	}
+	vs := make([]interface{}, 0, len(ds))
+	for _, d := range ds {
+		for _, v := range flatten(lookup(d, k)) {
+			if !containsValue(vs, v) {
+				vs = append(vs, v)
+			}
+		}
+	}
+	return fromValues(vs, ret)
+}
+
+//Insert
+func (r *FileRepository) Insert(n string, ds ...interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+	c, e := r.collection(n)
+	if e != nil {
+		return e
+	}
+	nc := make([]bson.M, len(c), len(c)+len(ds))
+	copy(nc, c)
+	for _, i := range ds {
+		d, e := toDoc(i)
+		if e != nil {
+			return e
+		}
+		if _, ok := d[ID]; !ok {
+			d[ID] = bson.NewObjectId()
+		}
+		for _, o := range nc {
+			if equal(o[ID], d[ID]) {
+				return DuplicateId
+			}
+		}
+		nc = append(nc, d)
+	}
+	return r.write(n, nc, nc[len(c):], nil)
+}
+
+//Update
+func (r *FileRepository) Update(n string, m, c interface{}) error {
+	return r.update(n, m, c, false)
+}
+
+//UpdateAll
+func (r *FileRepository) UpdateAll(n string, m, c interface{}) error {
+	return r.update(n, m, c, true)
+}
+
+//update applies changes c to the first or all documents in collection n matching m.
+func (r *FileRepository) update(n string, m, c interface{}, all bool) error {
+	r.Lock()
+	defer r.Unlock()
+	q, e := toDoc(m)
+	if e != nil {
+		return e
+	}
+	ch, e := toDoc(c)
+	if e != nil {
+		return e
+	}
+	col, e := r.collection(n)
+	if e != nil {
+		return e
+	}
+	nc := make([]bson.M, len(col))
+	copy(nc, col)
+	var us []bson.M
+	for i, d := range nc {
+		if !matches(d, q) {
+			continue
+		}
+		if nc[i], e = applyChange(d, ch); e != nil {
+			return e
+		}
+		us = append(us, nc[i])
+		if !all {
+			break
+		}
+	}
+	if len(us) == 0 {
+		if all {
+			return nil
+		}
+		return NotFound
+	}
+	return r.write(n, nc, us, nil)
+}
+
+//Remove
+func (r *FileRepository) Remove(n string, m interface{}) error {
+	return r.remove(n, m, false)
+}
+
+//RemoveAll
+func (r *FileRepository) RemoveAll(n string, m interface{}) error {
+	return r.remove(n, m, true)
+}
+
+//remove removes the first or all documents in collection n matching m.
+func (r *FileRepository) remove(n string, m interface{}, all bool) error {
+	r.Lock()
+	defer r.Unlock()
+	q, e := toDoc(m)
+	if e != nil {
+		return e
+	}
+	c, e := r.collection(n)
+	if e != nil {
+		return e
+	}
+	nc := make([]bson.M, 0, len(c))
+	var rm []interface{}
+	for _, d := range c {
+		if (all || len(rm) == 0) && matches(d, q) {
+			rm = append(rm, d[ID])
+			continue
+		}
+		nc = append(nc, d)
+	}
+	if len(rm) == 0 {
+		if all {
+			return nil
+		}
+		return NotFound
+	}
+	return r.write(n, nc, nil, rm)
+}
+
+//ReadGridFile
+func (r *FileRepository) ReadGridFile(id interface{}) (string, []byte, error) {
+	d, e := ioutil.ReadFile(r.gridPath(id))
+	if os.IsNotExist(e) {
+		return "", nil, NotFound
+	} else if e != nil {
+		return "", nil, e
+	}
+	var f *gridFile
+	if e = bson.Unmarshal(d, &f); e != nil {
+		return "", nil, e
+	}
+	return f.ContentType, f.Data, nil
+}
+
+//WriteGridFile
+func (r *FileRepository) WriteGridFile(id interface{}, ct string, d []byte) error {
+	p := r.gridPath(id)
+	if _, e := os.Stat(p); e == nil {
+		return DuplicateId
+	}
+	b, e := bson.Marshal(&gridFile{ct, d})
+	if e != nil {
+		return e
+	}
+	return writeFile(p, b)
+}
+
+//RemoveGridFile
+func (r *FileRepository) RemoveGridFile(id interface{}) error {
+	e := os.Remove(r.gridPath(id))
+	if os.IsNotExist(e) {
+		return NotFound
+	}
+	return e
+}
+
+//Collections
+func (r *FileRepository) Collections(db string) ([]string, error) {
+	ps, e := filepath.Glob(filepath.Join(r.root, db, "*"+COLLECTION_EXT))
+	if e != nil {
+		return nil, e
+	}
+	ns := make([]string, len(ps))
+	for i, p := range ps {
+		ns[i] = strings.TrimSuffix(filepath.Base(p), COLLECTION_EXT)
+	}
+	sort.Strings(ns)
+	return ns, nil
+}
+
+//Databases
+func (r *FileRepository) Databases() ([]string, error) {
+	fs, e := ioutil.ReadDir(r.root)
+	if e != nil {
+		return nil, e
+	}
+	ns := make([]string, 0, len(fs))
+	for _, f := range fs {
+		if f.IsDir() {
+			ns = append(ns, f.Name())
+		}
+	}
+	return ns, nil
+}
+
+//DeleteDB
+func (r *FileRepository) DeleteDB(db string) error {
+	r.Lock()
+	defer r.Unlock()
+	return r.deleteDB(db)
+}
+
+//deleteDB removes database db, discarding its collections if they are loaded.
+func (r *FileRepository) deleteDB(db string) error {
+	if db == r.db {
+		r.cols = make(map[string][]bson.M)
+		r.records = make(map[string]int)
+	}
+	return os.RemoveAll(filepath.Join(r.root, db))
+}
+
+//CopyDB
+func (r *FileRepository) CopyDB(f, t string) error {
+	r.Lock()
+	defer r.Unlock()
+	if e := r.deleteDB(t); e != nil {
+		return e
+	}
+	src := filepath.Join(r.root, f)
+	dst := filepath.Join(r.root, t)
+	return filepath.Walk(src, func(p string, i os.FileInfo, e error) error {
+		if e != nil || i.IsDir() {
+			return e
+		}
+		rel, e := filepath.Rel(src, p)
+		if e != nil {
+			return e
+		}
+		d, e := ioutil.ReadFile(p)
+		if e != nil {
+			return e
+		}
+		return writeFile(filepath.Join(dst, rel), d)
+	})
+}
+
+//CloneCollection
+func (r *FileRepository) CloneCollection(o, c string) error {
+	return fmt.Errorf("cloning collection %s from %s is not supported by the file backend", c, o)
+}
+
+//Close
+func (r *FileRepository) Close() {
+	r.Lock()
+	defer r.Unlock()
+	r.cols = make(map[string][]bson.M)
+	r.records = make(map[string]int)
+}
+
+//appendFile appends d to the file at p, creating it if necessary.
+func appendFile(p string, d []byte) error {
+	if e := os.MkdirAll(filepath.Dir(p), os.ModePerm); e != nil {
+		return e
+	}
+	f, e := os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if e != nil {
+		return e
+	}
+	if _, e = f.Write(d); e != nil {
+		f.Close()
+		return e
+	}
+	return f.Close()
+}
+
+//writeFile atomically replaces the file at p with d.
+func writeFile(p string, d []byte) error {
+	if e := os.MkdirAll(filepath.Dir(p), os.ModePerm); e != nil {
+		return e
+	}
+	t := p + ".tmp"
+	if e := ioutil.WriteFile(t, d, 0644); e != nil {
+		return e
+	}
+	return os.Rename(t, p)
+}
@@ -0,0 +1,259 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"bytes"
+
+	"github.com/godfried/impendulo/project"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func setupFileRepository(t *testing.T) string {
+	d, e := ioutil.TempDir("", "impendulo")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e = Setup(FILE_SCHEME + filepath.Join(d, TEST_DB)); e != nil {
+		t.Fatal(e)
+	}
+	return d
+}
+
+func TestFileRepository(t *testing.T) {
+	d := setupFileRepository(t)
+	defer os.RemoveAll(d)
+	defer Close()
+	for i := 0; i < 10; i++ {
+		p := project.New("name"+strconv.Itoa(i/5), "user", "lang", "a description")
+		p.Time = int64(i)
+		if e := Add(PROJECTS, p); e != nil {
+			t.Error(e)
+		}
+	}
+	n, e := Count(PROJECTS, bson.M{NAME: "name0"})
+	if e != nil {
+		t.Error(e)
+	}
+	if n != 5 {
+		t.Errorf("Invalid count %d, should be %d.", n, 5)
+	}
+	ps, e := Projects(bson.M{TIME: bson.M{GTE: 3}}, bson.M{NAME: 1, TIME: 1}, "-"+TIME)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(ps) != 7 || ps[0].Time != 9 || ps[0].User != "" {
+		t.Errorf("Invalid projects %v.", ps)
+	}
+	if e = Update(PROJECTS, bson.M{ID: ps[0].Id}, bson.M{SET: bson.M{USER: "other"}}); e != nil {
+		t.Error(e)
+	}
+	if e = Add(PROJECTS, ps[0]); e != DuplicateId {
+		if ae, ok := e.(*AddError); !ok || ae.err != DuplicateId {
+			t.Errorf("Expected duplicate id error but got %v.", e)
+		}
+	}
+	var us []string
+	if e = active.Distinct(PROJECTS, nil, USER, &us); e != nil {
+		t.Error(e)
+	}
+	if len(us) != 2 {
+		t.Errorf("Invalid distinct users %v.", us)
+	}
+	if e = RemoveById(PROJECTS, ps[1].Id); e != nil {
+		t.Error(e)
+	}
+	Close()
+	if e = Setup(FILE_SCHEME + filepath.Join(d, TEST_DB)); e != nil {
+		t.Error(e)
+	}
+	if n, e = Count(PROJECTS, nil); e != nil {
+		t.Error(e)
+	}
+	if n != 9 {
+		t.Errorf("Invalid count %d after reload, should be %d.", n, 9)
+	}
+	p, e := Project(bson.M{ID: ps[0].Id}, nil)
+	if e != nil {
+		t.Error(e)
+	}
+	if p.User != "other" || p.Description != "a description" {
+		t.Errorf("Invalid updated project %v.", p)
+	}
+	if _, e = Project(bson.M{ID: ps[1].Id}, nil); e == nil {
+		t.Error("Expected error retrieving removed project.")
+	}
+	cs, e := Collections(TEST_DB)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(cs) != 1 || cs[0] != PROJECTS {
+		t.Errorf("Invalid collections %v.", cs)
+	}
+}
+
+func TestFileRepositoryFiles(t *testing.T) {
+	d := setupFileRepository(t)
+	defer os.RemoveAll(d)
+	defer Close()
+	sid := bson.NewObjectId()
+	for i := 0; i < 3; i++ {
+		f, e := project.NewFile(sid, fileInfo, append(fileData, bytes.Repeat([]byte("//Edited\n"), i)...))
+		if e != nil {
+			t.Error(e)
+		}
+		f.Time += int64(i)
+		if e = AddFile(f); e != nil {
+			t.Error(e)
+		}
+	}
+	fs, e := Files(bson.M{SUBID: sid}, nil, 0, "-"+TIME)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(fs) != 3 {
+		t.Errorf("Invalid number of files %d.", len(fs))
+	}
+	for i, f := range fs {
+		if exp := append(fileData, bytes.Repeat([]byte("//Edited\n"), 2-i)...); !bytes.Equal(f.Data, exp) {
+			t.Errorf("Data not equal for snapshot %d.", i)
+		}
+	}
+	id := bson.NewObjectId()
+	if e = AddGridFile(id, grandPrix); e != nil {
+		t.Error(e)
+	}
+	var g []byte
+	if e = GridFile(id, &g); e != nil {
+		t.Error(e)
+	}
+	if !bytes.Equal(g, grandPrix) {
+		t.Error("Grid data not equal.")
+	}
+}
+
+func TestFileRepositoryJournal(t *testing.T) {
+	d := setupFileRepository(t)
+	defer os.RemoveAll(d)
+	defer Close()
+	p := filepath.Join(d, TEST_DB, PROJECTS+COLLECTION_EXT)
+	ps := make([]*project.Project, 10)
+	for i := range ps {
+		ps[i] = project.New("name"+strconv.Itoa(i), "user", "lang", "a description")
+		if e := Add(PROJECTS, ps[i]); e != nil {
+			t.Error(e)
+		}
+	}
+	i, e := os.Stat(p)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e = Update(PROJECTS, bson.M{ID: ps[0].Id}, bson.M{SET: bson.M{USER: "other"}}); e != nil {
+		t.Error(e)
+	}
+	if e = RemoveById(PROJECTS, ps[1].Id); e != nil {
+		t.Error(e)
+	}
+	a, e := os.Stat(p)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if a.Size() <= i.Size() {
+		t.Errorf("Journal was rewritten instead of appended to: %d <= %d.", a.Size(), i.Size())
+	}
+	//An incomplete record left by an interrupted write should be ignored.
+	f, e := os.OpenFile(p, os.O_WRONLY|os.O_APPEND, 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+	f.Write([]byte{100, 0, 0, 0, 3})
+	f.Close()
+	Close()
+	if e = Setup(FILE_SCHEME + filepath.Join(d, TEST_DB)); e != nil {
+		t.Fatal(e)
+	}
+	if n, e := Count(PROJECTS, nil); e != nil || n != 9 {
+		t.Errorf("Invalid count %d after reload: %v.", n, e)
+	}
+	if n, e := Count(PROJECTS, bson.M{USER: "other"}); e != nil || n != 1 {
+		t.Errorf("Invalid count %d of updated projects after reload: %v.", n, e)
+	}
+	for j := 0; j < MIN_RECORDS; j++ {
+		if e = Update(PROJECTS, bson.M{ID: ps[2].Id}, bson.M{SET: bson.M{TIME: int64(j)}}); e != nil {
+			t.Error(e)
+		}
+	}
+	c, e := os.Stat(p)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if c.Size() >= 3*a.Size() {
+		t.Errorf("Journal of size %d was not compacted.", c.Size())
+	}
+	Close()
+	if e = Setup(FILE_SCHEME + filepath.Join(d, TEST_DB)); e != nil {
+		t.Fatal(e)
+	}
+	if q, e := Project(bson.M{ID: ps[2].Id}, nil); e != nil || q.Time != int64(MIN_RECORDS-1) {
+		t.Errorf("Invalid project %v after compaction: %v.", q, e)
+	}
+	if n, e := Count(PROJECTS, nil); e != nil || n != 9 {
+		t.Errorf("Invalid count %d after compaction: %v.", n, e)
+	}
+}
+
+func TestFileRepositoryCopyDB(t *testing.T) {
+	d := setupFileRepository(t)
+	defer os.RemoveAll(d)
+	defer Close()
+	if e := Add(PROJECTS, project.New("name", "user", "lang", "a description")); e != nil {
+		t.Error(e)
+	}
+	if e := CopyDB(TEST_DB, BACKUP_DB); e != nil {
+		t.Error(e)
+	}
+	if e := Add(PROJECTS, project.New("other", "user", "lang", "a description")); e != nil {
+		t.Error(e)
+	}
+	if e := CopyDB(TEST_DB, BACKUP_DB); e != nil {
+		t.Error(e)
+	}
+	if n, e := Count(PROJECTS, nil); e != nil || n != 2 {
+		t.Errorf("Invalid count %d in source database: %v.", n, e)
+	}
+	Close()
+	if e := Setup(FILE_SCHEME + filepath.Join(d, BACKUP_DB)); e != nil {
+		t.Fatal(e)
+	}
+	if n, e := Count(PROJECTS, nil); e != nil || n != 2 {
+		t.Errorf("Invalid count %d in copied database: %v.", n, e)
+	}
+}
@@ -25,13 +25,48 @@
 package db
 
 import (
+	"bytes"
 	"encoding/gob"
+	"fmt"
+
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
 	"labix.org/v2/mgo/bson"
+
+	"reflect"
 )
 
 const (
 	GRIDFS_NAME = "fs"
+	//GRID_FORMAT is the content type of GridFiles stored in the current format.
+	GRID_FORMAT = "application/x-impendulo+bson"
+	//GRID_VERSION is the version of the format GridFiles are currently stored in.
+	GRID_VERSION = 1
+)
+
+type (
+	//GridData is the envelope in which data is stored on GridFS.
+	//A GridFile's content type is set to GRID_FORMAT and its contents
+	//consist of a single bson document with the following fields:
+	//  version: the version of the format used to store the data.
+	//  type:    the Go type of the stored data, e.g. *javac.Report.
+	//  data:    the stored data as a bson value.
+	//Since bson is decoded by field name, fields can be added to or removed from
+	//a report without making previously stored reports unreadable.
+	//GridFiles without a content type were stored via gob by earlier versions
+	//and are upgraded to the current format the first time they are read.
+	GridData struct {
+		Version int         `bson:"version"`
+		Type    string      `bson:"type"`
+		Data    interface{} `bson:"data"`
+	}
+
+	//rawGridData is used to decode a GridData's envelope before its data.
+	rawGridData struct {
+		Version int      `bson:"version"`
+		Type    string   `bson:"type"`
+		Data    bson.Raw `bson:"data"`
+	}
 )
 
 //HasGridFile checks whether this query needs to get data from GridFS
@@ -40,32 +75,71 @@ func HasGridFile(r result.Tooler, sl bson.M) bool {
 }
 
 //GridFile loads a GridFile matching id into a provided data structure from GridFS.
+//GridFiles stored in the legacy gob format are upgraded to the current format.
 func GridFile(id, ret interface{}) error {
-	s, e := Session()
+	r, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	f, e := s.DB("").GridFS(GRIDFS_NAME).OpenId(id)
+	ct, d, e := r.ReadGridFile(id)
 	if e != nil {
 		return e
 	}
-	defer f.Close()
-	return gob.NewDecoder(f).Decode(ret)
+	if ct != GRID_FORMAT {
+		if e = gob.NewDecoder(bytes.NewReader(d)).Decode(ret); e != nil {
+			return e
+		}
+		if e = upgradeGridFile(id, ret); e != nil {
+			util.Log(e)
+		}
+		return nil
+	}
+	var g rawGridData
+	if e = bson.Unmarshal(d, &g); e != nil {
+		return e
+	}
+	if g.Version > GRID_VERSION {
+		return fmt.Errorf("unsupported GridFS format version %d for %v", g.Version, id)
+	}
+	return g.Data.Unmarshal(ret)
 }
 
-//AddGridFile creates a new GridFile and stores the provided data structure in it via gob.
+//AddGridFile creates a new GridFile and stores the provided data structure in it.
+//See GridData for a description of the format used.
 func AddGridFile(id, data interface{}) error {
-	s, e := Session()
+	d, e := gridData(data)
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	f, e := s.DB("").GridFS(GRIDFS_NAME).Create("")
+	r, e := Active()
 	if e != nil {
 		return e
 	}
-	defer f.Close()
-	f.SetId(id)
-	return gob.NewEncoder(f).Encode(data)
+	return r.WriteGridFile(id, GRID_FORMAT, d)
+}
+
+//upgradeGridFile replaces the GridFile matching id with one containing data
+//stored in the current format. data is a pointer to the decoded legacy data.
+func upgradeGridFile(id, data interface{}) error {
+	d, e := gridData(reflect.ValueOf(data).Elem().Interface())
+	if e != nil {
+		return e
+	}
+	r, e := Active()
+	if e != nil {
+		return e
+	}
+	if e = r.RemoveGridFile(id); e != nil {
+		return e
+	}
+	return r.WriteGridFile(id, GRID_FORMAT, d)
+}
+
+//gridData encodes data in the current GridFS format.
+func gridData(data interface{}) ([]byte, error) {
+	return bson.Marshal(&GridData{
+		Version: GRID_VERSION,
+		Type:    fmt.Sprintf("%T", data),
+		Data:    data,
+	})
 }
@@ -26,6 +26,7 @@ package db
 
 import (
 	"bytes"
+	"encoding/gob"
 
 	"github.com/godfried/impendulo/project"
 	"github.com/godfried/impendulo/tool/result"
@@ -38,11 +39,10 @@ import (
 func TestResultGridFS(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	file, e := project.NewFile(bson.NewObjectId(), fileInfo, fileData)
 	if e != nil {
 		t.Error(e)
@@ -73,11 +73,10 @@ func TestResultGridFS(t *testing.T) {
 func TestGridFS(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	id := bson.NewObjectId()
 	if e = AddGridFile(id, grandPrix); e != nil {
 		t.Error(e)
@@ -91,6 +90,44 @@ func TestGridFS(t *testing.T) {
 	}
 }
 
+func TestLegacyGridFS(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	s, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	id := bson.NewObjectId()
+	var b bytes.Buffer
+	if e = gob.NewEncoder(&b).Encode(grandPrix); e != nil {
+		t.Error(e)
+	}
+	if e = s.WriteGridFile(id, "", b.Bytes()); e != nil {
+		t.Error(e)
+	}
+	var d []byte
+	if e = GridFile(id, &d); e != nil {
+		t.Error(e)
+	}
+	if !bytes.Equal(grandPrix, d) {
+		t.Error("Legacy data not equal.")
+	}
+	ct, _, e := s.ReadGridFile(id)
+	if e != nil {
+		t.Error(e)
+	}
+	if ct != GRID_FORMAT {
+		t.Error("Legacy data not upgraded.")
+	}
+	d = nil
+	if e = GridFile(id, &d); e != nil {
+		t.Error(e)
+	}
+	if !bytes.Equal(grandPrix, d) {
+		t.Error("Upgraded data not equal.")
+	}
+}
+
 var grandPrix = []byte(`
 Fast love,
 Heart breaker,
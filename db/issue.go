@@ -0,0 +1,313 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/result"
+	"labix.org/v2/mgo/bson"
+
+	"sort"
+)
+
+type (
+	//Issue is a problem found in a snapshot by a static analysis tool or compiler.
+	//Issues from all tools share this model so that they can be queried and
+	//compared regardless of which tool reported them. Result is the name under
+	//which the issue's result is stored in the file's results. Severity is one of
+	//the SARIF levels and Category one of the categories in the result package.
+	Issue struct {
+		Id       bson.ObjectId `bson:"_id"`
+		ResultId bson.ObjectId `bson:"resultid"`
+		FileId   bson.ObjectId `bson:"fileid"`
+		SubId    bson.ObjectId `bson:"subid"`
+		Result   string        `bson:"result"`
+		Tool     string        `bson:"tool"`
+		Rule     string        `bson:"rule"`
+		Category string        `bson:"category"`
+		Severity string        `bson:"severity"`
+		Name     string        `bson:"name"`
+		Start    int           `bson:"start"`
+		End      int           `bson:"end"`
+		Message  string        `bson:"message"`
+		Time     int64         `bson:"time"`
+	}
+
+	//IssueResult is a Displayer for all the issues found in a snapshot.
+	IssueResult struct {
+		FileId bson.ObjectId
+		Issues []*Issue
+	}
+
+	//IssueCount is the number of issues with a certain value for a field.
+	IssueCount struct {
+		Value string
+		Count int
+	}
+)
+
+const (
+	//ISSUE_RESULT is the type of the IssueResult Displayer.
+	ISSUE_RESULT = "Issues"
+)
+
+//NewIssues derives the issues found by result r which is stored as n in its
+//file's results. Results of tools which don't report issues have none.
+func NewIssues(r result.Tooler, n string) ([]*Issue, error) {
+	sr, ok := r.(result.Sarifer)
+	if !ok {
+		return nil, nil
+	}
+	f, e := File(bson.M{ID: r.GetFileId()}, bson.M{DATA: 0})
+	if e != nil {
+		return nil, e
+	}
+	return fileIssues(f, n, r.GetId(), sr), nil
+}
+
+//fileIssues converts the SARIF results of sr, stored as n in file f's results, into issues.
+func fileIssues(f *project.File, n string, rid bson.ObjectId, sr result.Sarifer) []*Issue {
+	rs := sr.SARIF(f.Name)
+	is := make([]*Issue, len(rs))
+	for i, r := range rs {
+		c, _ := r.Properties[result.CATEGORY].(string)
+		if c == "" {
+			c = result.OTHER
+		}
+		s, en := r.Lines()
+		is[i] = &Issue{
+			Id: bson.NewObjectId(), ResultId: rid, FileId: f.Id, SubId: f.SubId,
+			Result: n, Tool: sr.GetName(), Rule: r.RuleId, Category: c, Severity: r.Level,
+			Name: f.Name, Start: s, End: en, Message: r.Text(), Time: f.Time,
+		}
+	}
+	return is
+}
+
+//AddIssues adds is to the active database.
+func AddIssues(is []*Issue) error {
+	if len(is) == 0 {
+		return nil
+	}
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	ds := make([]interface{}, len(is))
+	for i, v := range is {
+		ds[i] = v
+	}
+	if e = s.Insert(ISSUES, ds...); e != nil {
+		return &AddError{ISSUES, e}
+	}
+	return nil
+}
+
+//Issues retrieves up to limit Issues matching m, ordered by file, time and line.
+func Issues(m, sl interface{}, limit int) ([]*Issue, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var is []*Issue
+	if e = s.Find(ISSUES, m, sl, limit, []string{NAME, TIME, START}, &is); e != nil {
+		return nil, &GetError{"issues", e, m}
+	}
+	return is, nil
+}
+
+//IssueCounts counts the Issues matching m for each value of field k,
+//ordered from the most to the least common value.
+func IssueCounts(m bson.M, k string) ([]*IssueCount, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var vs []string
+	if e = s.Distinct(ISSUES, m, k, &vs); e != nil {
+		return nil, &GetError{"issues", e, m}
+	}
+	cs := make([]*IssueCount, 0, len(vs))
+	for _, v := range vs {
+		cm := bson.M{k: v}
+		for mk, mv := range m {
+			cm[mk] = mv
+		}
+		n, e := s.Count(ISSUES, cm)
+		if e != nil {
+			return nil, &GetError{"issues", e, cm}
+		}
+		cs = append(cs, &IssueCount{Value: v, Count: n})
+	}
+	sort.Sort(issueCounts(cs))
+	return cs, nil
+}
+
+type issueCounts []*IssueCount
+
+func (c issueCounts) Len() int {
+	return len(c)
+}
+
+func (c issueCounts) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+func (c issueCounts) Less(i, j int) bool {
+	if c[i].Count == c[j].Count {
+		return c[i].Value < c[j].Value
+	}
+	return c[i].Count > c[j].Count
+}
+
+//RemoveResult removes the result matching id along with its report, issues and test outcomes.
+func RemoveResult(id bson.ObjectId) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	if Contains(RESULTS, bson.M{ID: id, GRIDFS: true}) {
+		s.RemoveGridFile(id)
+	}
+	if e = s.RemoveAll(ISSUES, bson.M{RESULTID: id}); e != nil {
+		return &RemoveError{ISSUES, e, id}
+	}
+	if e = s.RemoveAll(TESTOUTCOMES, bson.M{RESULTID: id}); e != nil {
+		return &RemoveError{TESTOUTCOMES, e, id}
+	}
+	return RemoveById(RESULTS, id)
+}
+
+//RebuildIssues recreates the issues of the source files matching m from their results.
+//It is used to index results which were added before issues were stored and to
+//restore the issues of archived projects.
+func RebuildIssues(m bson.M) (int, error) {
+	s, e := Active()
+	if e != nil {
+		return 0, e
+	}
+	fm := bson.M{TYPE: project.SRC}
+	for k, v := range m {
+		fm[k] = v
+	}
+	fs, e := Files(fm, bson.M{DATA: 0}, 0)
+	if e != nil {
+		return 0, e
+	}
+	n := 0
+	for _, f := range fs {
+		if e = s.RemoveAll(ISSUES, bson.M{FILEID: f.Id}); e != nil {
+			return n, &RemoveError{ISSUES, e, f.Id}
+		}
+		for rn, v := range f.Results {
+			id, ok := v.(bson.ObjectId)
+			if !ok {
+				continue
+			}
+			t, e := Tooler(bson.M{ID: id}, nil)
+			if e != nil {
+				continue
+			}
+			sr, ok := t.(result.Sarifer)
+			if !ok {
+				continue
+			}
+			is := fileIssues(f, rn, id, sr)
+			if e = AddIssues(is); e != nil {
+				return n, e
+			}
+			n += len(is)
+		}
+	}
+	return n, nil
+}
+
+//NewIssueResult creates a Displayer for the issues found in the snapshot matching fid.
+func NewIssueResult(fid bson.ObjectId) (*IssueResult, error) {
+	is, e := Issues(bson.M{FILEID: fid}, nil, 0)
+	if e != nil {
+		return nil, e
+	}
+	return &IssueResult{FileId: fid, Issues: is}, nil
+}
+
+func (r *IssueResult) GetType() string {
+	return ISSUE_RESULT
+}
+
+//GetName
+func (r *IssueResult) GetName() string {
+	return r.GetType()
+}
+
+//Reporter
+func (r *IssueResult) Reporter() result.Reporter {
+	return r
+}
+
+func (r *IssueResult) Template() string {
+	return "issuesresult"
+}
+
+//Tools retrieves the names of the tools which found issues.
+func (r *IssueResult) Tools() []string {
+	return r.values(func(i *Issue) string { return i.Tool })
+}
+
+//Categories retrieves the categories of the issues.
+func (r *IssueResult) Categories() []string {
+	return r.values(func(i *Issue) string { return i.Category })
+}
+
+//Severities retrieves the severities of the issues.
+func (r *IssueResult) Severities() []string {
+	return r.values(func(i *Issue) string { return i.Severity })
+}
+
+//values retrieves the sorted distinct values of an issue field.
+func (r *IssueResult) values(f func(*Issue) string) []string {
+	m := make(map[string]bool)
+	vs := make([]string, 0, len(r.Issues))
+	for _, i := range r.Issues {
+		if v := f(i); !m[v] {
+			m[v] = true
+			vs = append(vs, v)
+		}
+	}
+	sort.Strings(vs)
+	return vs
+}
+
+//Lines retrieves the locations of all issues which have a line number.
+func (r *IssueResult) Lines() []*result.Line {
+	ls := make([]*result.Line, 0, len(r.Issues))
+	for _, i := range r.Issues {
+		if i.Start <= 0 {
+			continue
+		}
+		ls = append(ls, &result.Line{Title: i.Tool + ": " + i.Rule, Description: i.Message, Start: i.Start, End: i.End})
+	}
+	return ls
+}
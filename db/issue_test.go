@@ -0,0 +1,102 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/javac"
+	"github.com/godfried/impendulo/tool/result"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestIssues(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	rp, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	p := project.New("Triangle", "user", "Java", "")
+	if e = Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	s := project.NewSubmission(p.Id, "student", project.FILE_MODE, 1000)
+	if e = Add(SUBMISSIONS, s); e != nil {
+		t.Error(e)
+	}
+	f, e := project.NewFile(s.Id, fileInfo, fileData)
+	if e != nil {
+		t.Error(e)
+	}
+	if e = AddFile(f); e != nil {
+		t.Error(e)
+	}
+	r := javac.NewResult(f.Id, []byte("/tmp/src/triangle/Triangle.java:5: error: ';' expected\n/tmp/src/triangle/Triangle.java:9: warning: [unchecked] unchecked call\n2 errors"))
+	if e = AddResult(r, r.GetName()); e != nil {
+		t.Error(e)
+	}
+	is, e := Issues(bson.M{SUBID: s.Id}, nil, 0)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(is) != 2 {
+		t.Fatalf("Expected 2 issues, got %d.", len(is))
+	}
+	if is[0].Tool != javac.NAME || is[0].Category != result.COMPILE || is[0].Start != 5 || is[0].ResultId != r.GetId() || is[0].Name != f.Name {
+		t.Errorf("Unexpected issue %v.", is[0])
+	}
+	cs, e := IssueCounts(bson.M{FILEID: f.Id}, SEVERITY)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(cs) != 2 || cs[0].Count != 1 || cs[1].Count != 1 {
+		t.Errorf("Unexpected issue counts %v.", cs)
+	}
+	ir, e := NewIssueResult(f.Id)
+	if e != nil {
+		t.Error(e)
+	}
+	if ts := ir.Tools(); len(ts) != 1 || ts[0] != javac.NAME {
+		t.Errorf("Unexpected tools %v.", ts)
+	}
+	if e = rp.RemoveAll(ISSUES, bson.M{}); e != nil {
+		t.Error(e)
+	}
+	n, e := RebuildIssues(bson.M{SUBID: s.Id})
+	if e != nil {
+		t.Error(e)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 rebuilt issues, got %d.", n)
+	}
+	if e = RemoveResult(r.GetId()); e != nil {
+		t.Error(e)
+	}
+	if c, e := Count(ISSUES, bson.M{}); e != nil || c != 0 {
+		t.Errorf("Expected issues to be removed, got %d %v.", c, e)
+	}
+}
@@ -0,0 +1,300 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"fmt"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+)
+
+type (
+	//MongoRepository is a Repository which stores data in mongodb.
+	MongoRepository struct {
+		sessionChan chan *mgo.Session
+		requestChan chan bool
+	}
+)
+
+//NewMongoRepository creates a MongoRepository connected to the mongodb url c.
+func NewMongoRepository(c string) (*MongoRepository, error) {
+	s, e := mgo.Dial(c)
+	if e != nil {
+		return nil, e
+	}
+	r := &MongoRepository{
+		sessionChan: make(chan *mgo.Session),
+		requestChan: make(chan bool),
+	}
+	go r.serveSession(s)
+	return r, nil
+}
+
+//serveSession manages the active session.
+func (r *MongoRepository) serveSession(s *mgo.Session) {
+	for {
+		q, ok := <-r.requestChan
+		if !ok || !q {
+			break
+		}
+		if s == nil {
+			r.sessionChan <- nil
+		} else {
+			r.sessionChan <- s.Clone()
+		}
+	}
+	if s != nil {
+		s.Close()
+	}
+	close(r.requestChan)
+	close(r.sessionChan)
+}
+
+//Session retrieves a copy of the active session.
+func (r *MongoRepository) Session() (*mgo.Session, error) {
+	r.requestChan <- true
+	s, ok := <-r.sessionChan
+	if s == nil || !ok {
+		return nil, fmt.Errorf("could not retrieve session")
+	}
+	return s, nil
+}
+
+//Close shuts down the active session.
+func (r *MongoRepository) Close() {
+	r.requestChan <- false
+}
+
+//FindOne
+func (r *MongoRepository) FindOne(n string, m, sl, ret interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return mongoError(s.DB("").C(n).Find(m).Select(sl).One(ret))
+}
+
+//Find
+func (r *MongoRepository) Find(n string, m, sl interface{}, limit int, sort []string, ret interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	q := s.DB("").C(n).Find(m).Limit(limit)
+	if len(sort) > 0 {
+		q = q.Sort(sort...)
+	}
+	return q.Select(sl).All(ret)
+}
+
+//Count
+func (r *MongoRepository) Count(n string, m interface{}) (int, error) {
+	s, e := r.Session()
+	if e != nil {
+		return -1, e
+	}
+	defer s.Close()
+	return s.DB("").C(n).Find(m).Count()
+}
+
+//Distinct
+func (r *MongoRepository) Distinct(n string, m interface{}, k string, ret interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return s.DB("").C(n).Find(m).Distinct(k, ret)
+}
+
+//Insert
+func (r *MongoRepository) Insert(n string, ds ...interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return mongoError(s.DB("").C(n).Insert(ds...))
+}
+
+//Update
+func (r *MongoRepository) Update(n string, m, c interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return mongoError(s.DB("").C(n).Update(m, c))
+}
+
+//UpdateAll
+func (r *MongoRepository) UpdateAll(n string, m, c interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	_, e = s.DB("").C(n).UpdateAll(m, c)
+	return e
+}
+
+//Remove
+func (r *MongoRepository) Remove(n string, m interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return mongoError(s.DB("").C(n).Remove(m))
+}
+
+//RemoveAll
+func (r *MongoRepository) RemoveAll(n string, m interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	_, e = s.DB("").C(n).RemoveAll(m)
+	return e
+}
+
+//ReadGridFile
+func (r *MongoRepository) ReadGridFile(id interface{}) (string, []byte, error) {
+	s, e := r.Session()
+	if e != nil {
+		return "", nil, e
+	}
+	defer s.Close()
+	f, e := s.DB("").GridFS(GRIDFS_NAME).OpenId(id)
+	if e != nil {
+		return "", nil, mongoError(e)
+	}
+	defer f.Close()
+	d, e := ioutil.ReadAll(f)
+	if e != nil {
+		return "", nil, e
+	}
+	return f.ContentType(), d, nil
+}
+
+//WriteGridFile
+func (r *MongoRepository) WriteGridFile(id interface{}, ct string, d []byte) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	f, e := s.DB("").GridFS(GRIDFS_NAME).Create("")
+	if e != nil {
+		return e
+	}
+	f.SetId(id)
+	f.SetContentType(ct)
+	if _, e = f.Write(d); e != nil {
+		f.Close()
+		return e
+	}
+	return f.Close()
+}
+
+//RemoveGridFile
+func (r *MongoRepository) RemoveGridFile(id interface{}) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return mongoError(s.DB("").GridFS(GRIDFS_NAME).RemoveId(id))
+}
+
+//Collections
+func (r *MongoRepository) Collections(db string) ([]string, error) {
+	s, e := r.Session()
+	if e != nil {
+		return nil, e
+	}
+	defer s.Close()
+	return s.DB(db).CollectionNames()
+}
+
+//Databases
+func (r *MongoRepository) Databases() ([]string, error) {
+	s, e := r.Session()
+	if e != nil {
+		return nil, e
+	}
+	defer s.Close()
+	return s.DatabaseNames()
+}
+
+//DeleteDB
+func (r *MongoRepository) DeleteDB(db string) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return s.DB(db).DropDatabase()
+}
+
+//CopyDB
+func (r *MongoRepository) CopyDB(f, t string) error {
+	if e := r.DeleteDB(t); e != nil {
+		return e
+	}
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return s.Run(bson.D{{"copydb", "1"}, {"fromdb", f}, {"todb", t}}, nil)
+}
+
+//CloneCollection
+func (r *MongoRepository) CloneCollection(o, c string) error {
+	s, e := r.Session()
+	if e != nil {
+		return e
+	}
+	defer s.Close()
+	return s.DB("").Run(bson.D{{"cloneCollection", c}, {"from", o}}, nil)
+}
+
+//mongoError converts mgo's errors to their equivalents in this package.
+func mongoError(e error) error {
+	switch {
+	case e == mgo.ErrNotFound:
+		return NotFound
+	case mgo.IsDup(e):
+		return DuplicateId
+	}
+	return e
+}
@@ -47,33 +47,42 @@ func (f *FileInfo) HasCharts() bool {
 
 //File retrieves a file matching m from the active database.
 func File(m, sl interface{}) (*project.File, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
+	sl, d := dataSelector(sl)
 	var f *project.File
-	if e = s.DB("").C(FILES).Find(m).Select(sl).One(&f); e != nil {
+	if e = s.FindOne(FILES, m, sl, &f); e != nil {
 		return nil, &GetError{"file", e, m}
 	}
+	if d {
+		if e = loadData(f, nil); e != nil {
+			return nil, e
+		}
+	}
 	return f, nil
 }
 
 //Files retrieves files matching m from the active database.
 func Files(m, sl interface{}, limit int, sort ...string) ([]*project.File, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
-	q := s.DB("").C(FILES).Find(m).Limit(limit)
-	if len(sort) > 0 {
-		q = q.Sort(sort...)
-	}
+	sl, d := dataSelector(sl)
 	var fs []*project.File
-	if e = q.Select(sl).All(&fs); e != nil {
+	if e = s.Find(FILES, m, sl, limit, sort, &fs); e != nil {
 		return nil, &GetError{"files", e, m}
 	}
+	if d {
+		c := make(map[string]*Blob)
+		for _, f := range fs {
+			if e = loadData(f, c); e != nil {
+				return nil, e
+			}
+		}
+	}
 	return fs, nil
 }
 
@@ -102,13 +111,12 @@ func FileInfos(m bson.M) ([]*FileInfo, error) {
 //FileNames retrieves names of files
 //matching the given interface from the active database.
 func FileNames(m interface{}) ([]string, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var ns []string
-	if e = s.DB("").C(FILES).Find(m).Distinct(NAME, &ns); e != nil {
+	if e = s.Distinct(FILES, m, NAME, &ns); e != nil {
 		return nil, &GetError{"filenames", e, m}
 	}
 	return ns, nil
@@ -116,13 +124,12 @@ func FileNames(m interface{}) ([]string, error) {
 
 //Submission retrieves a submission matching m from the active database.
 func Submission(m, sl interface{}) (*project.Submission, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var sb *project.Submission
-	if e = s.DB("").C(SUBMISSIONS).Find(m).Select(sl).One(&sb); e != nil {
+	if e = s.FindOne(SUBMISSIONS, m, sl, &sb); e != nil {
 		return nil, &GetError{"submission", e, m}
 	}
 	return sb, nil
@@ -130,17 +137,12 @@ func Submission(m, sl interface{}) (*project.Submission, error) {
 
 //Submissions retrieves submissions matching m from the active database.
 func Submissions(m, sl interface{}, sort ...string) ([]*project.Submission, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
-	q := s.DB("").C(SUBMISSIONS).Find(m)
-	if len(sort) > 0 {
-		q = q.Sort(sort...)
-	}
 	var ss []*project.Submission
-	if e = q.Select(sl).All(&ss); e != nil {
+	if e = s.Find(SUBMISSIONS, m, sl, 0, sort, &ss); e != nil {
 		return nil, &GetError{"submissions", e, m}
 	}
 	return ss, nil
@@ -148,13 +150,12 @@ func Submissions(m, sl interface{}, sort ...string) ([]*project.Submission, erro
 
 //Project retrieves a project matching m from the active database.
 func Project(m, sl interface{}) (*project.Project, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var p *project.Project
-	if e = s.DB("").C(PROJECTS).Find(m).Select(sl).One(&p); e != nil {
+	if e = s.FindOne(PROJECTS, m, sl, &p); e != nil {
 		return nil, &GetError{"project", e, m}
 	}
 	return p, nil
@@ -162,63 +163,78 @@ func Project(m, sl interface{}) (*project.Project, error) {
 
 //Projects retrieves projects matching m from the active database.
 func Projects(m, sl interface{}, sort ...string) ([]*project.Project, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
-	q := s.DB("").C(PROJECTS).Find(m)
-	if len(sort) > 0 {
-		q = q.Sort(sort...)
-	}
 	var p []*project.Project
-	if e = q.Select(sl).All(&p); e != nil {
+	if e = s.Find(PROJECTS, m, sl, 0, sort, &p); e != nil {
 		return nil, &GetError{"projects", e, m}
 	}
 	return p, nil
 }
 
 func Skeleton(m, sl interface{}) (*project.Skeleton, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var sk *project.Skeleton
-	if e = s.DB("").C(SKELETONS).Find(m).Select(sl).One(&sk); e != nil {
+	if e = s.FindOne(SKELETONS, m, sl, &sk); e != nil {
 		return nil, &GetError{"skeleton", e, m}
 	}
 	return sk, nil
 }
 
-func Skeletons(m, sl interface{}, sort ...string) ([]*project.Skeleton, error) {
-	s, e := Session()
+//Reference retrieves a project's reference solution matching m from the active database.
+func Reference(m, sl interface{}) (*project.Reference, error) {
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
-	q := s.DB("").C(SKELETONS).Find(m)
-	if len(sort) > 0 {
-		q = q.Sort(sort...)
+	var r *project.Reference
+	if e = s.FindOne(REFERENCES, m, sl, &r); e != nil {
+		return nil, &GetError{"reference solution", e, m}
+	}
+	return r, nil
+}
+
+//AddReference replaces a project's reference solution with r.
+func AddReference(r *project.Reference) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	s.RemoveAll(REFERENCES, bson.M{PROJECTID: r.ProjectId})
+	if e = s.Insert(REFERENCES, r); e != nil {
+		return &AddError{r.Name, e}
+	}
+	return nil
+}
+
+func Skeletons(m, sl interface{}, sort ...string) ([]*project.Skeleton, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
 	}
 	var sk []*project.Skeleton
-	if e = q.Select(sl).All(&sk); e != nil {
+	if e = s.Find(SKELETONS, m, sl, 0, sort, &sk); e != nil {
 		return nil, &GetError{"skeletons", e, m}
 	}
 	return sk, nil
 }
 
-//RemoveFileById removes a file matching the given id from the active database.
+//RemoveFileById removes a file matching the given id, along with its results,
+//their reports and issues, from the active database.
 func RemoveFileById(id interface{}) error {
 	f, e := File(bson.M{ID: id}, bson.M{RESULTS: 1})
 	if e != nil {
 		return e
 	}
 	for _, r := range f.Results {
-		if _, ok := r.(bson.ObjectId); !ok {
-			continue
+		if rid, ok := r.(bson.ObjectId); ok {
+			RemoveResult(rid)
 		}
-		RemoveById(RESULTS, r)
 	}
 	return RemoveById(FILES, id)
 }
@@ -235,6 +251,16 @@ func RemoveSubmissionById(id interface{}) error {
 			return e
 		}
 	}
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	if e = s.RemoveAll(ISSUETRACKS, bson.M{SUBID: id}); e != nil {
+		return &RemoveError{ISSUETRACKS, e, id}
+	}
+	if e = s.RemoveAll(SIMILARITIES, similarityMatcher(id)); e != nil {
+		return &RemoveError{SIMILARITIES, e, id}
+	}
 	return RemoveById(SUBMISSIONS, id)
 }
 
@@ -266,14 +292,61 @@ func RemoveProjectById(id interface{}) error {
 	for _, t := range ts {
 		RemoveById(TESTS, t.Id)
 	}
-	c, e := JPFConfig(pm, is)
-	if e == nil {
-		RemoveById(JPF, c.Id)
-	}
+	RemoveAll(JPF, pm)
 	r, e := PMDRules(pm, is)
 	if e == nil {
 		RemoveById(PMD, r.Id)
 	}
+	k, e := CheckstyleConfig(pm, is)
+	if e == nil {
+		RemoveById(CHECKSTYLE, k.Id)
+	}
+	f, e := FindbugsConfig(pm, is)
+	if e == nil {
+		RemoveById(FINDBUGS, f.Id)
+	}
+	RemoveAll(JAVAC, pm)
+	RemoveAll(GCC, pm)
+	RemoveAll(MAKE, pm)
+	xs, e := ExternalTools(pm, is)
+	if e != nil {
+		return e
+	}
+	for _, x := range xs {
+		RemoveById(EXTERNAL, x.Id)
+	}
+	cs, e := IOTestCases(pm, is)
+	if e != nil {
+		return e
+	}
+	for _, c := range cs {
+		RemoveById(IOTESTS, c.Id)
+	}
+	es, e := Explanations(pm, is)
+	if e != nil {
+		return e
+	}
+	for _, x := range es {
+		RemoveById(EXPLANATIONS, x.Id)
+	}
+	ws, e := Workloads(pm, is)
+	if e != nil {
+		return e
+	}
+	for _, w := range ws {
+		RemoveById(BENCHMARKS, w.Id)
+	}
+	if r, e := Reference(pm, is); e == nil {
+		RemoveById(REFERENCES, r.Id)
+	}
+	vs, e := Validations(pm, is, 0)
+	if e != nil {
+		return e
+	}
+	for _, v := range vs {
+		RemoveById(VALIDATIONS, v.Id)
+	}
+	removeArchives(pm)
 	return RemoveById(PROJECTS, id)
 }
 
@@ -308,13 +381,12 @@ func UpdateTime(sub *project.Submission) error {
 }
 
 func ProjectFileNames(id bson.ObjectId) ([]string, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var ss []bson.ObjectId
-	if e := s.DB("").C(SUBMISSIONS).Find(bson.M{PROJECTID: id}).Distinct(ID, &ss); e != nil {
+	if e := s.Distinct(SUBMISSIONS, bson.M{PROJECTID: id}, ID, &ss); e != nil {
 		return nil, &GetError{"submissions", e, id}
 	}
 	return FileNames(bson.M{SUBID: bson.M{IN: ss}})
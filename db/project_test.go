@@ -37,11 +37,10 @@ import (
 func TestRemoveFile(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	f, e := project.NewFile(bson.NewObjectId(), fileInfo, fileData)
 	if e != nil {
 		t.Error(e)
@@ -60,11 +59,10 @@ func TestRemoveFile(t *testing.T) {
 func TestFile(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	f, e := project.NewFile(bson.NewObjectId(), fileInfo, fileData)
 	if e != nil {
 		t.Error(e)
@@ -82,11 +80,10 @@ func TestFile(t *testing.T) {
 func TestSubmission(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	sub := project.NewSubmission(bson.NewObjectId(), "user", project.FILE_MODE, 1000)
 	if e = Add(SUBMISSIONS, sub); e != nil {
 		t.Error(e)
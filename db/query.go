@@ -0,0 +1,569 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"bytes"
+	"fmt"
+
+	"labix.org/v2/mgo/bson"
+
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//The functions in this file evaluate mongodb style matchers, selectors,
+//sorts and changes against documents held in memory. They are used by
+//backends which are not able to delegate these to mongodb. Documents are
+//expected to have been normalised by toDoc so that they only contain the
+//types produced by unmarshalling bson.
+
+//toDoc converts i to a document containing only bson types.
+func toDoc(i interface{}) (bson.M, error) {
+	if i == nil {
+		return bson.M{}, nil
+	}
+	d, e := bson.Marshal(i)
+	if e != nil {
+		return nil, e
+	}
+	var m bson.M
+	if e = bson.Unmarshal(d, &m); e != nil {
+		return nil, e
+	}
+	return m, nil
+}
+
+//fromDoc unmarshals document d into ret.
+func fromDoc(d bson.M, ret interface{}) error {
+	b, e := bson.Marshal(d)
+	if e != nil {
+		return e
+	}
+	return bson.Unmarshal(b, ret)
+}
+
+//fromDocs unmarshals documents ds into ret which must be a pointer to a slice.
+func fromDocs(ds []bson.M, ret interface{}) error {
+	v := reflect.ValueOf(ret)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected pointer to slice but got %T", ret)
+	}
+	st := v.Elem().Type()
+	s := reflect.MakeSlice(st, 0, len(ds))
+	for _, d := range ds {
+		p := reflect.New(st.Elem())
+		if e := fromDoc(d, p.Interface()); e != nil {
+			return e
+		}
+		s = reflect.Append(s, p.Elem())
+	}
+	v.Elem().Set(s)
+	return nil
+}
+
+//fromValues unmarshals a list of bson values into ret which must be a pointer to a slice.
+func fromValues(vs []interface{}, ret interface{}) error {
+	b, e := bson.Marshal(bson.M{"v": vs})
+	if e != nil {
+		return e
+	}
+	var r struct {
+		V bson.Raw `bson:"v"`
+	}
+	if e = bson.Unmarshal(b, &r); e != nil {
+		return e
+	}
+	return r.V.Unmarshal(ret)
+}
+
+//asDoc converts v to a bson.M if it is a document.
+func asDoc(v interface{}) (bson.M, bool) {
+	switch t := v.(type) {
+	case bson.M:
+		return t, true
+	case map[string]interface{}:
+		return bson.M(t), true
+	}
+	return nil, false
+}
+
+//isOperator checks whether all of the keys in d are operators.
+func isOperator(d bson.M) bool {
+	if len(d) == 0 {
+		return false
+	}
+	for k := range d {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+//lookup retrieves the values found at the dot separated path p in v.
+//Arrays encountered along the path are traversed element by element.
+func lookup(v interface{}, p string) []interface{} {
+	if p == "" {
+		return []interface{}{v}
+	}
+	k, r := p, ""
+	if i := strings.Index(p, "."); i >= 0 {
+		k, r = p[:i], p[i+1:]
+	}
+	if d, ok := asDoc(v); ok {
+		c, ok := d[k]
+		if !ok {
+			return nil
+		}
+		return lookup(c, r)
+	}
+	a, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	if i, e := strconv.Atoi(k); e == nil {
+		if i < 0 || i >= len(a) {
+			return nil
+		}
+		return lookup(a[i], r)
+	}
+	var vs []interface{}
+	for _, c := range a {
+		if _, ok := asDoc(c); ok {
+			vs = append(vs, lookup(c, r)...)
+		}
+	}
+	return vs
+}
+
+//matches checks whether document d matches matcher m.
+func matches(d, m bson.M) bool {
+	for k, c := range m {
+		switch k {
+		case AND, OR, NOR:
+			cs, ok := c.([]interface{})
+			if !ok {
+				return false
+			}
+			n := 0
+			for _, cm := range cs {
+				if sm, ok := asDoc(cm); ok && matches(d, sm) {
+					n++
+				}
+			}
+			if (k == AND && n != len(cs)) || (k == OR && n == 0) || (k == NOR && n > 0) {
+				return false
+			}
+		default:
+			if !matchField(lookup(d, k), c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//matchField checks whether the values vs found at a field satisfy condition c.
+func matchField(vs []interface{}, c interface{}) bool {
+	if o, ok := asDoc(c); ok && isOperator(o) {
+		for op, a := range o {
+			if !matchOperator(vs, op, a) {
+				return false
+			}
+		}
+		return true
+	}
+	return anyEqual(vs, c)
+}
+
+//matchOperator checks whether the values vs satisfy the operator op with argument a.
+func matchOperator(vs []interface{}, op string, a interface{}) bool {
+	switch op {
+	case NE:
+		return !anyEqual(vs, a)
+	case IN, NIN:
+		as, ok := a.([]interface{})
+		if !ok {
+			return false
+		}
+		in := false
+		for _, v := range as {
+			if anyEqual(vs, v) {
+				in = true
+				break
+			}
+		}
+		return in == (op == IN)
+	case EXISTS:
+		return (len(vs) > 0) == truthy(a)
+	case NOT:
+		return !matchField(vs, a)
+	case LT, LTE, GT, GTE:
+		for _, v := range flatten(vs) {
+			c, ok := compare(v, a)
+			if !ok {
+				continue
+			}
+			if (op == LT && c < 0) || (op == LTE && c <= 0) || (op == GT && c > 0) || (op == GTE && c >= 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//anyEqual checks whether any of the values vs, or their elements if they
+//are arrays, equal v. A nil v also matches a missing field.
+func anyEqual(vs []interface{}, v interface{}) bool {
+	if v == nil && len(vs) == 0 {
+		return true
+	}
+	return containsValue(vs, v) || containsValue(flatten(vs), v)
+}
+
+//containsValue checks whether vs contains a value equal to v.
+func containsValue(vs []interface{}, v interface{}) bool {
+	for _, c := range vs {
+		if equal(c, v) {
+			return true
+		}
+	}
+	return false
+}
+
+//flatten replaces arrays in vs with their elements.
+func flatten(vs []interface{}) []interface{} {
+	f := make([]interface{}, 0, len(vs))
+	for _, v := range vs {
+		if a, ok := v.([]interface{}); ok {
+			f = append(f, a...)
+		} else {
+			f = append(f, v)
+		}
+	}
+	return f
+}
+
+//truthy checks whether v is considered true when used as an operator or selector argument.
+func truthy(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	if f, ok := number(v); ok {
+		return f != 0
+	}
+	return v != nil
+}
+
+//number converts v to a float64 if it is a number.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+//equal checks whether a and b are equal bson values.
+func equal(a, b interface{}) bool {
+	if c, ok := compare(a, b); ok {
+		return c == 0
+	}
+	if x, ok := a.([]byte); ok {
+		y, ok := b.([]byte)
+		return ok && bytes.Equal(x, y)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+//compare orders a and b if they are comparable scalar values.
+func compare(a, b interface{}) (int, bool) {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return compareStrings(x, y), true
+		}
+	case bson.ObjectId:
+		if y, ok := b.(bson.ObjectId); ok {
+			return compareStrings(string(x), string(y)), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case y:
+				return -1, true
+			}
+			return 1, true
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1, true
+			case x.After(y):
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+//compareStrings orders strings a and b.
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+//sortDocs sorts ds by the fields in keys. Fields prefixed by '-' are sorted in descending order.
+func sortDocs(ds []bson.M, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.Stable(&docSorter{ds, keys})
+}
+
+type (
+	//docSorter sorts documents by a list of fields.
+	docSorter struct {
+		docs []bson.M
+		keys []string
+	}
+)
+
+func (s *docSorter) Len() int {
+	return len(s.docs)
+}
+
+func (s *docSorter) Swap(i, j int) {
+	s.docs[i], s.docs[j] = s.docs[j], s.docs[i]
+}
+
+func (s *docSorter) Less(i, j int) bool {
+	for _, k := range s.keys {
+		d := 1
+		if strings.HasPrefix(k, "-") {
+			d = -1
+		}
+		k = strings.TrimLeft(k, "+-")
+		a, b := lookup(s.docs[i], k), lookup(s.docs[j], k)
+		var c int
+		switch {
+		case len(a) == 0 && len(b) == 0:
+			c = 0
+		case len(a) == 0:
+			c = -1
+		case len(b) == 0:
+			c = 1
+		default:
+			c, _ = compare(a[0], b[0])
+		}
+		if c != 0 {
+			return c*d < 0
+		}
+	}
+	return false
+}
+
+//selectFields applies selector sl to document d.
+func selectFields(d, sl bson.M) bson.M {
+	if len(sl) == 0 {
+		return d
+	}
+	include := false
+	for _, v := range sl {
+		if truthy(v) {
+			include = true
+			break
+		}
+	}
+	if !include {
+		r := d
+		for k := range sl {
+			r = unsetPath(r, k)
+		}
+		return r
+	}
+	r := bson.M{}
+	if v, ok := sl[ID]; !ok || truthy(v) {
+		if id, ok := d[ID]; ok {
+			r[ID] = id
+		}
+	}
+	for k, v := range sl {
+		if k == ID || !truthy(v) {
+			continue
+		}
+		if vs := lookup(d, k); len(vs) > 0 {
+			r = setPath(r, k, vs[0])
+		}
+	}
+	return r
+}
+
+//applyChange applies the change c to document d and returns the changed document.
+//d is not modified.
+func applyChange(d, c bson.M) (bson.M, error) {
+	if !isOperator(c) {
+		r := bson.M{}
+		for k, v := range c {
+			r[k] = v
+		}
+		r[ID] = d[ID]
+		return r, nil
+	}
+	r := d
+	for op, a := range c {
+		fs, ok := asDoc(a)
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %v for %s", a, op)
+		}
+		for k, v := range fs {
+			if k == ID {
+				return nil, fmt.Errorf("cannot modify %s", ID)
+			}
+			switch op {
+			case SET:
+				r = setPath(r, k, v)
+			case UNSET:
+				r = unsetPath(r, k)
+			case INC:
+				n, ok := number(v)
+				if !ok {
+					return nil, fmt.Errorf("cannot increment %s by %v", k, v)
+				}
+				var o float64
+				if vs := lookup(r, k); len(vs) > 0 {
+					if o, ok = number(vs[0]); !ok {
+						return nil, fmt.Errorf("cannot increment non-numeric field %s", k)
+					}
+				}
+				r = setPath(r, k, increment(v, o+n))
+			case PUSH, PULL:
+				var a []interface{}
+				if vs := lookup(r, k); len(vs) > 0 {
+					if a, ok = vs[0].([]interface{}); !ok {
+						return nil, fmt.Errorf("field %s is not an array", k)
+					}
+				}
+				na := make([]interface{}, 0, len(a)+1)
+				for _, e := range a {
+					if op == PUSH || !equal(e, v) {
+						na = append(na, e)
+					}
+				}
+				if op == PUSH {
+					na = append(na, v)
+				}
+				r = setPath(r, k, na)
+			default:
+				return nil, fmt.Errorf("unsupported operator %s", op)
+			}
+		}
+	}
+	return r, nil
+}
+
+//increment converts the result of incrementing a field by v to v's type.
+func increment(v interface{}, n float64) interface{} {
+	switch v.(type) {
+	case int:
+		return int(n)
+	case int32:
+		return int32(n)
+	case int64:
+		return int64(n)
+	}
+	return n
+}
+
+//setPath returns a copy of d with the value at the dot separated path p set to v.
+func setPath(d bson.M, p string, v interface{}) bson.M {
+	r := make(bson.M, len(d)+1)
+	for k, c := range d {
+		r[k] = c
+	}
+	i := strings.Index(p, ".")
+	if i < 0 {
+		r[p] = v
+		return r
+	}
+	c, _ := asDoc(r[p[:i]])
+	r[p[:i]] = setPath(c, p[i+1:], v)
+	return r
+}
+
+//unsetPath returns a copy of d with the value at the dot separated path p removed.
+func unsetPath(d bson.M, p string) bson.M {
+	i := strings.Index(p, ".")
+	k := p
+	if i >= 0 {
+		k = p[:i]
+	}
+	c, ok := d[k]
+	if !ok {
+		return d
+	}
+	r := make(bson.M, len(d))
+	for n, v := range d {
+		r[n] = v
+	}
+	if i < 0 {
+		delete(r, k)
+	} else if cd, ok := asDoc(c); ok {
+		r[k] = unsetPath(cd, p[i+1:])
+	}
+	return r
+}
@@ -0,0 +1,141 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"labix.org/v2/mgo/bson"
+
+	"reflect"
+	"testing"
+)
+
+var queryDocs = []bson.M{
+	{ID: "a", NAME: "Triangle.java", TIME: 100, "tags": []interface{}{"x", "y"}, RESULTS: bson.M{"javac": "r1"}},
+	{ID: "b", NAME: "Triangle.java", TIME: 200, "tags": []interface{}{"y"}},
+	{ID: "c", NAME: "Square.java", TIME: 300, RESULTS: bson.M{"javac": "r2", "pmd": "r3"}},
+}
+
+func queryIds(m bson.M) []string {
+	var ids []string
+	for _, d := range queryDocs {
+		if matches(d, m) {
+			ids = append(ids, d[ID].(string))
+		}
+	}
+	return ids
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		m   bson.M
+		ids []string
+	}{
+		{bson.M{}, []string{"a", "b", "c"}},
+		{bson.M{NAME: "Triangle.java"}, []string{"a", "b"}},
+		{bson.M{NAME: "Triangle.java", TIME: 200}, []string{"b"}},
+		{bson.M{TIME: bson.M{GT: 100, LTE: 300}}, []string{"b", "c"}},
+		{bson.M{TIME: bson.M{LT: 200}}, []string{"a"}},
+		{bson.M{ID: bson.M{IN: []interface{}{"a", "c"}}}, []string{"a", "c"}},
+		{bson.M{ID: bson.M{NIN: []interface{}{"a", "c"}}}, []string{"b"}},
+		{bson.M{NAME: bson.M{NE: "Square.java"}}, []string{"a", "b"}},
+		{bson.M{RESULTS: bson.M{EXISTS: true}}, []string{"a", "c"}},
+		{bson.M{RESULTS + ".pmd": bson.M{EXISTS: false}}, []string{"a", "b"}},
+		{bson.M{RESULTS + ".javac": "r2"}, []string{"c"}},
+		{bson.M{"tags": "y"}, []string{"a", "b"}},
+		{bson.M{"tags": "x"}, []string{"a"}},
+		{bson.M{OR: []interface{}{bson.M{ID: "a"}, bson.M{TIME: 300}}}, []string{"a", "c"}},
+		{bson.M{AND: []interface{}{bson.M{NAME: "Triangle.java"}, bson.M{TIME: bson.M{GTE: 200}}}}, []string{"b"}},
+		{bson.M{NOR: []interface{}{bson.M{ID: "a"}}}, []string{"b", "c"}},
+		{bson.M{TIME: bson.M{NOT: bson.M{GT: 100}}}, []string{"a"}},
+	}
+	for _, c := range tests {
+		if ids := queryIds(c.m); !reflect.DeepEqual(ids, c.ids) {
+			t.Errorf("Matcher %v matched %v instead of %v.", c.m, ids, c.ids)
+		}
+	}
+}
+
+func TestSortDocs(t *testing.T) {
+	ds := make([]bson.M, len(queryDocs))
+	copy(ds, queryDocs)
+	sortDocs(ds, []string{NAME, "-" + TIME})
+	var ids []string
+	for _, d := range ds {
+		ids = append(ids, d[ID].(string))
+	}
+	if !reflect.DeepEqual(ids, []string{"c", "b", "a"}) {
+		t.Errorf("Invalid sort order %v.", ids)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	d := queryDocs[2]
+	if r := selectFields(d, bson.M{NAME: 1}); !reflect.DeepEqual(r, bson.M{ID: "c", NAME: "Square.java"}) {
+		t.Errorf("Invalid inclusion %v.", r)
+	}
+	if r := selectFields(d, bson.M{ID: 0, RESULTS + ".pmd": 1}); !reflect.DeepEqual(r, bson.M{RESULTS: bson.M{"pmd": "r3"}}) {
+		t.Errorf("Invalid nested inclusion %v.", r)
+	}
+	if r := selectFields(d, bson.M{ID: 1}); !reflect.DeepEqual(r, bson.M{ID: "c"}) {
+		t.Errorf("Invalid id inclusion %v.", r)
+	}
+	r := selectFields(d, bson.M{RESULTS + ".pmd": 0, TIME: 0})
+	if !reflect.DeepEqual(r, bson.M{ID: "c", NAME: "Square.java", RESULTS: bson.M{"javac": "r2"}}) {
+		t.Errorf("Invalid exclusion %v.", r)
+	}
+	if _, ok := d[RESULTS].(bson.M)["pmd"]; !ok {
+		t.Error("Exclusion modified document.")
+	}
+}
+
+func TestApplyChange(t *testing.T) {
+	d := queryDocs[1]
+	r, e := applyChange(d, bson.M{SET: bson.M{NAME: "Circle.java", RESULTS + ".javac": "r4"}, UNSET: bson.M{TIME: ""}, PUSH: bson.M{"tags": "z"}})
+	if e != nil {
+		t.Error(e)
+	}
+	exp := bson.M{ID: "b", NAME: "Circle.java", "tags": []interface{}{"y", "z"}, RESULTS: bson.M{"javac": "r4"}}
+	if !reflect.DeepEqual(r, exp) {
+		t.Errorf("Invalid change %v.", r)
+	}
+	if d[NAME] != "Triangle.java" {
+		t.Error("Change modified document.")
+	}
+	if r, e = applyChange(d, bson.M{INC: bson.M{TIME: 5}}); e != nil {
+		t.Error(e)
+	}
+	if r[TIME] != 205 {
+		t.Errorf("Invalid increment %v.", r[TIME])
+	}
+	if r, e = applyChange(d, bson.M{NAME: "Circle.java"}); e != nil {
+		t.Error(e)
+	}
+	if !reflect.DeepEqual(r, bson.M{ID: "b", NAME: "Circle.java"}) {
+		t.Errorf("Invalid replacement %v.", r)
+	}
+	if _, e = applyChange(d, bson.M{SET: bson.M{ID: "d"}}); e == nil {
+		t.Error("Expected error modifying id.")
+	}
+}
@@ -0,0 +1,120 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"strings"
+)
+
+type (
+	//Repository is implemented by the backends in which Impendulo stores its data.
+	//Data is grouped into named collections of documents (users, projects, submissions,
+	//files, tests, configurations and results) with larger data such as reports
+	//stored separately as grid files.
+	//Matchers, selectors and changes are specified as bson.M documents using the
+	//mongodb syntax and the operators defined in this package.
+	Repository interface {
+		//FindOne retrieves the first document in collection n matching m.
+		//The fields specified by sl are unmarshalled into ret.
+		FindOne(n string, m, sl, ret interface{}) error
+		//Find retrieves up to limit documents from collection n matching m,
+		//ordered by the fields in sort, into ret which must be a pointer to a slice.
+		//A limit of 0 retrieves all matching documents.
+		Find(n string, m, sl interface{}, limit int, sort []string, ret interface{}) error
+		//Count calculates the number of documents in collection n matching m.
+		Count(n string, m interface{}) (int, error)
+		//Distinct retrieves the distinct values of field k in the documents
+		//in collection n matching m into ret which must be a pointer to a slice.
+		Distinct(n string, m interface{}, k string, ret interface{}) error
+		//Insert adds documents to collection n.
+		Insert(n string, ds ...interface{}) error
+		//Update applies the changes c to the first document in collection n matching m.
+		Update(n string, m, c interface{}) error
+		//UpdateAll applies the changes c to all documents in collection n matching m.
+		UpdateAll(n string, m, c interface{}) error
+		//Remove removes the first document in collection n matching m.
+		Remove(n string, m interface{}) error
+		//RemoveAll removes all documents in collection n matching m.
+		RemoveAll(n string, m interface{}) error
+		//ReadGridFile retrieves the content type and data of the grid file matching id.
+		ReadGridFile(id interface{}) (string, []byte, error)
+		//WriteGridFile stores data with the given content type in a new grid file.
+		WriteGridFile(id interface{}, ct string, d []byte) error
+		//RemoveGridFile removes the grid file matching id.
+		RemoveGridFile(id interface{}) error
+		//Collections retrieves the names of the collections in database db.
+		Collections(db string) ([]string, error)
+		//Databases retrieves the names of all available databases.
+		Databases() ([]string, error)
+		//DeleteDB removes database db.
+		DeleteDB(db string) error
+		//CopyDB replaces the contents of database t with a copy of database f.
+		//Database f is left unchanged.
+		CopyDB(f, t string) error
+		//CloneCollection copies collection c from the server at o into the active database.
+		CloneCollection(o, c string) error
+		//Close releases the resources held by this Repository.
+		Close()
+	}
+)
+
+var (
+	active Repository
+)
+
+//Setup creates a Repository for the connection c and makes it the active Repository.
+//Connections starting with FILE_SCHEME use the embedded file backend with the
+//remainder of c specifying the database's directory. All other connections are
+//treated as mongodb urls.
+//This must be called before using any other db functions.
+func Setup(c string) error {
+	var r Repository
+	var e error
+	if strings.HasPrefix(c, FILE_SCHEME) {
+		r, e = NewFileRepository(strings.TrimPrefix(c, FILE_SCHEME))
+	} else {
+		r, e = NewMongoRepository(c)
+	}
+	if e != nil {
+		return e
+	}
+	active = r
+	return nil
+}
+
+//Active retrieves the active Repository.
+func Active() (Repository, error) {
+	if active == nil {
+		return nil, NoRepository
+	}
+	return active, nil
+}
+
+//Close shuts down the active Repository.
+func Close() {
+	if active != nil {
+		active.Close()
+	}
+}
@@ -28,20 +28,26 @@ import (
 	"fmt"
 
 	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/benchmark"
 	"github.com/godfried/impendulo/tool/checkstyle"
 	"github.com/godfried/impendulo/tool/diff"
+	"github.com/godfried/impendulo/tool/external"
 	"github.com/godfried/impendulo/tool/findbugs"
 	"github.com/godfried/impendulo/tool/gcc"
+	"github.com/godfried/impendulo/tool/iotest"
 	"github.com/godfried/impendulo/tool/jacoco"
 	"github.com/godfried/impendulo/tool/javac"
 	"github.com/godfried/impendulo/tool/jpf"
 	"github.com/godfried/impendulo/tool/junit"
+	"github.com/godfried/impendulo/tool/metrics"
+	"github.com/godfried/impendulo/tool/mutation"
 	"github.com/godfried/impendulo/tool/pmd"
 	"github.com/godfried/impendulo/tool/result"
 	"github.com/godfried/impendulo/util"
 	"github.com/godfried/impendulo/util/convert"
-	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
+
+	"strings"
 )
 
 type (
@@ -53,13 +59,12 @@ type (
 //CheckstyleResult retrieves a Result matching
 //the given interface from the active database.
 func CheckstyleResult(m, sl bson.M) (*checkstyle.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *checkstyle.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -73,13 +78,12 @@ func CheckstyleResult(m, sl bson.M) (*checkstyle.Result, error) {
 //PMDResult retrieves a Result matching
 //the given interface from the active database.
 func PMDResult(m, sl bson.M) (*pmd.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *pmd.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -93,13 +97,12 @@ func PMDResult(m, sl bson.M) (*pmd.Result, error) {
 //FindbugsResult retrieves a Result matching
 //the given interface from the active database.
 func FindbugsResult(m, sl bson.M) (*findbugs.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *findbugs.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -113,13 +116,12 @@ func FindbugsResult(m, sl bson.M) (*findbugs.Result, error) {
 //JPFResult retrieves a Result matching
 //the given interface from the active database.
 func JPFResult(m, sl bson.M) (*jpf.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *jpf.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -133,13 +135,50 @@ func JPFResult(m, sl bson.M) (*jpf.Result, error) {
 //JUnitResult retrieves aResult matching
 //the given interface from the active database.
 func JUnitResult(m, sl bson.M) (*junit.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *junit.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
+		return nil, &GetError{"result", e, m}
+	} else if !HasGridFile(r, sl) {
+		return r, nil
+	}
+	if e := GridFile(r.GetId(), &r.Report); e != nil {
+		return nil, e
+	}
+	return r, nil
+}
+
+//MutationResult retrieves a Result matching
+//the given interface from the active database.
+func MutationResult(m, sl bson.M) (*mutation.Result, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var r *mutation.Result
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
+		return nil, &GetError{"result", e, m}
+	} else if !HasGridFile(r, sl) {
+		return r, nil
+	}
+	if e := GridFile(r.GetId(), &r.Report); e != nil {
+		return nil, e
+	}
+	return r, nil
+}
+
+//IOTestResult retrieves a Result matching
+//the given interface from the active database.
+func IOTestResult(m, sl bson.M) (*iotest.Result, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var r *iotest.Result
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -150,14 +189,27 @@ func JUnitResult(m, sl bson.M) (*junit.Result, error) {
 	return r, nil
 }
 
+//BenchmarkResult retrieves a Result matching
+//the given interface from the active database.
+func BenchmarkResult(m, sl bson.M) (*benchmark.Result, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var r *benchmark.Result
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
+		return nil, &GetError{"result", e, m}
+	}
+	return r, nil
+}
+
 func JacocoResult(m, sl bson.M) (*jacoco.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *jacoco.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -171,13 +223,12 @@ func JacocoResult(m, sl bson.M) (*jacoco.Result, error) {
 //JavacResult retrieves a JavacResult matching
 //the given interface from the active database.
 func JavacResult(m, sl bson.M) (*javac.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *javac.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -189,13 +240,12 @@ func JavacResult(m, sl bson.M) (*javac.Result, error) {
 }
 
 func GCCResult(m, sl bson.M) (*gcc.Result, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var r *gcc.Result
-	if e = s.DB("").C(RESULTS).Find(m).Select(sl).One(&r); e != nil {
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
 		return nil, &GetError{"result", e, m}
 	} else if !HasGridFile(r, sl) {
 		return r, nil
@@ -206,14 +256,46 @@ func GCCResult(m, sl bson.M) (*gcc.Result, error) {
 	return r, nil
 }
 
+//ExternalResult retrieves a Result matching
+//the given interface from the active database.
+func ExternalResult(m, sl bson.M) (*external.Result, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var r *external.Result
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
+		return nil, &GetError{"result", e, m}
+	} else if !HasGridFile(r, sl) {
+		return r, nil
+	}
+	if e := GridFile(r.GetId(), &r.Report); e != nil {
+		return nil, e
+	}
+	return r, nil
+}
+
+//MetricsResult retrieves a Result matching
+//the given interface from the active database.
+func MetricsResult(m, sl bson.M) (*metrics.Result, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var r *metrics.Result
+	if e = s.FindOne(RESULTS, m, sl, &r); e != nil {
+		return nil, &GetError{"result", e, m}
+	}
+	return r, nil
+}
+
 func resultType(m bson.M) (string, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return "", e
 	}
-	defer s.Close()
 	var h *TypeHolder
-	if e = s.DB("").C(RESULTS).Find(m).One(&h); e != nil {
+	if e = s.FindOne(RESULTS, m, nil, &h); e != nil {
 		return "", &GetError{"result type", e, m}
 	}
 	return h.Type, nil
@@ -242,6 +324,16 @@ func Tooler(m, sl bson.M) (result.Tooler, error) {
 		return JacocoResult(m, sl)
 	case junit.NAME:
 		return JUnitResult(m, sl)
+	case external.NAME:
+		return ExternalResult(m, sl)
+	case metrics.NAME:
+		return MetricsResult(m, sl)
+	case mutation.NAME:
+		return MutationResult(m, sl)
+	case iotest.NAME:
+		return IOTestResult(m, sl)
+	case benchmark.NAME:
+		return BenchmarkResult(m, sl)
 	default:
 		return nil, fmt.Errorf("unsupported result type %s", t)
 	}
@@ -270,6 +362,16 @@ func Displayer(m, sl bson.M) (result.Displayer, error) {
 		return JacocoResult(m, sl)
 	case junit.NAME:
 		return JUnitResult(m, sl)
+	case external.NAME:
+		return ExternalResult(m, sl)
+	case metrics.NAME:
+		return MetricsResult(m, sl)
+	case mutation.NAME:
+		return MutationResult(m, sl)
+	case iotest.NAME:
+		return IOTestResult(m, sl)
+	case benchmark.NAME:
+		return BenchmarkResult(m, sl)
 	default:
 		return nil, fmt.Errorf("unsupported result type %s", t)
 	}
@@ -298,6 +400,16 @@ func Charter(m, sl bson.M) (result.Charter, error) {
 		return JUnitResult(m, sl)
 	case jacoco.NAME:
 		return JacocoResult(m, sl)
+	case external.NAME:
+		return ExternalResult(m, sl)
+	case metrics.NAME:
+		return MetricsResult(m, sl)
+	case mutation.NAME:
+		return MutationResult(m, sl)
+	case iotest.NAME:
+		return IOTestResult(m, sl)
+	case benchmark.NAME:
+		return BenchmarkResult(m, sl)
 	default:
 		return nil, fmt.Errorf("Unsupported result type %s.", t)
 	}
@@ -318,6 +430,14 @@ func Coder(m, sl bson.M) (result.Coder, error) {
 		return PMDResult(m, sl)
 	case checkstyle.NAME:
 		return CheckstyleResult(m, sl)
+	case external.NAME:
+		return ExternalResult(m, sl)
+	case mutation.NAME:
+		return MutationResult(m, sl)
+	case javac.NAME:
+		return JavacResult(m, sl)
+	case gcc.NAME:
+		return GCCResult(m, sl)
 	default:
 		return nil, fmt.Errorf("Unsupported result type %s.", t)
 	}
@@ -331,21 +451,32 @@ func AddResult(r result.Tooler, n string) error {
 	if e := AddFileResult(r.GetFileId(), n, r.GetId()); e != nil {
 		return e
 	}
+	//Issues and test outcomes are derived before the report is moved to GridFS.
+	is, e := NewIssues(r, n)
+	if e != nil {
+		return e
+	}
+	os, e := NewTestOutcomes(r, n)
+	if e != nil {
+		return e
+	}
 	if r.OnGridFS() {
 		if e := AddGridFile(r.GetId(), r.Reporter()); e != nil {
 			return e
 		}
 		r.SetReport(nil)
 	}
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	if e = s.DB("").C(RESULTS).Insert(r); e != nil {
+	if e = s.Insert(RESULTS, r); e != nil {
 		return &AddError{r.GetName(), e}
 	}
-	return nil
+	if e = AddTestOutcomes(os); e != nil {
+		return e
+	}
+	return AddIssues(is)
 }
 
 //AddFileResult adds or updates a result in a file's results.
@@ -372,94 +503,103 @@ func Charters(fid bson.ObjectId) ([]result.Charter, error) {
 	return rs, nil
 }
 
-//AllResultNames retrieves all result names for a given project.
+//ResultNames retrieves the names of all results of files named fname in a submission.
+//Result names are grouped by tool type and then by tool name with a list of the
+//ids encoded in the result names.
 func ResultNames(sid bson.ObjectId, fname string) (map[string]map[string][]interface{}, error) {
-	mr := &mgo.MapReduce{
-		Map: `function() {
-	var emitted = {};
-	for (r in this.results) {
-            if(r in emitted || r === undefined){
-               continue;		
-            }		
-            var sa = r.split('-');
-	    var sb = sa[0].split(':');
-            var t = sb[0];
-            var n = sb.length >= 2 ? sb[1] : '';
-            var id = sa.length >= 2 ? sa[1] : '';                 
-            if(t === ''){
-                continue;
-            }
-            emitted[r] = true;
-            o = {};
-            o[t] = {};
-            if(n === ''){
-                emit("", o);
-                continue;           
-            }
-            o[t][n] = [];
-            if(o.id !== ''){
-                o[t][n].push(id);
-            }
-            emit('', o);		
-	}
-	
-};`,
-		Reduce: `function(name, values) {
-        var r = {};
-        var added = {};
-        for(i in values){
-            for(k in values[i]){
-                r[k] = values[i][k];
-            }    
-       }
-       return r;
-};`,
-	}
-	s, e := Session()
+	fs, e := Files(bson.M{SUBID: sid, NAME: fname}, bson.M{NAME: 1, RESULTS: 1}, 0)
 	if e != nil {
 		return nil, e
-	}
-	defer s.Close()
-	var ns []*struct {
-		Value map[string]map[string][]interface{} `bson:"value"`
-	}
-	if _, e := s.DB("").C(FILES).Find(bson.M{SUBID: sid, NAME: fname}).
-		Select(bson.M{NAME: 1, RESULTS: 1}).MapReduce(mr, &ns); e != nil {
-		return nil, e
-	} else if len(ns) == 0 {
+	} else if len(fs) == 0 {
 		return nil, fmt.Errorf("no results found")
 	}
-	m := ns[0].Value
+	m := make(map[string]map[string][]interface{})
+	for _, f := range fs {
+		for r := range f.Results {
+			t, n, id := splitResultName(r)
+			if t == "" {
+				continue
+			}
+			if _, ok := m[t]; !ok {
+				m[t] = make(map[string][]interface{})
+			}
+			if n == "" {
+				continue
+			}
+			ids := m[t][n]
+			if ids == nil {
+				ids = []interface{}{}
+			}
+			if id != "" && !containsValue(ids, id) {
+				ids = append(ids, id)
+			}
+			m[t][n] = ids
+		}
+	}
 	m[result.CODE] = map[string][]interface{}{}
 	m[diff.NAME] = map[string][]interface{}{}
+	m[ISSUE_RESULT] = map[string][]interface{}{}
 	return m, nil
 }
 
+//splitResultName splits a result name of the form <type>[:<name>][-<id>] into its parts.
+func splitResultName(r string) (string, string, string) {
+	var n, id string
+	sa := strings.SplitN(r, "-", 2)
+	if len(sa) == 2 {
+		id = sa[1]
+	}
+	sb := strings.SplitN(sa[0], ":", 2)
+	if len(sb) == 2 {
+		n = sb[1]
+	}
+	return sb[0], n, id
+}
+
 func ProjectResults(pid bson.ObjectId) []string {
-	rs := []string{javac.NAME, pmd.NAME, findbugs.NAME, checkstyle.NAME}
+	rs := []string{javac.NAME, pmd.NAME, findbugs.NAME, checkstyle.NAME, metrics.NAME}
+	//Each metric can be compared separately.
+	for _, c := range metrics.Criteria() {
+		rs = append(rs, metrics.NAME+":"+c)
+	}
 	if Contains(JPF, bson.M{PROJECTID: pid}) {
 		rs = append(rs, jpf.NAME)
 	}
-	ts, e := JUnitTests(bson.M{PROJECTID: pid}, bson.M{NAME: 1})
+	if Contains(IOTESTS, bson.M{PROJECTID: pid}) {
+		rs = append(rs, iotest.NAME)
+	}
+	if Contains(BENCHMARKS, bson.M{PROJECTID: pid}) {
+		rs = append(rs, benchmark.NAME)
+	}
+	ts, e := JUnitTests(bson.M{PROJECTID: pid}, bson.M{NAME: 1, TYPE: 1})
 	if e != nil {
 		return rs
 	}
 	for _, t := range ts {
 		n, _ := util.Extension(t.Name)
 		rs = append(rs, junit.NAME+":"+n, jacoco.NAME+":"+n)
+		if t.Type == junit.USER {
+			rs = append(rs, mutation.NAME+":"+n)
+		}
+	}
+	xs, e := ExternalTools(bson.M{PROJECTID: pid}, bson.M{NAME: 1})
+	if e != nil {
+		return rs
+	}
+	for _, x := range xs {
+		rs = append(rs, external.NAME+":"+x.Name)
 	}
 	return rs
 }
 
 func UserResults(u string) []string {
 	rs := []string{javac.NAME, pmd.NAME, findbugs.NAME, checkstyle.NAME}
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return rs
 	}
-	defer s.Close()
 	var ids []bson.ObjectId
-	if e := s.DB("").C(SUBMISSIONS).Find(bson.M{USER: u}).Distinct(PROJECTID, &ids); e != nil || len(ids) == 0 {
+	if e := s.Distinct(SUBMISSIONS, bson.M{USER: u}, PROJECTID, &ids); e != nil || len(ids) == 0 {
 		return rs
 	}
 	type q struct{}
@@ -477,52 +617,30 @@ func UserResults(u string) []string {
 }
 
 func FileResultId(sid bson.ObjectId, fname, rtipe, rname string) (bson.ObjectId, error) {
-	mr := &mgo.MapReduce{
-		Map: `function() {
-	var added = {};
-        var rtipe = "` + rtipe + `";
-        var rname = "` + rname + `";
-	for (n in this.results) {
-                if(n in added){
-                    continue;
-                }
-                added[n] = true;
-                var sa = n.split('-');
-                var sb = sa[0].split(':');
-                if(sa.length < 2 || sb.length < 2 || sb[0] !== rtipe || sb[1] !== rname){
-                    continue;
-                }
-                emit(sa[1], "");
-	}
-};`,
-		Reduce: `function(name, vals) {
-       return name;
-};`,
-	}
-	s, e := Session()
+	fs, e := Files(bson.M{SUBID: sid, NAME: fname}, bson.M{NAME: 1, RESULTS: 1}, 0)
 	if e != nil {
 		return "", e
 	}
-	defer s.Close()
-	var ns []*struct {
-		Value string `bson:"value"`
-	}
-	if _, e := s.DB("").C(FILES).Find(bson.M{SUBID: sid, NAME: fname}).
-		Select(bson.M{NAME: 1, RESULTS: 1}).MapReduce(mr, &ns); e != nil {
-		return "", e
-	}
+	added := make(map[string]bool)
 	var f *project.File
-	for _, hex := range ns {
-		id, e := convert.Id(hex.Value)
-		if e != nil {
-			continue
-		}
-		cf, e := File(bson.M{ID: id}, bson.M{ID: 1, TIME: 1})
-		if e != nil {
-			continue
-		}
-		if f == nil || cf.Time > f.Time {
-			f = cf
+	for _, rf := range fs {
+		for r := range rf.Results {
+			t, n, hex := splitResultName(r)
+			if hex == "" || t != rtipe || n != rname || added[hex] {
+				continue
+			}
+			added[hex] = true
+			id, e := convert.Id(hex)
+			if e != nil {
+				continue
+			}
+			cf, e := File(bson.M{ID: id}, bson.M{ID: 1, TIME: 1})
+			if e != nil {
+				continue
+			}
+			if f == nil || cf.Time > f.Time {
+				f = cf
+			}
 		}
 	}
 	if f == nil {
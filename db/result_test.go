@@ -29,6 +29,7 @@ import (
 	"github.com/godfried/impendulo/tool/checkstyle"
 	"github.com/godfried/impendulo/tool/findbugs"
 	"github.com/godfried/impendulo/tool/javac"
+	"github.com/godfried/impendulo/tool/metrics"
 	"github.com/godfried/impendulo/tool/result"
 
 	"html/template"
@@ -64,11 +65,10 @@ func TestResultNames(t *testing.T) {
 func TestResult(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	f, e := project.NewFile(bson.NewObjectId(), fileInfo, fileData)
 	if e != nil {
 		t.Error(e)
@@ -87,6 +87,35 @@ func TestResult(t *testing.T) {
 	}
 }
 
+func TestMetricsResult(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	f, e := project.NewFile(bson.NewObjectId(), fileInfo, fileData)
+	if e != nil {
+		t.Error(e)
+	}
+	if e = Add(FILES, f); e != nil {
+		t.Error(e)
+	}
+	r := metrics.NewResult(f.Id, fileData)
+	if e = AddResult(r, r.GetName()); e != nil {
+		t.Error(e)
+	}
+	c, e := Charter(bson.M{ID: r.GetId()}, nil)
+	if e != nil {
+		t.Error(e)
+	} else if !reflect.DeepEqual(r.ChartVals(), c.ChartVals()) {
+		t.Errorf("Expected %v but got %v.", r.ChartVals(), c.ChartVals())
+	}
+	found := false
+	for _, n := range ProjectResults(bson.NewObjectId()) {
+		found = found || n == metrics.NAME+":"+metrics.COMPLEXITY
+	}
+	if !found {
+		t.Error("Metrics not found in project results.")
+	}
+}
+
 func javacResult(fileId bson.ObjectId, gridFS bool) *javac.Result {
 	id := bson.NewObjectId()
 	return &javac.Result{
@@ -0,0 +1,85 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
+	"labix.org/v2/mgo/bson"
+)
+
+//SARIF creates a SARIF log containing the analysis results of all the source files
+//matching m. Each tool's results are stored in a separate run and each result records
+//the snapshot, submission and user it belongs to in its properties.
+func SARIF(m bson.M) (*sarif.Log, error) {
+	fm := bson.M{TYPE: project.SRC}
+	for k, v := range m {
+		fm[k] = v
+	}
+	fs, e := Files(fm, bson.M{DATA: 0}, 0, TIME)
+	if e != nil {
+		return nil, e
+	}
+	l := sarif.NewLog()
+	us := make(map[bson.ObjectId]string)
+	for _, f := range fs {
+		u, ok := us[f.SubId]
+		if !ok {
+			s, e := Submission(bson.M{ID: f.SubId}, bson.M{USER: 1})
+			if e != nil {
+				return nil, e
+			}
+			u = s.User
+			us[f.SubId] = u
+		}
+		p := filePath(f)
+		for n, v := range f.Results {
+			id, ok := v.(bson.ObjectId)
+			if !ok {
+				continue
+			}
+			t, e := Tooler(bson.M{ID: id}, nil)
+			if e != nil {
+				continue
+			}
+			s, ok := t.(result.Sarifer)
+			if !ok {
+				continue
+			}
+			run := l.Run(s.GetName())
+			for _, r := range s.SARIF(f.Name) {
+				r.SetUri(p)
+				r.SetProperty("result", n)
+				r.SetProperty("fileId", f.Id.Hex())
+				r.SetProperty("submissionId", f.SubId.Hex())
+				r.SetProperty("user", u)
+				r.SetProperty("time", f.Time)
+				run.Results = append(run.Results, r)
+			}
+		}
+	}
+	return l, nil
+}
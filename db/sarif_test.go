@@ -0,0 +1,72 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/javac"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestSARIF(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	p := project.New("Triangle", "user", "Java", "")
+	if e = Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	s := project.NewSubmission(p.Id, "student", project.FILE_MODE, 1000)
+	if e = Add(SUBMISSIONS, s); e != nil {
+		t.Error(e)
+	}
+	f, e := project.NewFile(s.Id, fileInfo, fileData)
+	if e != nil {
+		t.Error(e)
+	}
+	if e = AddFile(f); e != nil {
+		t.Error(e)
+	}
+	r := javac.NewResult(f.Id, []byte("/tmp/src/triangle/Triangle.java:5: error: ';' expected\n1 error"))
+	if e = AddResult(r, r.GetName()); e != nil {
+		t.Error(e)
+	}
+	l, e := SARIF(bson.M{SUBID: s.Id})
+	if e != nil {
+		t.Error(e)
+	}
+	if len(l.Runs) != 1 || l.Runs[0].Name() != javac.NAME || len(l.Runs[0].Results) != 1 {
+		t.Fatalf("Unexpected SARIF log %v.", l)
+	}
+	sr := l.Runs[0].Results[0]
+	if sr.Uri() != "triangle/Triangle.java" || sr.Properties["user"] != "student" || sr.Properties["fileId"] != f.Id.Hex() {
+		t.Errorf("Unexpected SARIF result %v.", sr)
+	}
+}
@@ -0,0 +1,179 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/similarity"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+//CompareSubmissions compares the latest source of each of a project's submissions
+//with that of every other user's submissions and stores the similar pairs. Code
+//provided by the project's most recent skeleton is ignored. The snapshots of pairs
+//which are at least similarity.THRESHOLD similar are checked to determine whether
+//the shared code appeared suddenly. The project's existing pairs are replaced.
+func CompareSubmissions(pid bson.ObjectId) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	ss, e := Submissions(bson.M{PROJECTID: pid}, bson.M{ID: 1, USER: 1}, TIME)
+	if e != nil {
+		return e
+	}
+	g, e := skeletonGrams(pid)
+	if e != nil {
+		return e
+	}
+	srcs := make([]*similarity.Source, len(ss))
+	for i, sub := range ss {
+		if srcs[i], e = latestSource(sub.Id); e != nil {
+			return e
+		}
+		srcs[i].Exclude(g)
+	}
+	if e = s.RemoveAll(SIMILARITIES, bson.M{PROJECTID: pid}); e != nil {
+		return &RemoveError{SIMILARITIES, e, pid}
+	}
+	t := util.CurMilis()
+	for i, a := range ss {
+		for j := i + 1; j < len(ss); j++ {
+			b := ss[j]
+			if a.User == b.User {
+				continue
+			}
+			p := similarity.Compare(pid, similarity.NewSide(a.Id, a.User, srcs[i]), similarity.NewSide(b.Id, b.User, srcs[j]), t)
+			if p.Matched == 0 {
+				continue
+			}
+			if p.Similarity >= similarity.THRESHOLD {
+				if e = appear(p.A); e != nil {
+					return e
+				}
+				if e = appear(p.B); e != nil {
+					return e
+				}
+			}
+			if e = s.Insert(SIMILARITIES, p); e != nil {
+				return &AddError{SIMILARITIES, e}
+			}
+		}
+	}
+	return nil
+}
+
+//latestSource creates a Source from the latest snapshot of each of a submission's source files.
+func latestSource(sid bson.ObjectId) (*similarity.Source, error) {
+	fs, e := Files(bson.M{SUBID: sid, TYPE: project.SRC}, bson.M{NAME: 1, PKG: 1, TIME: 1}, 0, TIME)
+	if e != nil {
+		return nil, e
+	}
+	latest := make(map[string]*project.File)
+	ps := make([]string, 0, len(fs))
+	for _, f := range fs {
+		p := filePath(f)
+		if _, ok := latest[p]; !ok {
+			ps = append(ps, p)
+		}
+		latest[p] = f
+	}
+	src := similarity.NewSource()
+	for _, p := range ps {
+		f, e := File(bson.M{ID: latest[p].Id}, bson.M{NAME: 1, DATA: 1})
+		if e != nil {
+			return nil, e
+		}
+		src.Add(f.Id, f.Name, f.Data)
+	}
+	return src, nil
+}
+
+//skeletonGrams retrieves the grams of the source files in a project's most recent skeleton.
+func skeletonGrams(pid bson.ObjectId) (similarity.Grams, error) {
+	sks, e := Skeletons(bson.M{PROJECTID: pid}, nil, "-"+ID)
+	if e != nil || len(sks) == 0 {
+		return similarity.Grams{}, e
+	}
+	fs, e := util.UnzipToMap(sks[0].Data)
+	if e != nil {
+		return nil, e
+	}
+	src := similarity.NewSource()
+	for n, d := range fs {
+		if similarity.IsSource(n) {
+			src.Add("", n, d)
+		}
+	}
+	return src.Grams(), nil
+}
+
+//appear checks the snapshots of sd's files containing matches for shared code which appeared suddenly.
+func appear(sd *similarity.Side) error {
+	for _, id := range sd.MatchedFiles() {
+		f, e := File(bson.M{ID: id}, bson.M{NAME: 1, PKG: 1})
+		if e != nil {
+			return e
+		}
+		fs, e := Files(bson.M{SUBID: sd.SubId, NAME: f.Name, PKG: f.Package}, nil, 0, TIME)
+		if e != nil {
+			return e
+		}
+		sd.Appear(id, fs)
+	}
+	return nil
+}
+
+//Similarities retrieves up to limit similar pairs of submissions matching m, most similar first.
+func Similarities(m, sl interface{}, limit int) ([]*similarity.Pair, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var ps []*similarity.Pair
+	if e = s.Find(SIMILARITIES, m, sl, limit, []string{"-" + SIMILARITY}, &ps); e != nil {
+		return nil, &GetError{"similarities", e, m}
+	}
+	return ps, nil
+}
+
+//Similarity retrieves the similar pair of submissions matching m.
+func Similarity(m, sl interface{}) (*similarity.Pair, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var p *similarity.Pair
+	if e = s.FindOne(SIMILARITIES, m, sl, &p); e != nil {
+		return nil, &GetError{"similarity", e, m}
+	}
+	return p, nil
+}
+
+//similarityMatcher matches the similar pairs which the submission sid is part of.
+func similarityMatcher(sid interface{}) bson.M {
+	return bson.M{OR: []interface{}{bson.M{"a." + SUBID: sid}, bson.M{"b." + SUBID: sid}}}
+}
@@ -0,0 +1,144 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"strings"
+	"testing"
+)
+
+var (
+	skeletonSrc = `package triangle;
+
+public class Triangle {
+	private int[] sides;
+
+	public Triangle(int a, int b, int c) {
+		sides = new int[]{a, b, c};
+	}
+`
+	copiedSrc = `
+	public boolean valid() {
+		for (int i = 0; i < sides.length; i++) {
+			if (sides[i] <= 0) {
+				return false;
+			}
+		}
+		return sides[0] + sides[1] > sides[2] && sides[1] + sides[2] > sides[0];
+	}
+}
+`
+	otherSrc = `
+	public double area() {
+		double s = (sides[0] + sides[1] + sides[2]) / 2.0;
+		while (s < 0) {
+			s = Math.abs(s);
+		}
+		return Math.sqrt(s * (s - sides[0]) * (s - sides[1]) * (s - sides[2]));
+	}
+}
+`
+)
+
+func TestCompareSubmissions(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	p := project.New("Triangle", "user", "Java", "")
+	if e := Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	sk, e := util.ZipMap(map[string][]byte{"src/triangle/Triangle.java": []byte(skeletonSrc + "}")})
+	if e != nil {
+		t.Error(e)
+	}
+	if e = Add(SKELETONS, project.NewSkeleton(p.Id, "skeleton", sk)); e != nil {
+		t.Error(e)
+	}
+	renamed := strings.NewReplacer("sides", "lengths", "i ", "j ", "i]", "j]", "i++", "j++").Replace(copiedSrc)
+	snapshots := map[string][]string{
+		"original": []string{skeletonSrc + "}", skeletonSrc + copiedSrc},
+		"copier":   []string{skeletonSrc + "}", skeletonSrc + "\n\tpublic boolean valid() {\n\t\treturn true;\n\t}\n}", skeletonSrc + renamed},
+		"other":    []string{skeletonSrc + otherSrc},
+	}
+	subs := make(map[string]*project.Submission)
+	for _, u := range []string{"original", "copier", "other"} {
+		s := project.NewSubmission(p.Id, u, project.FILE_MODE, 1000)
+		if e = Add(SUBMISSIONS, s); e != nil {
+			t.Error(e)
+		}
+		subs[u] = s
+		for i, d := range snapshots[u] {
+			f, e := project.NewFile(s.Id, fileInfo, []byte(d))
+			if e != nil {
+				t.Error(e)
+			}
+			f.Time = int64(1000 * (i + 1))
+			if e = AddFile(f); e != nil {
+				t.Error(e)
+			}
+		}
+	}
+	if e = CompareSubmissions(p.Id); e != nil {
+		t.Fatal(e)
+	}
+	ps, e := Similarities(bson.M{PROJECTID: p.Id}, nil, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(ps) == 0 {
+		t.Fatal("Expected similar submissions.")
+	}
+	c := ps[0]
+	if c.A.User != "original" || c.B.User != "copier" || c.Similarity != 1 {
+		t.Fatalf("Expected original and copier to be identical but got %s, %s and %f.", c.A.User, c.B.User, c.Similarity)
+	}
+	if len(c.Matches) != 1 {
+		t.Fatalf("Expected a single match but got %d.", len(c.Matches))
+	}
+	if r := c.Matches[0].A; r.Start != 10 || r.End != 18 {
+		t.Errorf("Expected match to exclude the skeleton but got lines %d to %d.", r.Start, r.End)
+	}
+	if !c.B.Sudden() || c.B.Appearance.Time != 3000 {
+		t.Errorf("Expected copied code to have appeared suddenly in the last snapshot but got %v.", c.B.Appearance)
+	}
+	for _, o := range ps[1:] {
+		if o.Similarity >= c.Similarity {
+			t.Errorf("Expected other submission to be less similar but got %f.", o.Similarity)
+		}
+	}
+	if e = RemoveSubmissionById(subs["other"].Id); e != nil {
+		t.Error(e)
+	}
+	if ps, e = Similarities(bson.M{PROJECTID: p.Id}, nil, 0); e != nil || len(ps) != 1 {
+		t.Errorf("Expected other submission's pairs to be removed but got %d, %v.", len(ps), e)
+	}
+	if _, e = Similarity(bson.M{ID: c.Id}, nil); e != nil {
+		t.Error(e)
+	}
+}
@@ -0,0 +1,413 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/junit"
+	"github.com/godfried/impendulo/tool/result"
+	"labix.org/v2/mgo/bson"
+
+	"sort"
+)
+
+type (
+	//TestOutcome is the outcome of a single JUnit test case when it was run on a snapshot.
+	//Outcomes are stored separately from their reports so that a test case can be
+	//followed through a submission's snapshots without loading every report.
+	//Result is the name under which the outcome's result is stored in the file's
+	//results, Test the name of the JUnit test and Case the name of the test case.
+	//Duration is the number of seconds the test case ran for and Flaky is set if
+	//its outcome differed when it was rerun.
+	TestOutcome struct {
+		Id       bson.ObjectId `bson:"_id"`
+		ResultId bson.ObjectId `bson:"resultid"`
+		FileId   bson.ObjectId `bson:"fileid"`
+		SubId    bson.ObjectId `bson:"subid"`
+		Result   string        `bson:"result"`
+		Name     string        `bson:"name"`
+		Test     string        `bson:"test"`
+		Class    string        `bson:"class"`
+		Case     string        `bson:"case"`
+		Status   junit.Status  `bson:"status"`
+		Duration float64       `bson:"duration"`
+		Message  string        `bson:"message"`
+		Flaky    bool          `bson:"flaky"`
+		Time     int64         `bson:"time"`
+	}
+
+	//TestHistory is the outcomes of a test case in each of a submission's snapshots it was run on.
+	TestHistory struct {
+		SubId    bson.ObjectId
+		Test     string
+		Case     string
+		Outcomes []*TestOutcome
+	}
+
+	//TestCaseSummary describes how the submissions of a project fared with a test case.
+	//Solved is the number of submissions in which the test case eventually passed,
+	//SolveTime the mean number of miliseconds and Attempts the mean number of
+	//snapshots it took them to pass it. Regressions is the total number of times
+	//the test case failed again after having passed.
+	TestCaseSummary struct {
+		Test        string
+		Case        string
+		Count       int
+		Solved      int
+		SolveTime   int64
+		Attempts    float64
+		Regressions int
+	}
+
+	//FlakyTest describes how often a test case's outcome differed when it was
+	//rerun. Count is the number of snapshots it was run on and Flaky the
+	//number of those in which it was flaky.
+	FlakyTest struct {
+		Test  string
+		Case  string
+		Count int
+		Flaky int
+	}
+
+	testHistories     []*TestHistory
+	testCaseSummaries []*TestCaseSummary
+	flakyTests        []*FlakyTest
+)
+
+//NewTestOutcomes derives the outcomes of the test cases run by result r which is
+//stored as n in its file's results. Only JUnit results have test case outcomes.
+func NewTestOutcomes(r result.Tooler, n string) ([]*TestOutcome, error) {
+	jr, ok := r.(*junit.Result)
+	if !ok || jr.Report == nil {
+		return nil, nil
+	}
+	f, e := File(bson.M{ID: r.GetFileId()}, bson.M{DATA: 0})
+	if e != nil {
+		return nil, e
+	}
+	return fileTestOutcomes(f, n, jr), nil
+}
+
+//fileTestOutcomes converts the test cases of jr, stored as n in file f's results, into outcomes.
+func fileTestOutcomes(f *project.File, n string, jr *junit.Result) []*TestOutcome {
+	os := make([]*TestOutcome, len(jr.Report.Results))
+	for i, c := range jr.Report.Results {
+		o := &TestOutcome{
+			Id: bson.NewObjectId(), ResultId: jr.Id, FileId: f.Id, SubId: f.SubId,
+			Result: n, Name: f.Name, Test: jr.TestName, Class: c.ClassName, Case: c.Name,
+			Status: c.Status(), Duration: c.Time, Flaky: c.Flaky, Time: f.Time,
+		}
+		if p := c.Problem(); p != nil {
+			o.Message = p.Message
+		}
+		os[i] = o
+	}
+	return os
+}
+
+//AddTestOutcomes adds os to the active database.
+func AddTestOutcomes(os []*TestOutcome) error {
+	if len(os) == 0 {
+		return nil
+	}
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	ds := make([]interface{}, len(os))
+	for i, o := range os {
+		ds[i] = o
+	}
+	if e = s.Insert(TESTOUTCOMES, ds...); e != nil {
+		return &AddError{TESTOUTCOMES, e}
+	}
+	return nil
+}
+
+//TestOutcomes retrieves up to limit TestOutcomes matching m, ordered by test, test case and time.
+func TestOutcomes(m, sl interface{}, limit int) ([]*TestOutcome, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var os []*TestOutcome
+	if e = s.Find(TESTOUTCOMES, m, sl, limit, []string{TEST, CASE, TIME}, &os); e != nil {
+		return nil, &GetError{"test outcomes", e, m}
+	}
+	return os, nil
+}
+
+//RebuildTestOutcomes recreates the test case outcomes of the source files matching m
+//from their JUnit results. It is used to index results which were added before
+//outcomes were stored and to restore the outcomes of archived projects.
+func RebuildTestOutcomes(m bson.M) (int, error) {
+	s, e := Active()
+	if e != nil {
+		return 0, e
+	}
+	fm := bson.M{TYPE: project.SRC}
+	for k, v := range m {
+		fm[k] = v
+	}
+	fs, e := Files(fm, bson.M{DATA: 0}, 0)
+	if e != nil {
+		return 0, e
+	}
+	n := 0
+	for _, f := range fs {
+		if e = s.RemoveAll(TESTOUTCOMES, bson.M{FILEID: f.Id}); e != nil {
+			return n, &RemoveError{TESTOUTCOMES, e, f.Id}
+		}
+		for rn, v := range f.Results {
+			id, ok := v.(bson.ObjectId)
+			if !ok {
+				continue
+			}
+			t, e := Tooler(bson.M{ID: id}, nil)
+			if e != nil {
+				continue
+			}
+			jr, ok := t.(*junit.Result)
+			if !ok || jr.Report == nil {
+				continue
+			}
+			os := fileTestOutcomes(f, rn, jr)
+			if e = AddTestOutcomes(os); e != nil {
+				return n, e
+			}
+			n += len(os)
+		}
+	}
+	return n, nil
+}
+
+//TestHistories follows the test cases of the TestOutcomes matching m through
+//the snapshots of each submission they were run on.
+func TestHistories(m interface{}) ([]*TestHistory, error) {
+	os, e := TestOutcomes(m, nil, 0)
+	if e != nil {
+		return nil, e
+	}
+	return testCaseHistories(os), nil
+}
+
+//testCaseHistories groups os, which must be sorted by time, into the histories of
+//each submission's test cases, ordered by test and test case.
+func testCaseHistories(os []*TestOutcome) []*TestHistory {
+	hm := make(map[string]*TestHistory)
+	hs := make(testHistories, 0, 10)
+	for _, o := range os {
+		k := o.SubId.Hex() + ":" + o.Test + ":" + o.Case
+		h, ok := hm[k]
+		if !ok {
+			h = &TestHistory{SubId: o.SubId, Test: o.Test, Case: o.Case}
+			hm[k] = h
+			hs = append(hs, h)
+		}
+		h.Outcomes = append(h.Outcomes, o)
+	}
+	sort.Sort(hs)
+	return hs
+}
+
+//FirstPass retrieves the position of the first outcome in which the
+//test case passed, -1 if it never did.
+func (h *TestHistory) FirstPass() int {
+	for i, o := range h.Outcomes {
+		if o.Status == junit.PASSED {
+			return i
+		}
+	}
+	return -1
+}
+
+//Solved checks whether the test case passed in the last snapshot it was run on.
+func (h *TestHistory) Solved() bool {
+	return len(h.Outcomes) > 0 && h.Outcomes[len(h.Outcomes)-1].Status == junit.PASSED
+}
+
+//SolveTime is the number of miliseconds between the first snapshot the test case
+//was run on and the first one it passed in, up until the last snapshot if it never passed.
+func (h *TestHistory) SolveTime() int64 {
+	if len(h.Outcomes) == 0 {
+		return 0
+	}
+	l := h.Outcomes[len(h.Outcomes)-1]
+	if i := h.FirstPass(); i != -1 {
+		l = h.Outcomes[i]
+	}
+	return l.Time - h.Outcomes[0].Time
+}
+
+//Regressions is the number of times the test case didn't pass after it had passed in the previous snapshot.
+func (h *TestHistory) Regressions() int {
+	n := 0
+	for i := 1; i < len(h.Outcomes); i++ {
+		if h.Outcomes[i-1].Status == junit.PASSED && h.Outcomes[i].Status != junit.PASSED {
+			n++
+		}
+	}
+	return n
+}
+
+//TestCaseSummaries summarises the TestHistories of the TestOutcomes matching m for each
+//test case. Test cases which the most submissions haven't solved come first followed by
+//those which took the longest to solve.
+func TestCaseSummaries(m interface{}) ([]*TestCaseSummary, error) {
+	hs, e := TestHistories(m)
+	if e != nil {
+		return nil, e
+	}
+	return NewTestCaseSummaries(hs), nil
+}
+
+//NewTestCaseSummaries summarises the histories hs for each test case.
+func NewTestCaseSummaries(hs []*TestHistory) []*TestCaseSummary {
+	sm := make(map[string]*TestCaseSummary)
+	ss := make(testCaseSummaries, 0, len(hs))
+	for _, h := range hs {
+		k := h.Test + ":" + h.Case
+		s, ok := sm[k]
+		if !ok {
+			s = &TestCaseSummary{Test: h.Test, Case: h.Case}
+			sm[k] = s
+			ss = append(ss, s)
+		}
+		s.Count++
+		s.Regressions += h.Regressions()
+		if h.Solved() {
+			s.Solved++
+			s.SolveTime += h.SolveTime()
+			s.Attempts += float64(h.FirstPass() + 1)
+		}
+	}
+	for _, s := range ss {
+		if s.Solved > 0 {
+			s.SolveTime /= int64(s.Solved)
+			s.Attempts /= float64(s.Solved)
+		}
+	}
+	sort.Sort(ss)
+	return ss
+}
+
+//Unsolved is the number of submissions in which the test case never passed.
+func (s *TestCaseSummary) Unsolved() int {
+	return s.Count - s.Solved
+}
+
+//FlakyTests retrieves the test cases of the TestOutcomes matching m which were flaky
+//at least once, from the most to the least flaky.
+func FlakyTests(m interface{}) ([]*FlakyTest, error) {
+	os, e := TestOutcomes(m, bson.M{TEST: 1, CASE: 1, FLAKY: 1}, 0)
+	if e != nil {
+		return nil, e
+	}
+	fm := make(map[string]*FlakyTest)
+	fs := make(flakyTests, 0, 10)
+	for _, o := range os {
+		k := o.Test + ":" + o.Case
+		f, ok := fm[k]
+		if !ok {
+			f = &FlakyTest{Test: o.Test, Case: o.Case}
+			fm[k] = f
+			fs = append(fs, f)
+		}
+		f.Count++
+		if o.Flaky {
+			f.Flaky++
+		}
+	}
+	r := make(flakyTests, 0, len(fs))
+	for _, f := range fs {
+		if f.Flaky > 0 {
+			r = append(r, f)
+		}
+	}
+	sort.Sort(r)
+	return r, nil
+}
+
+//Rate is the percentage of snapshots in which the test case was flaky.
+func (f *FlakyTest) Rate() float64 {
+	if f.Count == 0 {
+		return 0
+	}
+	return float64(f.Flaky) * 100 / float64(f.Count)
+}
+
+func (h testHistories) Len() int {
+	return len(h)
+}
+
+func (h testHistories) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h testHistories) Less(i, j int) bool {
+	if h[i].Test != h[j].Test {
+		return h[i].Test < h[j].Test
+	}
+	if h[i].Case != h[j].Case {
+		return h[i].Case < h[j].Case
+	}
+	return h[i].SubId < h[j].SubId
+}
+
+func (s testCaseSummaries) Len() int {
+	return len(s)
+}
+
+func (s testCaseSummaries) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s testCaseSummaries) Less(i, j int) bool {
+	if s[i].Unsolved() != s[j].Unsolved() {
+		return s[i].Unsolved() > s[j].Unsolved()
+	}
+	if s[i].SolveTime != s[j].SolveTime {
+		return s[i].SolveTime > s[j].SolveTime
+	}
+	return s[i].Test+s[i].Case < s[j].Test+s[j].Case
+}
+
+func (f flakyTests) Len() int {
+	return len(f)
+}
+
+func (f flakyTests) Swap(i, j int) {
+	f[i], f[j] = f[j], f[i]
+}
+
+func (f flakyTests) Less(i, j int) bool {
+	if f[i].Rate() != f[j].Rate() {
+		return f[i].Rate() > f[j].Rate()
+	}
+	if f[i].Flaky != f[j].Flaky {
+		return f[i].Flaky > f[j].Flaky
+	}
+	return f[i].Test+f[i].Case < f[j].Test+f[j].Case
+}
@@ -0,0 +1,148 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/junit"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+var junitXML = []byte(`<testsuite errors="1" failures="1" skipped="1" name="testing.AllTests" tests="4" time="0.3">
+<testcase classname="testing.AllTests" name="testValid" time="0.1"/>
+<testcase classname="testing.AllTests" name="testArea" time="0.1"><failure message="expected 6" type="junit.framework.AssertionFailedError">trace</failure></testcase>
+<testcase classname="testing.AllTests" name="testNull" time="0.1"><error message="null" type="java.lang.NullPointerException">trace</error></testcase>
+<testcase classname="testing.AllTests" name="testSlow" time="0"><skipped/></testcase>
+</testsuite>`)
+
+func TestTestOutcomes(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	p := project.New("Triangle", "user", "Java", "")
+	if e := Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	s := project.NewSubmission(p.Id, "student", project.FILE_MODE, 1000)
+	if e := Add(SUBMISSIONS, s); e != nil {
+		t.Error(e)
+	}
+	f, e := project.NewFile(s.Id, fileInfo, fileData)
+	if e != nil {
+		t.Error(e)
+	}
+	if e = AddFile(f); e != nil {
+		t.Error(e)
+	}
+	r, e := junit.NewResult(f.Id, bson.NewObjectId(), "AllTests.java", junitXML)
+	if e != nil {
+		t.Fatal(e)
+	}
+	n := junit.NAME + ":AllTests"
+	if e = AddResult(r, n); e != nil {
+		t.Error(e)
+	}
+	os, e := TestOutcomes(bson.M{SUBID: s.Id}, nil, 0)
+	if e != nil {
+		t.Error(e)
+	}
+	expected := map[string]junit.Status{"testValid": junit.PASSED, "testArea": junit.FAILED, "testNull": junit.ERROR, "testSlow": junit.SKIPPED}
+	if len(os) != len(expected) {
+		t.Fatalf("Expected %d outcomes, got %d.", len(expected), len(os))
+	}
+	for _, o := range os {
+		if o.Status != expected[o.Case] || o.Result != n || o.Test != "AllTests.java" || o.ResultId != r.Id || o.Time != f.Time {
+			t.Errorf("Unexpected outcome %+v.", o)
+		}
+	}
+	if e = RemoveResult(r.Id); e != nil {
+		t.Error(e)
+	}
+	if Contains(TESTOUTCOMES, bson.M{SUBID: s.Id}) {
+		t.Error("Expected outcomes to be removed with their result.")
+	}
+}
+
+func TestFlakyTests(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	sid := bson.NewObjectId()
+	outcome := func(c string, flaky bool) *TestOutcome {
+		return &TestOutcome{Id: bson.NewObjectId(), SubId: sid, Test: "AllTests.java", Case: c, Status: junit.PASSED, Flaky: flaky}
+	}
+	os := []*TestOutcome{
+		outcome("testArea", true), outcome("testArea", false), outcome("testArea", false), outcome("testArea", false),
+		outcome("testThreads", true), outcome("testThreads", true), outcome("testValid", false),
+	}
+	if e := AddTestOutcomes(os); e != nil {
+		t.Fatal(e)
+	}
+	fs, e := FlakyTests(bson.M{SUBID: sid})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(fs) != 2 {
+		t.Fatalf("Expected 2 flaky tests, got %d.", len(fs))
+	}
+	if fs[0].Case != "testThreads" || fs[0].Rate() != 100 || fs[1].Case != "testArea" || fs[1].Flaky != 1 || fs[1].Rate() != 25 {
+		t.Errorf("Unexpected flaky tests %+v %+v.", fs[0], fs[1])
+	}
+}
+
+func TestTestHistories(t *testing.T) {
+	a, b := bson.NewObjectId(), bson.NewObjectId()
+	outcome := func(sid bson.ObjectId, c string, s junit.Status, tm int64) *TestOutcome {
+		return &TestOutcome{SubId: sid, Test: "AllTests.java", Case: c, Status: s, Time: tm}
+	}
+	os := []*TestOutcome{
+		outcome(a, "testArea", junit.FAILED, 1000), outcome(a, "testArea", junit.PASSED, 2000),
+		outcome(a, "testArea", junit.FAILED, 3000), outcome(a, "testArea", junit.PASSED, 4000),
+		outcome(b, "testArea", junit.FAILED, 1000), outcome(b, "testArea", junit.FAILED, 5000),
+		outcome(a, "testValid", junit.PASSED, 1000), outcome(b, "testValid", junit.ERROR, 1000),
+		outcome(b, "testValid", junit.PASSED, 3000),
+	}
+	hs := testCaseHistories(os)
+	if len(hs) != 4 {
+		t.Fatalf("Expected 4 histories, got %d.", len(hs))
+	}
+	for _, h := range hs {
+		if h.Case == "testArea" && h.SubId == a && (!h.Solved() || h.FirstPass() != 1 || h.SolveTime() != 1000 || h.Regressions() != 1) {
+			t.Errorf("Unexpected history %+v.", h)
+		} else if h.Case == "testArea" && h.SubId == b && (h.Solved() || h.FirstPass() != -1 || h.SolveTime() != 4000) {
+			t.Errorf("Unexpected history %+v.", h)
+		}
+	}
+	ss := NewTestCaseSummaries(hs)
+	if len(ss) != 2 {
+		t.Fatalf("Expected 2 summaries, got %d.", len(ss))
+	}
+	if ss[0].Case != "testArea" || ss[0].Unsolved() != 1 || ss[0].Regressions != 1 || ss[0].SolveTime != 1000 || ss[0].Attempts != 2 {
+		t.Errorf("Unexpected summary %+v.", ss[0])
+	}
+	if ss[1].Case != "testValid" || ss[1].Solved != 2 || ss[1].SolveTime != 1000 || ss[1].Attempts != 1.5 {
+		t.Errorf("Unexpected summary %+v.", ss[1])
+	}
+}
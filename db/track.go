@@ -0,0 +1,293 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"labix.org/v2/mgo/bson"
+
+	"sort"
+)
+
+type (
+	//IssueTrack follows an issue through the consecutive snapshots of a file in a
+	//submission. Introduced is the time of the snapshot in which the issue first
+	//appeared, Last the time of the last snapshot in which it was present and Fixed
+	//the time of the first snapshot, analysed by the same tool, in which it was gone.
+	//Fixed is 0 if the issue was never fixed. Lifetime is the number of miliseconds
+	//the issue existed for, up until the last snapshot if it was never fixed.
+	IssueTrack struct {
+		Id         bson.ObjectId `bson:"_id"`
+		SubId      bson.ObjectId `bson:"subid"`
+		Name       string        `bson:"name"`
+		Result     string        `bson:"result"`
+		Tool       string        `bson:"tool"`
+		Rule       string        `bson:"rule"`
+		Category   string        `bson:"category"`
+		Severity   string        `bson:"severity"`
+		Message    string        `bson:"message"`
+		Line       int           `bson:"line"`
+		Snapshots  int           `bson:"snapshots"`
+		Introduced int64         `bson:"introduced"`
+		Last       int64         `bson:"last"`
+		Fixed      int64         `bson:"fixed"`
+		Lifetime   int64         `bson:"lifetime"`
+	}
+
+	//IssueSummary describes how quickly the issues reported for a rule were fixed.
+	//FixTime is the mean lifetime of the fixed issues.
+	IssueSummary struct {
+		Tool     string
+		Rule     string
+		Category string
+		Count    int
+		Fixed    int
+		FixTime  int64
+	}
+
+	issueSummaries []*IssueSummary
+)
+
+//newIssueTrack starts tracking issue i.
+func newIssueTrack(i *Issue) *IssueTrack {
+	return &IssueTrack{
+		Id: bson.NewObjectId(), SubId: i.SubId, Name: i.Name, Result: i.Result,
+		Tool: i.Tool, Rule: i.Rule, Category: i.Category, Introduced: i.Time,
+	}
+}
+
+//update records that i is the issue's occurrence in the next snapshot.
+func (t *IssueTrack) update(i *Issue) {
+	t.Severity, t.Message, t.Line = i.Severity, i.Message, i.Start
+	t.Last = i.Time
+	t.Lifetime = t.Last - t.Introduced
+	t.Snapshots++
+}
+
+//fix records that the issue was fixed in the snapshot created at time f.
+func (t *IssueTrack) fix(f int64) {
+	t.Fixed = f
+	t.Lifetime = f - t.Introduced
+}
+
+//IsFixed
+func (t *IssueTrack) IsFixed() bool {
+	return t.Fixed > 0
+}
+
+//Open is the number of issues which were never fixed.
+func (s *IssueSummary) Open() int {
+	return s.Count - s.Fixed
+}
+
+//TrackIssues follows the issues found in a submission's snapshots from the
+//snapshot in which they were introduced to the one in which they were fixed.
+//The submission's existing issue tracks are replaced.
+func TrackIssues(sid bson.ObjectId) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	fs, e := Files(bson.M{SUBID: sid, TYPE: project.SRC}, bson.M{DATA: 0}, 0, TIME)
+	if e != nil {
+		return e
+	}
+	is, e := Issues(bson.M{SUBID: sid}, nil, 0)
+	if e != nil {
+		return e
+	}
+	fi := make(map[bson.ObjectId][]*Issue)
+	for _, i := range is {
+		fi[i.FileId] = append(fi[i.FileId], i)
+	}
+	if e = s.RemoveAll(ISSUETRACKS, bson.M{SUBID: sid}); e != nil {
+		return &RemoveError{ISSUETRACKS, e, sid}
+	}
+	ts := trackIssues(fs, fi)
+	if len(ts) == 0 {
+		return nil
+	}
+	ds := make([]interface{}, len(ts))
+	for i, t := range ts {
+		ds[i] = t
+	}
+	if e = s.Insert(ISSUETRACKS, ds...); e != nil {
+		return &AddError{ISSUETRACKS, e}
+	}
+	return nil
+}
+
+//trackIssues follows the issues in is, grouped by snapshot id, through the snapshots fs
+//which must be sorted by time. Issues are followed separately for each file and result
+//and a snapshot is only considered if the result's tool was run on it successfully.
+func trackIssues(fs []*project.File, is map[bson.ObjectId][]*Issue) []*IssueTrack {
+	open := make(map[string][]*IssueTrack)
+	ts := make([]*IssueTrack, 0, 10)
+	for _, f := range fs {
+		ri := make(map[string][]*Issue)
+		for _, i := range is[f.Id] {
+			ri[i.Result] = append(ri[i.Result], i)
+		}
+		for r, v := range f.Results {
+			if _, ok := v.(bson.ObjectId); !ok {
+				continue
+			}
+			k := f.Name + ":" + r
+			var a []*IssueTrack
+			open[k], a = matchIssues(open[k], ri[r], f.Time)
+			ts = append(ts, a...)
+		}
+	}
+	return ts
+}
+
+//matchIssues matches the issues is found in a snapshot created at time t to the tracks
+//which were open in the previous snapshot. Issues are matched to tracks with the same
+//rule and message, or failing that the same rule, and the track closest to the issue's
+//line is preferred so that issues are still matched when lines are added or removed.
+//Tracks which aren't matched are fixed. The tracks still open and the new tracks are returned.
+func matchIssues(open []*IssueTrack, is []*Issue, t int64) ([]*IssueTrack, []*IssueTrack) {
+	ts := make([]*IssueTrack, len(is))
+	used := make([]bool, len(open))
+	for _, same := range []func(*IssueTrack, *Issue) bool{sameMessage, sameRule} {
+		for n, i := range is {
+			if ts[n] != nil {
+				continue
+			}
+			if b := nearestTrack(open, used, i, same); b != -1 {
+				used[b] = true
+				ts[n] = open[b]
+			}
+		}
+	}
+	var a []*IssueTrack
+	for n, i := range is {
+		if ts[n] == nil {
+			ts[n] = newIssueTrack(i)
+			a = append(a, ts[n])
+		}
+		ts[n].update(i)
+	}
+	for j, o := range open {
+		if !used[j] {
+			o.fix(t)
+		}
+	}
+	return ts, a
+}
+
+//nearestTrack retrieves the index of the unused track closest to issue i which
+//matches it according to same, or -1 if there is none.
+func nearestTrack(ts []*IssueTrack, used []bool, i *Issue, same func(*IssueTrack, *Issue) bool) int {
+	b := -1
+	for j, t := range ts {
+		if used[j] || !same(t, i) {
+			continue
+		}
+		if b == -1 || distance(t.Line, i.Start) < distance(ts[b].Line, i.Start) {
+			b = j
+		}
+	}
+	return b
+}
+
+func sameRule(t *IssueTrack, i *Issue) bool {
+	return t.Rule == i.Rule
+}
+
+func sameMessage(t *IssueTrack, i *Issue) bool {
+	return t.Rule == i.Rule && t.Message == i.Message
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+//IssueTracks retrieves up to limit IssueTracks matching m, longest lived first.
+func IssueTracks(m, sl interface{}, limit int) ([]*IssueTrack, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var ts []*IssueTrack
+	if e = s.Find(ISSUETRACKS, m, sl, limit, []string{"-" + LIFETIME}, &ts); e != nil {
+		return nil, &GetError{"issue tracks", e, m}
+	}
+	return ts, nil
+}
+
+//IssueSummaries summarises the IssueTracks matching m for each rule. Rules with
+//the most issues which were never fixed come first followed by those whose issues
+//took the longest to fix.
+func IssueSummaries(m interface{}) ([]*IssueSummary, error) {
+	ts, e := IssueTracks(m, bson.M{TOOL: 1, RULE: 1, CATEGORY: 1, FIXED: 1, LIFETIME: 1}, 0)
+	if e != nil {
+		return nil, e
+	}
+	sm := make(map[string]*IssueSummary)
+	ss := make(issueSummaries, 0, len(ts))
+	for _, t := range ts {
+		k := t.Tool + ":" + t.Rule
+		s, ok := sm[k]
+		if !ok {
+			s = &IssueSummary{Tool: t.Tool, Rule: t.Rule, Category: t.Category}
+			sm[k] = s
+			ss = append(ss, s)
+		}
+		s.Count++
+		if t.IsFixed() {
+			s.FixTime += t.Lifetime
+			s.Fixed++
+		}
+	}
+	for _, s := range ss {
+		if s.Fixed > 0 {
+			s.FixTime /= int64(s.Fixed)
+		}
+	}
+	sort.Sort(ss)
+	return ss, nil
+}
+
+func (s issueSummaries) Len() int {
+	return len(s)
+}
+
+func (s issueSummaries) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s issueSummaries) Less(i, j int) bool {
+	if s[i].Open() != s[j].Open() {
+		return s[i].Open() > s[j].Open()
+	}
+	if s[i].FixTime != s[j].FixTime {
+		return s[i].FixTime > s[j].FixTime
+	}
+	return s[i].Tool+s[i].Rule < s[j].Tool+s[j].Rule
+}
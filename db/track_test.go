@@ -0,0 +1,95 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/javac"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestTrackIssues(t *testing.T) {
+	fs := make([]*project.File, 4)
+	for i := range fs {
+		fs[i] = &project.File{Id: bson.NewObjectId(), Name: "Triangle.java", Time: int64(i+1) * 1000, Results: bson.M{javac.NAME: bson.NewObjectId()}}
+	}
+	//The compiler failed to run on the third snapshot.
+	fs[2].Results[javac.NAME] = "timeout"
+	issue := func(f *project.File, rule, msg string, line int) *Issue {
+		return &Issue{FileId: f.Id, Name: f.Name, Result: javac.NAME, Tool: javac.NAME, Rule: rule, Message: msg, Start: line, Time: f.Time}
+	}
+	is := map[bson.ObjectId][]*Issue{
+		fs[0].Id: []*Issue{issue(fs[0], "R1", "m1", 5), issue(fs[0], "R2", "m2", 10)},
+		fs[1].Id: []*Issue{issue(fs[1], "R1", "m1", 7), issue(fs[1], "R2", "m2", 12), issue(fs[1], "R1", "m3", 20)},
+		fs[3].Id: []*Issue{issue(fs[3], "R1", "m1 changed", 8)},
+	}
+	ts := trackIssues(fs, is)
+	if len(ts) != 3 {
+		t.Fatalf("Expected 3 tracks, got %d.", len(ts))
+	}
+	expected := []struct {
+		rule                  string
+		snapshots, line       int
+		introduced, fixed, lt int64
+	}{
+		{"R1", 3, 8, 1000, 0, 3000},
+		{"R2", 2, 12, 1000, 4000, 3000},
+		{"R1", 1, 20, 2000, 4000, 2000},
+	}
+	for i, x := range expected {
+		tr := ts[i]
+		if tr.Rule != x.rule || tr.Snapshots != x.snapshots || tr.Line != x.line || tr.Introduced != x.introduced || tr.Fixed != x.fixed || tr.Lifetime != x.lt {
+			t.Errorf("Unexpected track %d %+v.", i, tr)
+		}
+	}
+}
+
+func TestIssueSummaries(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	sid := bson.NewObjectId()
+	ts := []*IssueTrack{
+		&IssueTrack{Id: bson.NewObjectId(), SubId: sid, Tool: javac.NAME, Rule: "R1", Fixed: 3000, Lifetime: 2000},
+		&IssueTrack{Id: bson.NewObjectId(), SubId: sid, Tool: javac.NAME, Rule: "R1", Fixed: 5000, Lifetime: 4000},
+		&IssueTrack{Id: bson.NewObjectId(), SubId: sid, Tool: javac.NAME, Rule: "R2", Lifetime: 1000},
+	}
+	for _, tr := range ts {
+		if e := Add(ISSUETRACKS, tr); e != nil {
+			t.Error(e)
+		}
+	}
+	ss, e := IssueSummaries(bson.M{SUBID: sid})
+	if e != nil {
+		t.Error(e)
+	}
+	if len(ss) != 2 {
+		t.Fatalf("Expected 2 summaries, got %d.", len(ss))
+	}
+	if ss[0].Rule != "R2" || ss[0].Open() != 1 || ss[1].Rule != "R1" || ss[1].Fixed != 2 || ss[1].FixTime != 3000 {
+		t.Errorf("Unexpected summaries %+v %+v.", ss[0], ss[1])
+	}
+}
@@ -0,0 +1,421 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"os"
+)
+
+type (
+	//Trash records a deletion made by a user. The deleted documents, including
+	//all documents which depend on them, are moved to the trash and can be
+	//restored until the Trash expires and is purged.
+	Trash struct {
+		Id      bson.ObjectId `bson:"_id"`
+		Type    string        `bson:"type"`
+		Name    string        `bson:"name"`
+		User    string        `bson:"user"`
+		Time    int64         `bson:"time"`
+		Expires int64         `bson:"expires"`
+	}
+
+	//Trashed is a document which has been moved to the trash.
+	//Documents which were modified rather than removed by a deletion are
+	//stored with Merge set and their original fields are set on the
+	//existing document when restored.
+	Trashed struct {
+		Id         bson.ObjectId `bson:"_id"`
+		TrashId    bson.ObjectId `bson:"trashid"`
+		Collection string        `bson:"collection"`
+		Doc        bson.M        `bson:"doc"`
+		Merge      bool          `bson:"merge"`
+	}
+
+	//trasher moves documents to a Trash.
+	trasher struct {
+		s Repository
+		t *Trash
+	}
+)
+
+const (
+	//TRASH_DAYS is the number of days deleted documents are kept in the trash.
+	TRASH_DAYS = 30
+	//Types of Trash.
+	PROJECT_TRASH    = "project"
+	USER_TRASH       = "user"
+	SUBMISSION_TRASH = "submission"
+	SKELETON_TRASH   = "skeleton"
+	RESULTS_TRASH    = "results"
+)
+
+//TrashItems retrieves all Trash matching m.
+func TrashItems(m, sl interface{}) ([]*Trash, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var ts []*Trash
+	if e = s.Find(TRASH, m, sl, 0, []string{"-" + TIME}, &ts); e != nil {
+		return nil, &GetError{"trash", e, m}
+	}
+	return ts, nil
+}
+
+//TrashProject moves the project matching id, along with its submissions,
+//skeletons, tests and configurations, to the trash.
+func TrashProject(id bson.ObjectId, u string) (*Trash, error) {
+	n, e := ProjectName(id)
+	if e != nil {
+		return nil, e
+	}
+	t, e := newTrasher(PROJECT_TRASH, n, u)
+	if e != nil {
+		return nil, e
+	}
+	return t.t, t.project(id)
+}
+
+//TrashUser moves the user matching id, along with their submissions, to the trash.
+func TrashUser(id string, u string) (*Trash, error) {
+	if !Contains(USERS, bson.M{ID: id}) {
+		return nil, &GetError{"user", NotFound, id}
+	}
+	t, e := newTrasher(USER_TRASH, id, u)
+	if e != nil {
+		return nil, e
+	}
+	return t.t, t.user(id)
+}
+
+//TrashSubmission moves the submission matching id, along with its files
+//and their results, to the trash.
+func TrashSubmission(id bson.ObjectId, u string) (*Trash, error) {
+	s, e := Submission(bson.M{ID: id}, nil)
+	if e != nil {
+		return nil, e
+	}
+	t, e := newTrasher(SUBMISSION_TRASH, submissionName(s.ProjectId, s.User, s.Time), u)
+	if e != nil {
+		return nil, e
+	}
+	return t.t, t.submission(id)
+}
+
+//TrashSkeleton moves the skeleton matching id to the trash.
+func TrashSkeleton(id bson.ObjectId, u string) (*Trash, error) {
+	sk, e := Skeleton(bson.M{ID: id}, bson.M{NAME: 1})
+	if e != nil {
+		return nil, e
+	}
+	t, e := newTrasher(SKELETON_TRASH, sk.Name, u)
+	if e != nil {
+		return nil, e
+	}
+	return t.t, t.move(SKELETONS, bson.M{ID: id})
+}
+
+//TrashResults moves the results of the submission matching sid's files to the trash.
+func TrashResults(sid bson.ObjectId, u string) (*Trash, error) {
+	s, e := Submission(bson.M{ID: sid}, nil)
+	if e != nil {
+		return nil, e
+	}
+	t, e := newTrasher(RESULTS_TRASH, submissionName(s.ProjectId, s.User, s.Time), u)
+	if e != nil {
+		return nil, e
+	}
+	return t.t, t.results(sid)
+}
+
+//submissionName describes a submission in a Trash.
+func submissionName(pid bson.ObjectId, u string, t int64) string {
+	n, e := ProjectName(pid)
+	if e != nil {
+		n = pid.Hex()
+	}
+	return fmt.Sprintf("%s → %s → %s", n, u, util.Date(t))
+}
+
+//RestoreTrash moves the documents in the Trash matching id back to
+//their collections and removes the Trash.
+func RestoreTrash(id bson.ObjectId) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	m := bson.M{TRASHID: id}
+	var ts []*Trashed
+	if e = s.Find(TRASHED, m, nil, 0, nil, &ts); e != nil {
+		return &GetError{"trashed documents", e, m}
+	}
+	for _, t := range ts {
+		if e = restoreTrashed(s, t); e != nil {
+			return e
+		}
+	}
+	if e = s.RemoveAll(TRASHED, m); e != nil {
+		return &RemoveError{"trashed documents", e, m}
+	}
+	return RemoveById(TRASH, id)
+}
+
+//restoreTrashed restores a single trashed document.
+func restoreTrashed(s Repository, t *Trashed) error {
+	if !t.Merge {
+		if e := s.Insert(t.Collection, t.Doc); e != nil && e != DuplicateId {
+			return &AddError{t.Collection, e}
+		}
+		return nil
+	}
+	c := bson.M{}
+	for k, v := range t.Doc {
+		if k == ID {
+			continue
+		}
+		if vm, ok := v.(bson.M); ok {
+			for sk, sv := range vm {
+				c[k+"."+sk] = sv
+			}
+		} else {
+			c[k] = v
+		}
+	}
+	if len(c) == 0 {
+		return nil
+	}
+	if e := s.Update(t.Collection, bson.M{ID: t.Doc[ID]}, bson.M{SET: c}); e != nil && e != NotFound {
+		return e
+	}
+	return nil
+}
+
+//PurgeTrash permanently removes the documents in the Trash matching id
+//along with the reports, project archive files and snapshot data only they refer to.
+func PurgeTrash(id bson.ObjectId) error {
+	s, e := Active()
+	if e != nil {
+		return e
+	}
+	m := bson.M{TRASHID: id, COLLECTION: RESULTS, DOC + "." + GRIDFS: true}
+	var ts []*Trashed
+	if e = s.Find(TRASHED, m, nil, 0, nil, &ts); e != nil {
+		return &GetError{"trashed results", e, m}
+	}
+	for _, t := range ts {
+		if e = s.RemoveGridFile(t.Doc[ID]); e != nil && e != NotFound {
+			return &RemoveError{"grid file", e, t.Doc[ID]}
+		}
+	}
+	am := bson.M{TRASHID: id, COLLECTION: ARCHIVES}
+	var as []*Trashed
+	if e = s.Find(TRASHED, am, nil, 0, nil, &as); e != nil {
+		return &GetError{"trashed archives", e, am}
+	}
+	for _, a := range as {
+		p, ok := a.Doc[PATH].(string)
+		if !ok {
+			continue
+		}
+		if e = os.Remove(p); e != nil && !os.IsNotExist(e) {
+			return e
+		}
+	}
+	tm := bson.M{TRASHID: id}
+	if e = s.RemoveAll(TRASHED, tm); e != nil {
+		return &RemoveError{"trashed documents", e, tm}
+	}
+	if e = RemoveById(TRASH, id); e != nil {
+		return e
+	}
+	_, e = RemoveOrphanBlobs()
+	return e
+}
+
+//PurgeExpiredTrash purges all Trash which has expired.
+//It returns the number of Trash purged.
+func PurgeExpiredTrash() (int, error) {
+	ts, e := TrashItems(bson.M{EXPIRES: bson.M{LT: util.CurMilis()}}, bson.M{ID: 1})
+	if e != nil {
+		return 0, e
+	}
+	for i, t := range ts {
+		if e = PurgeTrash(t.Id); e != nil {
+			return i, e
+		}
+	}
+	return len(ts), nil
+}
+
+//newTrasher creates a new Trash of type tipe describing the deletion of n by u.
+func newTrasher(tipe, n, u string) (*trasher, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	now := util.CurMilis()
+	t := &Trash{Id: bson.NewObjectId(), Type: tipe, Name: n, User: u, Time: now, Expires: now + TRASH_DAYS*DAY}
+	if e = Add(TRASH, t); e != nil {
+		return nil, e
+	}
+	return &trasher{s: s, t: t}, nil
+}
+
+//move moves all documents in collection n matching m to the trash.
+func (t *trasher) move(n string, m bson.M) error {
+	var ds []bson.M
+	if e := t.s.Find(n, m, nil, 0, nil, &ds); e != nil {
+		return &GetError{n, e, m}
+	}
+	for _, d := range ds {
+		if e := t.add(n, d, false); e != nil {
+			return e
+		}
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+	if e := t.s.RemoveAll(n, m); e != nil {
+		return &RemoveError{n, e, m}
+	}
+	return nil
+}
+
+//add adds d from collection n to the trash.
+func (t *trasher) add(n string, d bson.M, merge bool) error {
+	return Add(TRASHED, &Trashed{Id: bson.NewObjectId(), TrashId: t.t.Id, Collection: n, Doc: d, Merge: merge})
+}
+
+//project moves a project and all its dependent documents to the trash.
+func (t *trasher) project(id bson.ObjectId) error {
+	ss, e := Submissions(bson.M{PROJECTID: id}, bson.M{ID: 1})
+	if e != nil {
+		return e
+	}
+	for _, s := range ss {
+		if e = t.submission(s.Id); e != nil {
+			return e
+		}
+	}
+	m := bson.M{PROJECTID: id}
+	for _, n := range []string{SKELETONS, TESTS, JPF, PMD, CHECKSTYLE, FINDBUGS, JAVAC, GCC, EXPLANATIONS, MAKE, EXTERNAL, IOTESTS, BENCHMARKS, REFERENCES, VALIDATIONS, ARCHIVES, RETENTION} {
+		if e = t.move(n, m); e != nil {
+			return e
+		}
+	}
+	return t.move(PROJECTS, bson.M{ID: id})
+}
+
+//user moves a user and their submissions to the trash.
+func (t *trasher) user(id string) error {
+	ss, e := Submissions(bson.M{USER: id}, bson.M{ID: 1})
+	if e != nil {
+		return e
+	}
+	for _, s := range ss {
+		if e = t.submission(s.Id); e != nil {
+			return e
+		}
+	}
+	return t.move(USERS, bson.M{ID: id})
+}
+
+//submission moves a submission, its files and their results to the trash.
+func (t *trasher) submission(id bson.ObjectId) error {
+	fs, e := Files(bson.M{SUBID: id}, bson.M{RESULTS: 1}, 0)
+	if e != nil {
+		return e
+	}
+	if e = t.move(RESULTS, bson.M{ID: bson.M{IN: resultIds(fs)}}); e != nil {
+		return e
+	}
+	if e = t.move(ISSUES, bson.M{SUBID: id}); e != nil {
+		return e
+	}
+	if e = t.move(ISSUETRACKS, bson.M{SUBID: id}); e != nil {
+		return e
+	}
+	if e = t.move(TESTOUTCOMES, bson.M{SUBID: id}); e != nil {
+		return e
+	}
+	if e = t.move(SIMILARITIES, similarityMatcher(id)); e != nil {
+		return e
+	}
+	if e = t.move(FILES, bson.M{SUBID: id}); e != nil {
+		return e
+	}
+	return t.move(SUBMISSIONS, bson.M{ID: id})
+}
+
+//results moves the results of a submission's files to the trash.
+func (t *trasher) results(sid bson.ObjectId) error {
+	fs, e := Files(bson.M{SUBID: sid}, bson.M{RESULTS: 1}, 0)
+	if e != nil {
+		return e
+	}
+	if e = t.move(RESULTS, bson.M{ID: bson.M{IN: resultIds(fs)}}); e != nil {
+		return e
+	}
+	if e = t.move(ISSUES, bson.M{SUBID: sid}); e != nil {
+		return e
+	}
+	if e = t.move(ISSUETRACKS, bson.M{SUBID: sid}); e != nil {
+		return e
+	}
+	if e = t.move(TESTOUTCOMES, bson.M{SUBID: sid}); e != nil {
+		return e
+	}
+	for _, f := range fs {
+		if len(f.Results) == 0 {
+			continue
+		}
+		if e = t.add(FILES, bson.M{ID: f.Id, RESULTS: f.Results}, true); e != nil {
+			return e
+		}
+		if e = Update(FILES, bson.M{ID: f.Id}, bson.M{SET: bson.M{RESULTS: bson.M{}}}); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+//resultIds retrieves the ids of the results of files fs.
+func resultIds(fs []*project.File) []bson.ObjectId {
+	var ids []bson.ObjectId
+	for _, f := range fs {
+		for _, r := range f.Results {
+			if id, ok := r.(bson.ObjectId); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,191 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"bytes"
+
+	"github.com/godfried/impendulo/project"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestTrashProject(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	p, s, f := trashData(t)
+	tr, e := TrashProject(p.Id, "admin")
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, n := range []string{PROJECTS, SUBMISSIONS, FILES, RESULTS} {
+		if c, _ := Count(n, bson.M{}); c != 0 {
+			t.Errorf("Expected %s to be empty but found %d documents.", n, c)
+		}
+	}
+	if _, e = RemoveOrphanBlobs(); e != nil {
+		t.Error(e)
+	}
+	if e = RestoreTrash(tr.Id); e != nil {
+		t.Fatal(e)
+	}
+	if !Contains(PROJECTS, bson.M{ID: p.Id}) || !Contains(SUBMISSIONS, bson.M{ID: s.Id}) {
+		t.Error("Project not restored.")
+	}
+	rf, e := File(bson.M{ID: f.Id}, nil)
+	if e != nil {
+		t.Error(e)
+	} else if !bytes.Equal(rf.Data, f.Data) {
+		t.Error("Restored file data not equal.")
+	}
+	if _, e = JavacResult(bson.M{ID: rf.Results["javac"]}, nil); e != nil {
+		t.Error(e)
+	}
+	if c, _ := Count(TRASH, bson.M{}); c != 0 {
+		t.Errorf("Expected empty trash but found %d items.", c)
+	}
+	if tr, e = TrashSubmission(s.Id, "admin"); e != nil {
+		t.Fatal(e)
+	}
+	SetBlobGrace(0)
+	defer SetBlobGrace(BLOB_GRACE)
+	if e = PurgeTrash(tr.Id); e != nil {
+		t.Error(e)
+	}
+	for _, n := range []string{SUBMISSIONS, FILES, RESULTS, TRASHED, BLOBS} {
+		if c, _ := Count(n, bson.M{}); c != 0 {
+			t.Errorf("Expected %s to be empty after purging but found %d documents.", n, c)
+		}
+	}
+	if !Contains(PROJECTS, bson.M{ID: p.Id}) {
+		t.Error("Project removed with submission.")
+	}
+}
+
+func TestTrashArchivedProject(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	d, e := ioutil.TempDir("", "archives")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(d)
+	SetArchiveDir(d)
+	defer SetArchiveDir("")
+	p, _, _ := trashData(t)
+	if e = SetRetention(p.Id, 30); e != nil {
+		t.Error(e)
+	}
+	a, e := ArchiveProject(p.Id)
+	if e != nil {
+		t.Fatal(e)
+	}
+	tr, e := TrashProject(p.Id, "admin")
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, n := range []string{ARCHIVES, RETENTION} {
+		if c, _ := Count(n, bson.M{}); c != 0 {
+			t.Errorf("Expected %s to be empty but found %d documents.", n, c)
+		}
+	}
+	if e = RestoreTrash(tr.Id); e != nil {
+		t.Fatal(e)
+	}
+	if _, e = ProjectArchive(p.Id); e != nil {
+		t.Error(e)
+	}
+	if _, e = ProjectRetention(p.Id); e != nil {
+		t.Error(e)
+	}
+	if tr, e = TrashProject(p.Id, "admin"); e != nil {
+		t.Fatal(e)
+	}
+	if e = PurgeTrash(tr.Id); e != nil {
+		t.Error(e)
+	}
+	if _, e = os.Stat(a.Path); !os.IsNotExist(e) {
+		t.Errorf("Expected archive %s to be removed but got %v.", a.Path, e)
+	}
+}
+
+func TestTrashResults(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	_, s, f := trashData(t)
+	tr, e := TrashResults(s.Id, "admin")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if c, _ := Count(RESULTS, bson.M{}); c != 0 {
+		t.Errorf("Expected no results but found %d.", c)
+	}
+	if rf, _ := File(bson.M{ID: f.Id}, bson.M{RESULTS: 1}); len(rf.Results) != 0 {
+		t.Error("File results not removed.")
+	}
+	if e = RestoreTrash(tr.Id); e != nil {
+		t.Fatal(e)
+	}
+	rf, e := File(bson.M{ID: f.Id}, bson.M{RESULTS: 1})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if _, e = JavacResult(bson.M{ID: rf.Results["javac"]}, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func trashData(t *testing.T) (*project.Project, *project.Submission, *project.File) {
+	p := project.New("Triangle", "user", "Java", "")
+	if e := Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	s := project.NewSubmission(p.Id, "user", project.FILE_MODE, 1000)
+	if e := Add(SUBMISSIONS, s); e != nil {
+		t.Error(e)
+	}
+	f, e := project.NewFile(s.Id, fileInfo, fileData)
+	if e != nil {
+		t.Error(e)
+	}
+	if e = AddFile(f); e != nil {
+		t.Error(e)
+	}
+	if e = AddResult(javacResult(f.Id, true), "javac"); e != nil {
+		t.Error(e)
+	}
+	return p, s, f
+}
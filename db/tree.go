@@ -0,0 +1,119 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"path"
+	"strings"
+
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+//SourceTree reconstructs the source tree of the submission matching sid as it was at time t.
+//The latest snapshot at or before t of each of the submission's source and test files
+//is overlaid on the project's most recent skeleton, replacing the skeleton's version
+//of the file if it has one. The tree maps each file's slash separated path to its contents.
+func SourceTree(sid bson.ObjectId, t int64) (map[string][]byte, error) {
+	s, e := Submission(bson.M{ID: sid}, bson.M{PROJECTID: 1})
+	if e != nil {
+		return nil, e
+	}
+	tree := make(map[string][]byte)
+	sks, e := Skeletons(bson.M{PROJECTID: s.ProjectId}, nil, "-"+ID)
+	if e != nil {
+		return nil, e
+	}
+	if len(sks) > 0 {
+		if tree, e = util.UnzipToMap(sks[0].Data); e != nil {
+			return nil, e
+		}
+	}
+	m := bson.M{SUBID: sid, TYPE: bson.M{IN: []project.Type{project.SRC, project.TEST}}, TIME: bson.M{LTE: t}}
+	fs, e := Files(m, bson.M{NAME: 1, PKG: 1, TYPE: 1, TIME: 1}, 0, TIME)
+	if e != nil {
+		return nil, e
+	}
+	latest := make(map[string]bson.ObjectId)
+	for _, f := range fs {
+		latest[filePath(f)] = f.Id
+	}
+	for p, id := range latest {
+		f, e := File(bson.M{ID: id}, bson.M{DATA: 1})
+		if e != nil {
+			return nil, e
+		}
+		tree[treePath(tree, p)] = f.Data
+	}
+	return tree, nil
+}
+
+//PackageTree reconstructs the source tree of f's submission as it was when f was
+//snapshotted. Only files sharing f's package root are included and their paths are
+//relative to this root.
+func PackageTree(f *project.File) (map[string][]byte, error) {
+	tree, e := SourceTree(f.SubId, f.Time)
+	if e != nil {
+		return nil, e
+	}
+	p := filePath(f)
+	r := strings.TrimSuffix(treePath(tree, p), p)
+	pt := make(map[string][]byte, len(tree))
+	for k, d := range tree {
+		if strings.HasPrefix(k, r) {
+			pt[strings.TrimPrefix(k, r)] = d
+		}
+	}
+	pt[p] = f.Data
+	return pt, nil
+}
+
+//filePath calculates the slash separated path of f relative to its package's root.
+func filePath(f *project.File) string {
+	if f.Package == "" {
+		return f.Name
+	}
+	return path.Join(append(strings.Split(f.Package, "."), f.Name)...)
+}
+
+//treePath finds the path in tree at which a file with path p relative to
+//its package's root should be stored. This is the path of the file in tree
+//which p is a suffix of if there is one and p otherwise.
+func treePath(tree map[string][]byte, p string) string {
+	if _, ok := tree[p]; ok {
+		return p
+	}
+	m := ""
+	for k := range tree {
+		if strings.HasSuffix(k, "/"+p) && (m == "" || len(k) < len(m)) {
+			m = k
+		}
+	}
+	if m == "" {
+		return p
+	}
+	return m
+}
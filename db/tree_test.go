@@ -0,0 +1,151 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"bytes"
+
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestSourceTree(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	p := project.New("Triangle", "user", "Java", "")
+	if e = Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	sk, e := util.ZipMap(map[string][]byte{
+		"src/triangle/Triangle.java": []byte("skeleton"),
+		"src/triangle/Util.java":     []byte("util"),
+		"build.xml":                  []byte("build"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	if e = Add(SKELETONS, project.NewSkeleton(p.Id, "skeleton", sk)); e != nil {
+		t.Error(e)
+	}
+	s := project.NewSubmission(p.Id, "user", project.FILE_MODE, 1000)
+	if e = Add(SUBMISSIONS, s); e != nil {
+		t.Error(e)
+	}
+	for i, d := range []string{"first", "second", "third"} {
+		f, e := project.NewFile(s.Id, fileInfo, []byte(d))
+		if e != nil {
+			t.Error(e)
+		}
+		f.Time = int64(1000 * (i + 1))
+		if e = AddFile(f); e != nil {
+			t.Error(e)
+		}
+	}
+	n, e := project.NewFile(s.Id, bson.M{project.TIME: 2500, project.TYPE: project.SRC, project.NAME: "New.java", project.PKG: "triangle"}, []byte("new"))
+	if e != nil {
+		t.Error(e)
+	}
+	if e = AddFile(n); e != nil {
+		t.Error(e)
+	}
+	tests := []struct {
+		time  int64
+		files map[string]string
+	}{
+		{500, map[string]string{"src/triangle/Triangle.java": "skeleton", "src/triangle/Util.java": "util", "build.xml": "build"}},
+		{2000, map[string]string{"src/triangle/Triangle.java": "second", "src/triangle/Util.java": "util", "build.xml": "build"}},
+		{3000, map[string]string{"src/triangle/Triangle.java": "third", "src/triangle/Util.java": "util", "build.xml": "build", "triangle/New.java": "new"}},
+	}
+	for _, test := range tests {
+		tree, e := SourceTree(s.Id, test.time)
+		if e != nil {
+			t.Error(e)
+			continue
+		}
+		if len(tree) != len(test.files) {
+			t.Errorf("Expected %d files at %d but got %d.", len(test.files), test.time, len(tree))
+		}
+		for k, v := range test.files {
+			if !bytes.Equal(tree[k], []byte(v)) {
+				t.Errorf("Expected %s to be %q at %d but got %q.", k, v, test.time, tree[k])
+			}
+		}
+	}
+}
+
+func TestPackageTree(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	_, e := Active()
+	if e != nil {
+		t.Error(e)
+	}
+	p := project.New("Triangle", "user", "Java", "")
+	if e = Add(PROJECTS, p); e != nil {
+		t.Error(e)
+	}
+	sk, e := util.ZipMap(map[string][]byte{
+		"src/triangle/Triangle.java": []byte("skeleton"),
+		"src/triangle/Util.java":     []byte("util"),
+		"build.xml":                  []byte("build"),
+	})
+	if e != nil {
+		t.Error(e)
+	}
+	if e = Add(SKELETONS, project.NewSkeleton(p.Id, "skeleton", sk)); e != nil {
+		t.Error(e)
+	}
+	s := project.NewSubmission(p.Id, "user", project.FILE_MODE, 1000)
+	if e = Add(SUBMISSIONS, s); e != nil {
+		t.Error(e)
+	}
+	f, e := project.NewFile(s.Id, fileInfo, []byte("snapshot"))
+	if e != nil {
+		t.Error(e)
+	}
+	if e = AddFile(f); e != nil {
+		t.Error(e)
+	}
+	tree, e := PackageTree(f)
+	if e != nil {
+		t.Error(e)
+	}
+	expected := map[string]string{"triangle/Triangle.java": "snapshot", "triangle/Util.java": "util"}
+	if len(tree) != len(expected) {
+		t.Errorf("Expected %d files but got %d.", len(expected), len(tree))
+	}
+	for k, v := range expected {
+		if !bytes.Equal(tree[k], []byte(v)) {
+			t.Errorf("Expected %s to be %q but got %q.", k, v, tree[k])
+		}
+	}
+}
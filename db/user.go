@@ -29,17 +29,18 @@ import (
 
 	"github.com/godfried/impendulo/user"
 	"labix.org/v2/mgo/bson"
+
+	"sort"
 )
 
 //User retrieves a user matching the given id from the active database.
 func User(id string) (*user.User, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var u *user.User
-	if e = s.DB("").C(USERS).FindId(id).One(&u); e != nil {
+	if e = s.FindOne(USERS, bson.M{ID: id}, nil, &u); e != nil {
 		return nil, &GetError{"user", e, id}
 	}
 	return u, nil
@@ -47,43 +48,41 @@ func User(id string) (*user.User, error) {
 
 //Users retrieves users matching the given interface from the active database.
 func Users(m interface{}, sort ...string) ([]*user.User, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
-	q := s.DB("").C(USERS).Find(m)
-	if len(sort) > 0 {
-		q = q.Sort(sort...)
-	}
 	var u []*user.User
-	if e = q.All(&u); e != nil {
+	if e = s.Find(USERS, m, nil, 0, sort, &u); e != nil {
 		return nil, &GetError{"users", e, m}
 	}
 	return u, nil
 }
 
 func Usernames(m interface{}) ([]string, error) {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return nil, e
 	}
-	defer s.Close()
 	var n []string
-	if e = s.DB("").C(USERS).Find(m).Sort("_id").Distinct("_id", &n); e != nil {
+	if e = s.Distinct(USERS, m, ID, &n); e != nil {
 		return nil, &GetError{"users", e, m}
 	}
+	sort.Strings(n)
 	return n, nil
 }
 
 //AddUsers adds new users to the active database.
 func AddUsers(users ...*user.User) error {
-	s, e := Session()
+	s, e := Active()
 	if e != nil {
 		return e
 	}
-	defer s.Close()
-	if e = s.DB("").C(USERS).Insert(users); e != nil {
+	us := make([]interface{}, len(users))
+	for i, u := range users {
+		us[i] = u
+	}
+	if e = s.Insert(USERS, us...); e != nil {
 		return fmt.Errorf("error %q adding users %q to db", e, users)
 	}
 	return nil
@@ -34,11 +34,10 @@ import (
 func TestUser(t *testing.T) {
 	Setup(TEST_CONN)
 	defer DeleteDB(TEST_DB)
-	s, e := Session()
+	_, e := Active()
 	if e != nil {
 		t.Error(e)
 	}
-	defer s.Close()
 	u := user.New("uname", "pword")
 	if e = Add(USERS, u); e != nil {
 		t.Error(e)
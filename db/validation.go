@@ -0,0 +1,190 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/iotest"
+	"github.com/godfried/impendulo/tool/jacoco"
+	"github.com/godfried/impendulo/tool/jpf"
+	"github.com/godfried/impendulo/tool/junit"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+//Validation retrieves a validation matching m from the active database.
+func Validation(m, sl interface{}) (*project.Validation, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var v *project.Validation
+	if e = s.FindOne(VALIDATIONS, m, sl, &v); e != nil {
+		return nil, &GetError{"validation", e, m}
+	}
+	return v, nil
+}
+
+//Validations retrieves at most limit validations matching m, newest first.
+func Validations(m, sl interface{}, limit int) ([]*project.Validation, error) {
+	s, e := Active()
+	if e != nil {
+		return nil, e
+	}
+	var vs []*project.Validation
+	if e = s.Find(VALIDATIONS, m, sl, limit, []string{"-" + TIME}, &vs); e != nil {
+		return nil, &GetError{"validations", e, m}
+	}
+	return vs, nil
+}
+
+//FinishValidation stores the outcome of a completed validation.
+func FinishValidation(v *project.Validation) error {
+	return Update(VALIDATIONS, bson.M{ID: v.Id}, bson.M{SET: bson.M{STATUS: v.Status, ERROR: v.Error, CHECKS: v.Checks}})
+}
+
+var (
+	//pendingConfigs maps the collections of the tool configurations which are validated
+	//before they are used to the results which need to be recalculated when they change.
+	//A nil value indicates that all results need to be.
+	pendingConfigs = map[string][]string{
+		JPF: []string{jpf.NAME}, MAKE: nil, JAVAC: nil, GCC: nil,
+	}
+)
+
+//Live creates a matcher for a project's tests which excludes pending tests.
+func Live(pid bson.ObjectId) bson.M {
+	return bson.M{PROJECTID: pid, PENDING: bson.M{NE: true}}
+}
+
+//Current creates a matcher for project pid's configuration in collection n.
+//A pending configuration is only matched when validating and takes
+//precedence over the live configuration.
+func Current(n string, pid bson.ObjectId, validating bool) bson.M {
+	if m := (bson.M{PROJECTID: pid, PENDING: true}); validating && Contains(n, m) {
+		return m
+	}
+	return Live(pid)
+}
+
+//replaced creates a matcher for the configurations or tests of project pid which a new one
+//replaces. A pending configuration only replaces other pending configurations so that the
+//live configuration is still used until it is published.
+func replaced(pid bson.ObjectId, pending bool) bson.M {
+	if pending {
+		return bson.M{PROJECTID: pid, PENDING: true}
+	}
+	return bson.M{PROJECTID: pid}
+}
+
+//HasPending checks whether a project has tests, input/output
+//test cases or tool configurations which have not been published yet.
+func HasPending(pid bson.ObjectId) bool {
+	m := bson.M{PROJECTID: pid, PENDING: true}
+	if Contains(TESTS, m) || Contains(IOTESTS, m) {
+		return true
+	}
+	for n := range pendingConfigs {
+		if Contains(n, m) {
+			return true
+		}
+	}
+	return false
+}
+
+//Publish makes a project's pending tests, input/output test cases and tool configurations
+//live. They replace the project's live tests with the same name or which they were edited from
+//and its live configurations. The names of the results affected by the published tests and
+//configurations are returned and all is set if every result is.
+func Publish(pid bson.ObjectId) ([]string, bool, error) {
+	m := bson.M{PROJECTID: pid, PENDING: true}
+	ts, e := JUnitTests(m, bson.M{NAME: 1, REPLACES: 1})
+	if e != nil {
+		return nil, false, e
+	}
+	cs, e := IOTestCases(m, bson.M{NAME: 1})
+	if e != nil {
+		return nil, false, e
+	}
+	for _, t := range ts {
+		lm := Live(pid)
+		lm[NAME] = t.Name
+		if t.Replaces != "" {
+			delete(lm, NAME)
+			lm[OR] = []bson.M{{NAME: t.Name}, {ID: t.Replaces}}
+		}
+		if e = RemoveAll(TESTS, lm); e != nil {
+			return nil, false, e
+		}
+	}
+	for _, c := range cs {
+		lm := Live(pid)
+		lm[NAME] = c.Name
+		if e = RemoveAll(IOTESTS, lm); e != nil {
+			return nil, false, e
+		}
+	}
+	u := bson.M{SET: bson.M{PENDING: false}, UNSET: bson.M{REPLACES: ""}}
+	if len(ts) > 0 {
+		if e = UpdateAll(TESTS, m, u); e != nil {
+			return nil, false, e
+		}
+	}
+	if len(cs) > 0 {
+		if e = UpdateAll(IOTESTS, m, u); e != nil {
+			return nil, false, e
+		}
+	}
+	rs := make([]string, 0, 2*len(ts)+2)
+	for _, t := range ts {
+		rs = append(rs, TestResults(t.Name)...)
+	}
+	if len(cs) > 0 {
+		rs = append(rs, iotest.NAME)
+	}
+	all := false
+	for n, tools := range pendingConfigs {
+		if !Contains(n, m) {
+			continue
+		}
+		if e = RemoveAll(n, Live(pid)); e != nil {
+			return nil, false, e
+		}
+		if e = UpdateAll(n, m, u); e != nil {
+			return nil, false, e
+		}
+		if tools == nil {
+			all = true
+		}
+		rs = append(rs, tools...)
+	}
+	return rs, all, nil
+}
+
+//TestResults retrieves the names of the results produced by the JUnit test n.
+func TestResults(n string) []string {
+	n, _ = util.Extension(n)
+	return []string{junit.NAME + ":" + n, jacoco.NAME + ":" + n}
+}
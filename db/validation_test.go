@@ -0,0 +1,177 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/iotest"
+	"github.com/godfried/impendulo/tool/jpf"
+	"github.com/godfried/impendulo/tool/junit"
+	mk "github.com/godfried/impendulo/tool/make"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	pid := bson.NewObjectId()
+	target := tool.NewTarget("TriangleTest.java", "testing", "", tool.JAVA)
+	live := junit.NewTest(pid, "LiveTest.java", junit.DEFAULT, target, []byte("live"), nil)
+	old := junit.NewTest(pid, "TriangleTest.java", junit.DEFAULT, target, []byte("old"), nil)
+	pending := junit.NewTest(pid, "TriangleTest.java", junit.DEFAULT, target, []byte("pending"), nil)
+	pending.Pending = true
+	edited := junit.NewTest(pid, "EditedTest.java", junit.DEFAULT, target, []byte("edited"), nil)
+	edited.Pending, edited.Replaces = true, live.Id
+	for _, jt := range []*junit.Test{live, old, pending, edited} {
+		if e := AddJUnitTest(jt); e != nil {
+			t.Fatal(e)
+		}
+	}
+	c, e := iotest.NewCase(pid, "case", string(tool.JAVA), "", "", "1 2 3", "6", 0, iotest.EXACT, 0, false)
+	if e != nil {
+		t.Fatal(e)
+	}
+	c.Pending = true
+	if e = AddIOTestCase(c); e != nil {
+		t.Fatal(e)
+	}
+	if !HasPending(pid) {
+		t.Error("expected pending tests")
+	}
+	ts, e := JUnitTests(Live(pid), nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(ts) != 2 {
+		t.Errorf("expected %s and %s to be live, got %v", live.Name, old.Name, ts)
+	}
+	rs, all, e := Publish(pid)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if all {
+		t.Error("expected only the published tests' results to be affected")
+	}
+	expected := append(append(TestResults(pending.Name), TestResults(edited.Name)...), iotest.NAME)
+	if len(rs) != len(expected) {
+		t.Fatalf("expected results %v, got %v", expected, rs)
+	}
+	for i, r := range rs {
+		if r != expected[i] {
+			t.Errorf("expected result %s, got %s", expected[i], r)
+		}
+	}
+	if HasPending(pid) {
+		t.Error("expected no pending tests after publishing")
+	}
+	if ts, e = JUnitTests(Live(pid), nil); e != nil {
+		t.Fatal(e)
+	} else if len(ts) != 2 {
+		t.Errorf("expected 2 live tests, got %d", len(ts))
+	}
+	for _, jt := range ts {
+		if jt.Id != pending.Id && jt.Id != edited.Id {
+			t.Errorf("expected %s to be replaced", jt.Name)
+		}
+	}
+}
+
+func TestPublishConfig(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	pid := bson.NewObjectId()
+	target := tool.NewTarget("Triangle.java", "triangle", "", tool.JAVA)
+	live := jpf.NewConfig(pid, target, []byte("live"))
+	if e := AddJPFConfig(live); e != nil {
+		t.Fatal(e)
+	}
+	pending := jpf.NewConfig(pid, target, []byte("pending"))
+	pending.Pending = true
+	if e := AddJPFConfig(pending); e != nil {
+		t.Fatal(e)
+	}
+	c, e := JPFConfig(Current(JPF, pid, false), nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(c.Data) != "live" {
+		t.Errorf("expected live configuration to be used, got %s", c.Data)
+	}
+	if c, e = JPFConfig(Current(JPF, pid, true), nil); e != nil {
+		t.Fatal(e)
+	} else if string(c.Data) != "pending" {
+		t.Errorf("expected pending configuration to be validated, got %s", c.Data)
+	}
+	if !HasPending(pid) {
+		t.Error("expected pending configuration")
+	}
+	mf := mk.NewMakefile(pid, []byte("all:"))
+	mf.Pending = true
+	if e = AddMakefile(mf); e != nil {
+		t.Fatal(e)
+	}
+	rs, all, e := Publish(pid)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !all || len(rs) != 1 || rs[0] != jpf.NAME {
+		t.Errorf("unexpected published results %v, %t", rs, all)
+	}
+	if n, _ := Count(JPF, bson.M{PROJECTID: pid}); n != 1 {
+		t.Errorf("expected 1 JPF configuration after publishing, got %d", n)
+	}
+	if c, e = JPFConfig(Current(JPF, pid, false), nil); e != nil {
+		t.Fatal(e)
+	} else if string(c.Data) != "pending" {
+		t.Errorf("expected published configuration to be live, got %s", c.Data)
+	}
+	if HasPending(pid) {
+		t.Error("expected no pending configurations after publishing")
+	}
+}
+
+func TestValidation(t *testing.T) {
+	Setup(TEST_CONN)
+	defer DeleteDB(TEST_DB)
+	v := project.NewValidation(bson.NewObjectId(), "reference", "user", nil, true)
+	if e := Add(VALIDATIONS, v); e != nil {
+		t.Fatal(e)
+	}
+	v.Add("Triangle.java", junit.NAME, false, "testValid failed")
+	v.Status = project.DONE
+	if e := FinishValidation(v); e != nil {
+		t.Fatal(e)
+	}
+	got, e := Validation(bson.M{ID: v.Id}, nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if got.Status != project.DONE || got.Failures() != 1 || got.Passed() {
+		t.Errorf("unexpected validation %v", got)
+	}
+}
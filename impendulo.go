@@ -45,6 +45,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 //Flag variables for setting ports to listen on, users file to process, mode to run in, etc.
@@ -52,8 +53,12 @@ var (
 	wFlags, rFlags, pFlags   *flag.FlagSet
 	cfgFile, errLog, infoLog string
 	backupDB, access         string
+	archiveDir               string
 	dbName, dbAddr, mqURI    string
 	mProcs                   uint
+	deltaDepth, retainHours  int
+	migrate, retain, issues  bool
+	outcomes                 bool
 	httpPort, tcpPort        uint
 )
 
@@ -68,11 +73,18 @@ func init() {
 	flag.StringVar(&infoLog, "i", "f", "Specify where to log info to (default file).")
 	flag.StringVar(&cfgFile, "c", d, fmt.Sprintf("Specify a configuration file (default %s).", d))
 	flag.StringVar(&dbName, "db", db.DEBUG_DB, fmt.Sprintf("Specify a db to use (default %s).", db.DEBUG_DB))
-	flag.StringVar(&dbAddr, "da", db.ADDRESS, fmt.Sprintf("Specify a db address to use (default %s).", db.ADDRESS))
+	flag.StringVar(&dbAddr, "da", db.ADDRESS, fmt.Sprintf("Specify a db address to use, addresses starting with %s use the embedded file backend (default %s).", db.FILE_SCHEME, db.ADDRESS))
 	flag.StringVar(&access, "a", "",
 		"Change a user's access permissions."+
 			"Available permissions: NONE=0, STUDENT=1, TEACHER=2, ADMIN=3."+
 			"Example: -a=pieter:2.")
+	flag.IntVar(&deltaDepth, "dd", db.DELTA_DEPTH, fmt.Sprintf("Specify the maximum length of delta encoded snapshot chains, 0 disables delta encoding (default %d).", db.DELTA_DEPTH))
+	flag.BoolVar(&migrate, "m", false, "Migrate snapshot data stored inline to deduplicated blob storage.")
+	flag.BoolVar(&issues, "is", false, "Rebuild the issues of all snapshots from their analysis results and track them through each submission.")
+	flag.BoolVar(&outcomes, "to", false, "Rebuild the test case outcomes of all snapshots from their JUnit results.")
+	flag.BoolVar(&retain, "r", false, "Archive projects which have exceeded their retention period and purge expired trash.")
+	flag.IntVar(&retainHours, "rh", 24, "Specify the number of hours between applying retention periods and purging expired trash while the web server runs, 0 disables this (default 24).")
+	flag.StringVar(&archiveDir, "ad", "", "Specify a directory to store archived projects in (default ~/.impendulo/archives).")
 	flag.StringVar(&mqURI, "mq", mq.DEFAULT_AMQP_URI, fmt.Sprintf("Specify the address of the Rabbitmq server (default %s).", mq.DEFAULT_AMQP_URI))
 
 	pFlags = flag.NewFlagSet("processor", flag.ExitOnError)
@@ -100,6 +112,8 @@ func main() {
 	util.SetErrorLogging(errLog)
 	util.SetInfoLogging(infoLog)
 	mq.SetAMQP_URI(mqURI)
+	db.SetDeltaDepth(deltaDepth)
+	db.SetArchiveDir(archiveDir)
 	//Handle setup flags
 	if e = backup(backupDB); e != nil {
 		return
@@ -111,6 +125,18 @@ func main() {
 	if e = modifyAccess(access); e != nil {
 		return
 	}
+	if e = migrateFiles(migrate); e != nil {
+		return
+	}
+	if e = rebuildIssues(issues); e != nil {
+		return
+	}
+	if e = rebuildTestOutcomes(outcomes); e != nil {
+		return
+	}
+	if e = applyRetention(retain); e != nil {
+		return
+	}
 	if flag.NArg() < 1 {
 		e = fmt.Errorf("too few arguments provided %d", flag.NArg())
 		return
@@ -120,6 +146,7 @@ func main() {
 	}
 	switch flag.Arg(0) {
 	case "web":
+		go scheduleRetention(time.Duration(retainHours) * time.Hour)
 		runWebServer(httpPort)
 	case "receiver":
 		runFileReceiver(tcpPort)
@@ -147,13 +174,110 @@ func modifyAccess(a string) error {
 	if p < user.NONE || p > user.ADMIN {
 		return fmt.Errorf("invalid user access token %d", v)
 	}
-	if e = db.Update(db.USERS, bson.M{user.ID: ps[0]}, bson.M{db.SET: bson.M{user.ACCESS: p}}); e != nil {
+	if e = db.AuditedUpdate(cliActor(), "access", db.USERS, ps[0], bson.M{user.ACCESS: p}); e != nil {
 		return fmt.Errorf("update error: user %s's access permissions", ps[0])
 	}
 	fmt.Printf("updated %s's permission level to %s\n", ps[0], p.Name())
 	return nil
 }
 
+//cliActor identifies the user performing command line operations in the audit trail.
+func cliActor() string {
+	return "cli:" + util.SystemUser()
+}
+
+//migrateFiles moves all snapshot data stored inline to blob storage.
+func migrateFiles(m bool) error {
+	if !m {
+		return nil
+	}
+	n, e := db.MigrateFiles()
+	if e != nil {
+		return e
+	}
+	fmt.Printf("successfully migrated %d files to blob storage.\n", n)
+	return db.AddAudit(db.NewAudit(cliActor(), "migratefiles", db.FILES, nil, nil, bson.M{"migrated": n}))
+}
+
+//rebuildIssues recreates the issues of all snapshots from their analysis results
+//and follows them through each submission.
+func rebuildIssues(r bool) error {
+	if !r {
+		return nil
+	}
+	n, e := db.RebuildIssues(nil)
+	if e != nil {
+		return e
+	}
+	ss, e := db.Submissions(nil, bson.M{db.ID: 1})
+	if e != nil {
+		return e
+	}
+	for _, s := range ss {
+		if e = db.TrackIssues(s.Id); e != nil {
+			return e
+		}
+	}
+	fmt.Printf("successfully rebuilt %d issues.\n", n)
+	return db.AddAudit(db.NewAudit(cliActor(), "rebuildissues", db.ISSUES, nil, nil, bson.M{"issues": n}))
+}
+
+//rebuildTestOutcomes recreates the test case outcomes of all snapshots from their JUnit results.
+func rebuildTestOutcomes(r bool) error {
+	if !r {
+		return nil
+	}
+	n, e := db.RebuildTestOutcomes(nil)
+	if e != nil {
+		return e
+	}
+	fmt.Printf("successfully rebuilt %d test case outcomes.\n", n)
+	return db.AddAudit(db.NewAudit(cliActor(), "rebuildtestoutcomes", db.TESTOUTCOMES, nil, nil, bson.M{"outcomes": n}))
+}
+
+//applyRetention archives all projects which have exceeded their retention period
+//and purges all expired trash.
+func applyRetention(r bool) error {
+	if !r {
+		return nil
+	}
+	return retainData(cliActor())
+}
+
+//scheduleRetention applies retention periods and purges expired trash every d.
+//It does nothing if d is not positive.
+func scheduleRetention(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	for _ = range time.Tick(d) {
+		if e := retainData("scheduler"); e != nil {
+			util.Log(e)
+		}
+	}
+}
+
+//retainData archives all projects which have exceeded their retention period
+//and purges all expired trash, recording u as the actor in the audit trail.
+func retainData(u string) error {
+	ids, e := db.ApplyRetention()
+	if e != nil {
+		return e
+	}
+	fmt.Printf("successfully archived %d projects.\n", len(ids))
+	for _, id := range ids {
+		if e = db.AddAudit(db.NewAudit(u, "archiveprojects", db.PROJECTS, id, nil, nil)); e != nil {
+			return e
+		}
+	}
+	n, e := db.PurgeExpiredTrash()
+	if e != nil {
+		return e
+	}
+	fmt.Printf("successfully purged %d trash items.\n", n)
+	return db.AddAudit(db.NewAudit(u, "purgetrash", db.TRASH, nil, nil, bson.M{"purged": n}))
+}
+
 //backup backs up the default database to a specified backup.
 func backup(b string) error {
 	if b == "" {
@@ -0,0 +1,362 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package testing;
+
+import java.io.File;
+import java.io.FileOutputStream;
+import java.io.IOException;
+import java.io.OutputStream;
+import java.io.OutputStreamWriter;
+import java.io.PrintStream;
+import java.io.PrintWriter;
+import java.io.StringWriter;
+import java.io.Writer;
+import java.security.InvalidParameterException;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.HashMap;
+import java.util.HashSet;
+import java.util.LinkedList;
+import java.util.List;
+import java.util.Map;
+import java.util.Set;
+
+import org.junit.platform.engine.FilterResult;
+import org.junit.platform.engine.TestDescriptor;
+import org.junit.platform.engine.TestExecutionResult;
+import org.junit.platform.engine.TestSource;
+import org.junit.platform.engine.discovery.DiscoverySelectors;
+import org.junit.platform.engine.support.descriptor.MethodSource;
+import org.junit.platform.launcher.Launcher;
+import org.junit.platform.launcher.LauncherDiscoveryRequest;
+import org.junit.platform.launcher.PostDiscoveryFilter;
+import org.junit.platform.launcher.TestExecutionListener;
+import org.junit.platform.launcher.TestIdentifier;
+import org.junit.platform.launcher.TestPlan;
+import org.junit.platform.launcher.core.LauncherDiscoveryRequestBuilder;
+import org.junit.platform.launcher.core.LauncherFactory;
+
+/**
+ * This class is used to run a JUnit 4 or JUnit 5 Test on a Java class using
+ * the JUnit Platform. The results of the Test are stored in a XML file with
+ * the same format as the one produced by ant's XML formatter so that they can
+ * be read by the same parser. Test cases are named by their display names, so
+ * nested and parameterized tests are named by the path of display names
+ * leading to them. See
+ * http://godoc.org/github.com/godfried/impendulo/tool/junit#Tool for more
+ * information.
+ * 
+ * @author godfried
+ * 
+ */
+public class PlatformRunner {
+	public static void main(String[] args) {
+		if (args.length != 4 && args.length != 5) {
+			throw new InvalidParameterException("Expected 4 or 5 arguments.");
+		}
+		// Disable output pipes.
+		PrintStream out = System.out;
+		PrintStream err = System.err;
+		System.setOut(new PrintStream(new OutputStream() {
+			@Override
+			public void write(int b) throws IOException {
+			}
+		}));
+		System.setErr(new PrintStream(new OutputStream() {
+			@Override
+			public void write(int b) throws IOException {
+			}
+		}));
+		// Setup arguments
+		String testExec = args[0];
+		String dataLocation = args[1];
+		String outFile = args[2];
+		String outDir = args[3];
+		System.setProperty("data.location", dataLocation);
+		// Only run the listed test methods if there are any.
+		final Set<String> methods = new HashSet<String>();
+		if (args.length == 5) {
+			methods.addAll(Arrays.asList(args[4].split(",")));
+		}
+		try {
+			LauncherDiscoveryRequestBuilder builder = LauncherDiscoveryRequestBuilder
+					.request().selectors(
+							DiscoverySelectors.selectClass(testExec));
+			if (!methods.isEmpty()) {
+				builder.filters(new PostDiscoveryFilter() {
+					@Override
+					public FilterResult apply(TestDescriptor d) {
+						return FilterResult.includedIf(!d.isTest()
+								|| methods.contains(methodName(d.getSource()
+										.orElse(null))));
+					}
+				});
+			}
+			LauncherDiscoveryRequest request = builder.build();
+			Launcher launcher = LauncherFactory.create();
+			Report report = new Report(testExec);
+			launcher.execute(request, report);
+			report.write(new File(outDir, outFile + ".xml"));
+		} catch (Throwable e) {
+			try {
+				err.write(String.valueOf(e.getMessage()).getBytes());
+			} catch (IOException e1) {
+			}
+		} finally {
+			System.setOut(out);
+			System.setErr(err);
+			System.exit(0);
+		}
+	}
+
+	/**
+	 * Report listens to the execution of a Test and records the outcome of
+	 * each of its test cases.
+	 */
+	static class Report implements TestExecutionListener {
+		private String name;
+		private TestPlan plan;
+		private List<TestCase> cases = new ArrayList<TestCase>();
+		private Map<String, Long> starts = new HashMap<String, Long>();
+		private long start;
+
+		Report(String name) {
+			this.name = name;
+		}
+
+		@Override
+		public void testPlanExecutionStarted(TestPlan plan) {
+			this.plan = plan;
+			start = System.currentTimeMillis();
+		}
+
+		@Override
+		public void executionStarted(TestIdentifier id) {
+			starts.put(id.getUniqueId(), System.currentTimeMillis());
+		}
+
+		@Override
+		public void executionSkipped(TestIdentifier id, String reason) {
+			if (id.isTest()) {
+				cases.add(new TestCase(plan, id, "skipped", reason, null, 0));
+				return;
+			}
+			// Skipping a container skips all of its tests.
+			for (TestIdentifier d : plan.getDescendants(id)) {
+				if (d.isTest()) {
+					cases.add(new TestCase(plan, d, "skipped", reason, null, 0));
+				}
+			}
+		}
+
+		@Override
+		public void executionFinished(TestIdentifier id,
+				TestExecutionResult result) {
+			if (!id.isTest()) {
+				return;
+			}
+			Long s = starts.get(id.getUniqueId());
+			long time = s == null ? 0 : System.currentTimeMillis() - s;
+			Throwable t = result.getThrowable().orElse(null);
+			String kind = null;
+			switch (result.getStatus()) {
+			case SUCCESSFUL:
+				break;
+			case ABORTED:
+				kind = "skipped";
+				break;
+			default:
+				kind = t instanceof AssertionError ? "failure" : "error";
+			}
+			String message = t == null ? null : t.getMessage();
+			cases.add(new TestCase(plan, id, kind, message, t, time));
+		}
+
+		/**
+		 * write stores the report in the same format as ant's XML formatter.
+		 */
+		void write(File f) throws IOException {
+			int errors = 0, failures = 0, skipped = 0;
+			for (TestCase c : cases) {
+				if ("error".equals(c.kind)) {
+					errors++;
+				} else if ("failure".equals(c.kind)) {
+					failures++;
+				} else if ("skipped".equals(c.kind)) {
+					skipped++;
+				}
+			}
+			Writer w = new OutputStreamWriter(new FileOutputStream(f), "UTF-8");
+			try {
+				w.write("<?xml version=\"1.0\" encoding=\"UTF-8\" ?>\n");
+				w.write("<testsuite errors=\"" + errors + "\" failures=\""
+						+ failures + "\" skipped=\"" + skipped + "\" name=\""
+						+ escape(name) + "\" tests=\"" + cases.size()
+						+ "\" time=\""
+						+ seconds(System.currentTimeMillis() - start) + "\">\n");
+				for (TestCase c : cases) {
+					c.write(w);
+				}
+				w.write("</testsuite>\n");
+			} finally {
+				w.close();
+			}
+		}
+	}
+
+	/**
+	 * TestCase is the outcome of a single test. kind is null if the test
+	 * passed and otherwise the name of the XML element describing why it
+	 * didn't.
+	 */
+	static class TestCase {
+		String className, name, method, kind, message, type, trace;
+		long time;
+
+		TestCase(TestPlan plan, TestIdentifier id, String kind,
+				String message, Throwable t, long time) {
+			this.className = className(plan, id);
+			this.name = displayName(plan, id);
+			this.method = methodName(id.getSource().orElse(null));
+			this.kind = kind;
+			this.message = message;
+			this.time = time;
+			if (t != null) {
+				this.type = t.getClass().getName();
+				StringWriter sw = new StringWriter();
+				t.printStackTrace(new PrintWriter(sw));
+				this.trace = sw.toString();
+			}
+		}
+
+		void write(Writer w) throws IOException {
+			w.write("<testcase classname=\"" + escape(className)
+					+ "\" name=\"" + escape(name) + "\" time=\""
+					+ seconds(time) + "\"");
+			if (method != null) {
+				w.write(" method=\"" + escape(method) + "\"");
+			}
+			if (kind == null) {
+				w.write("/>\n");
+				return;
+			}
+			w.write("><" + kind);
+			if (message != null) {
+				w.write(" message=\"" + escape(message) + "\"");
+			}
+			if (type != null) {
+				w.write(" type=\"" + escape(type) + "\"");
+			}
+			w.write(">");
+			if (trace != null) {
+				w.write(escape(trace));
+			}
+			w.write("</" + kind + "></testcase>\n");
+		}
+
+		/**
+		 * className is the name of the class declaring the test's method.
+		 */
+		static String className(TestPlan plan, TestIdentifier id) {
+			for (TestIdentifier c = id; c != null; c = plan.getParent(c)
+					.orElse(null)) {
+				TestSource s = c.getSource().orElse(null);
+				if (s instanceof MethodSource) {
+					return ((MethodSource) s).getClassName();
+				}
+			}
+			return "";
+		}
+
+		/**
+		 * displayName joins the display names of the containers between the
+		 * test class and the test so that nested and parameterized tests can
+		 * be told apart.
+		 */
+		static String displayName(TestPlan plan, TestIdentifier id) {
+			LinkedList<String> names = new LinkedList<String>();
+			for (TestIdentifier c = id; c != null; c = plan.getParent(c)
+					.orElse(null)) {
+				TestIdentifier p = plan.getParent(c).orElse(null);
+				// Stop at the test class, whose parent is the engine.
+				if (p == null || !plan.getParent(p).isPresent()) {
+					break;
+				}
+				names.addFirst(c.getDisplayName());
+			}
+			if (names.isEmpty()) {
+				return id.getDisplayName();
+			}
+			StringBuilder sb = new StringBuilder();
+			for (String n : names) {
+				if (sb.length() > 0) {
+					sb.append(" > ");
+				}
+				sb.append(n);
+			}
+			return sb.toString();
+		}
+	}
+
+	/**
+	 * methodName is the name of the method a test is declared by or null if
+	 * it isn't declared by a method.
+	 */
+	static String methodName(TestSource s) {
+		if (s instanceof MethodSource) {
+			return ((MethodSource) s).getMethodName();
+		}
+		return null;
+	}
+
+	static String seconds(long millis) {
+		return String.valueOf(millis / 1000.0);
+	}
+
+	static String escape(String s) {
+		StringBuilder sb = new StringBuilder(s.length());
+		for (char c : s.toCharArray()) {
+			switch (c) {
+			case '<':
+				sb.append("&lt;");
+				break;
+			case '>':
+				sb.append("&gt;");
+				break;
+			case '&':
+				sb.append("&amp;");
+				break;
+			case '"':
+				sb.append("&quot;");
+				break;
+			default:
+				if (c >= 0x20 || c == '\n' || c == '\r' || c == '\t') {
+					sb.append(c);
+				}
+			}
+		}
+		return sb.toString();
+	}
+}
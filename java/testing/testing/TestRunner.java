@@ -47,8 +47,8 @@ import org.apache.tools.ant.taskdefs.optional.junit.JUnitTest;
  */
 public class TestRunner {
 	public static void main(String[] args) {
-		if (args.length != 4) {
-			throw new InvalidParameterException("Expected 4 arguments.");
+		if (args.length != 4 && args.length != 5) {
+			throw new InvalidParameterException("Expected 4 or 5 arguments.");
 		}
 		// Disable output pipes.
 		PrintStream out = System.out;
@@ -88,6 +88,10 @@ public class TestRunner {
 			JUnitTest test = new JUnitTest(testExec);
 			test.setOutfile(outFile);
 			test.setTodir(new File(outDir));
+			// Only run the listed test methods if there are any.
+			if (args.length == 5) {
+				test.setMethods(args[4]);
+			}
 			task.addTest(test);
 			task.execute();
 		} catch (Exception e) {
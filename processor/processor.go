@@ -33,7 +33,7 @@ import (
 	"github.com/godfried/impendulo/processor/request"
 	"github.com/godfried/impendulo/project"
 	"github.com/godfried/impendulo/tool"
-	"github.com/godfried/impendulo/tool/javac"
+	"github.com/godfried/impendulo/tool/metrics"
 	"github.com/godfried/impendulo/tool/result"
 	"github.com/godfried/impendulo/util"
 	"labix.org/v2/mgo/bson"
@@ -59,6 +59,8 @@ type (
 		jpfPath  string
 		compiler tool.Compiler
 		tools    []tool.T
+		//validating processors also use tests which have not been published.
+		validating bool
 	}
 	TestProcessor struct {
 		sub      *project.Submission
@@ -128,6 +130,9 @@ processing:
 	if e := db.UpdateTime(fp.sub); e != nil {
 		util.Log(e, LOG_PROCESSOR)
 	}
+	if e := db.TrackIssues(fp.sub.Id); e != nil {
+		util.Log(e, LOG_PROCESSOR)
+	}
 	os.RemoveAll(fp.rootDir)
 	util.Log("Processed submission", fp.sub, LOG_PROCESSOR)
 	dc <- util.E{}
@@ -156,9 +161,13 @@ func (fp *FileProcessor) ProcessSource(f *project.File) error {
 	defer util.Log("Processed file:", f.Id, LOG_PROCESSOR)
 	//Create a target for the tools to run on and save the file.
 	t := tool.NewTarget(f.Name, f.Package, fp.srcDir, tool.Language(fp.project.Lang))
-	if e := util.SaveFile(t.FilePath(), f.Data); e != nil {
+	if e := SaveSource(fp.project, f, t); e != nil {
 		return e
 	}
+	//Metrics don't require the snapshot to compile.
+	if e := runTool(metrics.New(t.Lang), f, t, metrics.NAME); e != nil {
+		util.Log(e, LOG_PROCESSOR)
+	}
 	RunTools(f, t, fp)
 	return nil
 }
@@ -236,7 +245,7 @@ func (fp *FileProcessor) StoreFile(n string, d []byte) (*project.File, error) {
 	}
 	f.SubId = fp.sub.Id
 	f.Data = d
-	if e := db.Add(db.FILES, f); e != nil {
+	if e := db.AddFile(f); e != nil {
 		return nil, e
 	}
 	return f, nil
@@ -263,7 +272,7 @@ func (fp *FileProcessor) Tools() []tool.T {
 func NewTestProcessor(tf *project.File, fp *FileProcessor) (*TestProcessor, error) {
 	d := filepath.Join(fp.rootDir, tf.Id.Hex())
 	td := filepath.Join(d, "tools")
-	c, e := javac.New("")
+	c, e := JavaCompiler(fp.project, td, fp.validating)
 	if e != nil {
 		return nil, e
 	}
@@ -300,7 +309,7 @@ func (tp *TestProcessor) Process(fid bson.ObjectId) error {
 		return e
 	}
 	t := tool.NewTarget(f.Name, f.Package, tp.srcDir, tool.JAVA)
-	if e = util.SaveFile(t.FilePath(), f.Data); e != nil {
+	if e = SaveSource(tp.project, f, t); e != nil {
 		return e
 	}
 	return RunTools(f, t, tp)
@@ -319,6 +328,21 @@ func (tp *TestProcessor) Tools() []tool.T {
 	return tp.tools
 }
 
+//SaveSource saves the source file f at its target t's location. If project p
+//is built as a whole, the source tree of f's submission as it was when f was
+//snapshotted is saved in t's directory. The directory is shared with the submission's
+//tests and compiled classes so its other files are kept.
+func SaveSource(p *project.Project, f *project.File, t *tool.Target) error {
+	if !p.WholeProject {
+		return util.SaveFile(t.FilePath(), f.Data)
+	}
+	tree, e := db.PackageTree(f)
+	if e != nil {
+		return e
+	}
+	return util.SaveFiles(t.Dir, tree)
+}
+
 //RunTools runs all available tools on a file. It skips a tool if
 //there is already a result for it present. This makes it possible to
 //rerun old tools or add new tools and run them on old files without having
@@ -32,14 +32,19 @@ import (
 	"github.com/godfried/impendulo/db"
 	"github.com/godfried/impendulo/project"
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/benchmark"
 	"github.com/godfried/impendulo/tool/checkstyle"
+	"github.com/godfried/impendulo/tool/diagnostic"
+	"github.com/godfried/impendulo/tool/external"
 	"github.com/godfried/impendulo/tool/findbugs"
 	"github.com/godfried/impendulo/tool/gcc"
+	"github.com/godfried/impendulo/tool/iotest"
 	"github.com/godfried/impendulo/tool/jacoco"
 	"github.com/godfried/impendulo/tool/javac"
 	"github.com/godfried/impendulo/tool/jpf"
 	"github.com/godfried/impendulo/tool/junit"
 	mk "github.com/godfried/impendulo/tool/make"
+	"github.com/godfried/impendulo/tool/mutation"
 	"github.com/godfried/impendulo/tool/pmd"
 	"github.com/godfried/impendulo/util"
 	"labix.org/v2/mgo/bson"
@@ -65,7 +70,7 @@ func cTestTools(p *TestProcessor, tf *project.File) []tool.T {
 }
 
 func javaTestTools(p *TestProcessor, tf *project.File) ([]tool.T, error) {
-	a := make([]tool.T, 0, 2)
+	a := make([]tool.T, 0, 3)
 	target := tool.NewTarget(tf.Name, tf.Package, filepath.Join(p.toolDir, tf.Id.Hex()), tool.JAVA)
 	if e := util.SaveFile(target.FilePath(), tf.Data); e != nil {
 		return nil, e
@@ -83,7 +88,13 @@ func javaTestTools(p *TestProcessor, tf *project.File) ([]tool.T, error) {
 	if e != nil {
 		return nil, e
 	}
-	return append(a, ja), nil
+	a = append(a, ja)
+	//Mutation testing shows whether the user's tests actually check their code.
+	mu, e := mutation.New(target, t.Target, p.toolDir, tf.Id)
+	if e != nil {
+		return nil, e
+	}
+	return append(a, mu), nil
 }
 
 //Tools retrieves the Impendulo tool suite for a Processor's language.
@@ -100,7 +111,23 @@ func Tools(p *FileProcessor) ([]tool.T, error) {
 }
 
 func cTools(p *FileProcessor) []tool.T {
-	return []tool.T{}
+	a := make([]tool.T, 0, 2)
+	if t, e := IOTest(p); e != nil {
+		util.Log(e, LOG_TOOLS)
+	} else {
+		a = append(a, t)
+	}
+	if t, e := Benchmark(p); e != nil {
+		util.Log(e, LOG_TOOLS)
+	} else {
+		a = append(a, t)
+	}
+	ts, e := ExternalTools(p)
+	if e != nil {
+		util.Log(e, LOG_TOOLS)
+		return a
+	}
+	return append(a, ts...)
 }
 
 //javaTools retrieves Impendulo's Java tool suite.
@@ -109,12 +136,12 @@ func javaTools(p *FileProcessor) ([]tool.T, error) {
 	//Only add tools if they were created successfully
 	var t tool.T
 	var e error
-	t, e = checkstyle.New()
+	t, e = Checkstyle(p)
 	if e != nil {
 		return nil, e
 	}
 	a = append(a, t)
-	t, e = findbugs.New()
+	t, e = Findbugs(p)
 	if e != nil {
 		return nil, e
 	}
@@ -130,10 +157,28 @@ func javaTools(p *FileProcessor) ([]tool.T, error) {
 		return nil, e
 	}
 	a = append(a, t)
+	t, e = IOTest(p)
+	if e != nil {
+		util.Log(e, LOG_TOOLS)
+	} else {
+		a = append(a, t)
+	}
+	t, e = Benchmark(p)
+	if e != nil {
+		util.Log(e, LOG_TOOLS)
+	} else {
+		a = append(a, t)
+	}
 	ts, e := junitTools(p)
 	if e != nil {
 		return nil, e
 	}
+	a = append(a, ts...)
+	ts, e = ExternalTools(p)
+	if e != nil {
+		util.Log(e, LOG_TOOLS)
+		return a, nil
+	}
 	return append(a, ts...), nil
 }
 
@@ -142,28 +187,152 @@ func Compiler(p *FileProcessor) (tool.Compiler, error) {
 	l := tool.Language(p.project.Lang)
 	switch l {
 	case tool.JAVA:
-		return javac.New("")
+		return JavaCompiler(p.project, p.toolDir, p.validating)
 	case tool.C:
-		m, e := db.Makefile(bson.M{db.PROJECTID: p.project.Id}, nil)
+		m, e := db.Makefile(db.Current(db.MAKE, p.project.Id, p.validating), nil)
 		if e != nil {
-			return gcc.New()
-		} else {
-			return mk.New(m, p.toolDir)
+			return CCompiler(p.project, p.validating)
+		}
+		c, e := mk.New(m, p.toolDir)
+		if e != nil {
+			return nil, e
 		}
+		c.SetCatalogue(Catalogue(p.project))
+		return c, nil
 	}
 	return nil, fmt.Errorf("no compiler found for %s language", l)
 }
 
+//Catalogue retrieves the catalogue used to explain compiler diagnostics in project p.
+//It consists of the project's own explanations followed by the built-in explanations.
+func Catalogue(p *project.Project) []*diagnostic.Explanation {
+	xs, e := db.Explanations(bson.M{db.PROJECTID: p.Id}, nil)
+	if e != nil {
+		util.Log(e, LOG_TOOLS)
+	}
+	return diagnostic.Catalogue(tool.Language(p.Lang), xs)
+}
+
+//JavaCompiler creates a javac instance for project p. If p is built as a whole,
+//the compiler compiles each snapshot with the rest of its source tree. JUnit is then
+//added to the classpath since the tree may contain tests. The project's javac
+//configuration is used if it has one and its jar files are stored in dir.
+//A pending configuration is used instead if the compiler is used for validation.
+func JavaCompiler(p *project.Project, dir string, validating bool) (*javac.Tool, error) {
+	var c *javac.Tool
+	var e error
+	if p.WholeProject {
+		c, e = javac.NewProject("")
+	} else {
+		c, e = javac.New("")
+	}
+	if e != nil {
+		return nil, e
+	}
+	if p.WholeProject {
+		if j, e := config.JUNIT.Path(); e == nil {
+			c.AddCP(j)
+		}
+	}
+	if jc, e := db.JavacConfig(db.Current(db.JAVAC, p.Id, validating), nil); e == nil {
+		if e = c.Configure(jc, filepath.Join(dir, "jars")); e != nil {
+			return nil, e
+		}
+	}
+	c.SetCatalogue(Catalogue(p))
+	return c, nil
+}
+
+//CCompiler creates a gcc instance for project p which uses the project's gcc
+//configuration if it has one, or its pending configuration if validating.
+func CCompiler(p *project.Project, validating bool) (*gcc.Tool, error) {
+	c, e := gcc.New()
+	if e != nil {
+		return nil, e
+	}
+	if gc, e := db.GCCConfig(db.Current(db.GCC, p.Id, validating), nil); e == nil {
+		c.Configure(gc)
+	}
+	c.SetCatalogue(Catalogue(p))
+	return c, nil
+}
+
+//ExternalTools creates instances of the external tools configured for a Processor's project.
+func ExternalTools(p *FileProcessor) ([]tool.T, error) {
+	cs, e := db.ExternalTools(bson.M{db.PROJECTID: p.project.Id}, nil)
+	if e != nil {
+		return nil, e
+	}
+	var cp string
+	if tool.Language(p.project.Lang) == tool.JAVA {
+		cp, _ = config.JUNIT.Path()
+	}
+	ts := make([]tool.T, len(cs))
+	for i, c := range cs {
+		ts[i] = external.New(c, cp)
+	}
+	return ts, nil
+}
+
 //JPF creates a new instance of the JPF tool.
 func JPF(p *FileProcessor) (tool.T, error) {
 	//First we need the project's JPF configuration.
-	c, e := db.JPFConfig(bson.M{db.PROJECTID: p.project.Id}, nil)
+	c, e := db.JPFConfig(db.Current(db.JPF, p.project.Id, p.validating), nil)
 	if e != nil {
 		return nil, e
 	}
 	return jpf.New(c, p.toolDir)
 }
 
+//IOTest creates a tool which runs a project's input/output test cases.
+func IOTest(p *FileProcessor) (tool.T, error) {
+	cs, e := db.IOTestCases(p.tests(), nil)
+	if e != nil {
+		return nil, e
+	}
+	if len(cs) == 0 {
+		return nil, fmt.Errorf("no input/output test cases for project %s", p.project.Name)
+	}
+	return iotest.New(cs, tool.Language(p.project.Lang))
+}
+
+//Benchmark creates a tool which measures snapshots on a project's benchmark workloads.
+//Snapshots are compared to the project's reference solution if it has one.
+func Benchmark(p *FileProcessor) (tool.T, error) {
+	ws, e := db.Workloads(bson.M{db.PROJECTID: p.project.Id}, nil)
+	if e != nil {
+		return nil, e
+	}
+	if len(ws) == 0 {
+		return nil, fmt.Errorf("no benchmark workloads for project %s", p.project.Name)
+	}
+	var d []byte
+	if r, e := db.Reference(bson.M{db.PROJECTID: p.project.Id}, bson.M{db.DATA: 1}); e == nil {
+		d = r.Data
+	}
+	return benchmark.New(ws, tool.Language(p.project.Lang), d, p.compiler, p.toolDir)
+}
+
+//Checkstyle creates a new instance of the Checkstyle tool which uses the project's
+//Checkstyle configuration if it has one.
+func Checkstyle(p *FileProcessor) (tool.T, error) {
+	c, e := db.CheckstyleConfig(bson.M{db.PROJECTID: p.project.Id}, nil)
+	if e != nil || c == nil || len(c.Checks) == 0 {
+		return checkstyle.New(nil)
+	}
+	return checkstyle.New(c)
+}
+
+//Findbugs creates a new instance of the Findbugs tool which uses the project's
+//Findbugs configuration if it has one.
+func Findbugs(p *FileProcessor) (tool.T, error) {
+	c, e := db.FindbugsConfig(bson.M{db.PROJECTID: p.project.Id}, nil)
+	if e != nil || c == nil {
+		return findbugs.New(nil)
+	}
+	return findbugs.New(c)
+}
+
 //PMD creates a new instance of the PMD tool.
 func PMD(p *FileProcessor) (tool.T, error) {
 	//First we need the project's PMD rules.
@@ -181,8 +350,19 @@ func PMD(p *FileProcessor) (tool.T, error) {
 	return pmd.New(r)
 }
 
+//tests creates a matcher for the tests a Processor should run. Only published
+//tests are run unless the Processor is validating them.
+func (p *FileProcessor) tests() bson.M {
+	if p.validating {
+		return bson.M{db.PROJECTID: p.project.Id}
+	}
+	return db.Live(p.project.Id)
+}
+
 func junitTools(p *FileProcessor) ([]tool.T, error) {
-	ts, e := db.JUnitTests(bson.M{db.PROJECTID: p.project.Id, db.TYPE: bson.M{db.NE: junit.USER}}, nil)
+	m := p.tests()
+	m[db.TYPE] = bson.M{db.NE: junit.USER}
+	ts, e := db.JUnitTests(m, nil)
 	if e != nil {
 		return nil, e
 	}
@@ -214,6 +394,8 @@ func junitTools(p *FileProcessor) ([]tool.T, error) {
 		if e != nil {
 			return nil, e
 		}
+		ju.SetReruns(t.Reruns)
+		ju.SetFramework(t.Framework)
 		tools = append(tools, ju)
 	}
 	return tools, nil
@@ -0,0 +1,185 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package processor
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/godfried/impendulo/db"
+	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/iotest"
+	"github.com/godfried/impendulo/tool/jpf"
+	"github.com/godfried/impendulo/tool/junit"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	//sourceExts are the extensions of the source files which are validated for each language.
+	sourceExts = map[tool.Language]string{tool.JAVA: ".java", tool.C: ".c"}
+)
+
+//Validate runs a project's tests and tool configurations, including those which
+//have not been published, on the project's reference solution. v is stored before
+//validation starts so that its progress can be followed and is updated with the outcome.
+func Validate(v *project.Validation) error {
+	if e := db.Add(db.VALIDATIONS, v); e != nil {
+		return e
+	}
+	if e := validate(v); e != nil {
+		v.Status, v.Error = project.FAILED, e.Error()
+	} else {
+		v.Status = project.DONE
+	}
+	return db.FinishValidation(v)
+}
+
+func validate(v *project.Validation) error {
+	p, e := db.Project(bson.M{db.ID: v.ProjectId}, nil)
+	if e != nil {
+		return e
+	}
+	r, e := db.Reference(bson.M{db.PROJECTID: p.Id}, nil)
+	if e != nil {
+		return fmt.Errorf("project %s has no reference solution", p.Name)
+	}
+	fs, e := util.UnzipToMap(r.Data)
+	if e != nil {
+		return e
+	}
+	d := filepath.Join(os.TempDir(), v.Id.Hex())
+	defer os.RemoveAll(d)
+	fp := &FileProcessor{
+		project:    p,
+		rootDir:    d,
+		srcDir:     filepath.Join(d, "src"),
+		toolDir:    filepath.Join(d, "tools"),
+		validating: true,
+	}
+	if e = util.SaveFiles(fp.srcDir, fs); e != nil {
+		return e
+	}
+	if fp.compiler, e = Compiler(fp); e != nil {
+		return e
+	}
+	ts, e := validationTools(fp)
+	if e != nil {
+		return e
+	}
+	l := tool.Language(p.Lang)
+	ns := make([]string, 0, len(fs))
+	for n := range fs {
+		if filepath.Ext(n) == sourceExts[l] {
+			ns = append(ns, n)
+		}
+	}
+	if len(ns) == 0 {
+		return fmt.Errorf("reference solution %s has no %s source files", r.Name, l)
+	}
+	sort.Strings(ns)
+	for _, n := range ns {
+		pkg := util.GetPackage(bytes.NewReader(fs[n]))
+		target := tool.NewTarget(filepath.Base(n), pkg, sourceRoot(filepath.Join(fp.srcDir, n), pkg), l)
+		id := bson.NewObjectId()
+		if _, e := fp.compiler.Run(id, target); e != nil {
+			v.Add(n, fp.compiler.Name(), false, e.Error())
+			continue
+		}
+		v.Add(n, fp.compiler.Name(), true, "")
+		for _, t := range ts {
+			if j, ok := t.(*junit.Tool); ok && j.Target().Executable() != target.Executable() {
+				continue
+			}
+			r, e := t.Run(id, target)
+			if e == nil && r == nil {
+				//The tool doesn't apply to this file.
+				continue
+			}
+			ok, msg := verdict(r, e)
+			v.Add(n, t.Name(), ok, msg)
+		}
+	}
+	return nil
+}
+
+//validationTools retrieves the tools which run a project's tests.
+func validationTools(p *FileProcessor) ([]tool.T, error) {
+	ts := make([]tool.T, 0, 5)
+	if tool.Language(p.project.Lang) == tool.JAVA {
+		js, e := junitTools(p)
+		if e != nil {
+			return nil, e
+		}
+		for _, j := range js {
+			if _, ok := j.(*junit.Tool); ok {
+				ts = append(ts, j)
+			}
+		}
+		if j, e := JPF(p); e == nil {
+			ts = append(ts, j)
+		}
+	}
+	if t, e := IOTest(p); e == nil {
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+//verdict determines whether a tool's result on the reference solution is acceptable.
+func verdict(r result.Tooler, e error) (bool, string) {
+	if e != nil {
+		return false, e.Error()
+	}
+	switch v := r.(type) {
+	case *junit.Result:
+		return v.Report.Success(), fmt.Sprintf("%d tests, %d failures, %d errors", v.Report.Tests, v.Report.Failures, v.Report.Errors)
+	case *jpf.Result:
+		return v.Report.Success(), fmt.Sprintf("%d errors", v.Report.ErrorCount())
+	case *iotest.Result:
+		return v.Report.Success(), fmt.Sprintf("passed %d of %d test cases", v.Report.Passed, v.Report.Total())
+	}
+	return true, ""
+}
+
+//sourceRoot determines the root of the source tree containing the file at p from its package.
+func sourceRoot(p, pkg string) string {
+	d := filepath.Dir(p)
+	if pkg == "" {
+		return d
+	}
+	s := string(filepath.Separator) + filepath.Join(strings.Split(pkg, ".")...)
+	if strings.HasSuffix(d, s) {
+		return strings.TrimSuffix(d, s)
+	}
+	return d
+}
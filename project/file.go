@@ -48,7 +48,8 @@ type (
 		Package  string        `bson:"package"`
 		Type     Type          `bson:"type"`
 		Time     int64         `bson:"time"`
-		Data     []byte        `bson:"data"`
+		Data     []byte        `bson:"data,omitempty"`
+		Blob     string        `bson:"blob,omitempty"`
 		Results  bson.M        `bson:"results"`
 		Comments []*Comment    `bson:"comments"`
 	}
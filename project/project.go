@@ -41,6 +41,9 @@ type (
 		Lang        string        `bson:"lang"`
 		Time        int64         `bson:"time"`
 		Description string        `bson:"description"`
+		//WholeProject indicates whether each snapshot should be built and tested
+		//together with the rest of its submission's source tree.
+		WholeProject bool `bson:"wholeproject"`
 	}
 
 	Comment struct {
@@ -0,0 +1,107 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package project
+
+import (
+	"github.com/godfried/impendulo/util"
+
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Validation records how a project's tests and tool configurations performed
+	//on the project's reference solution after Trigger changed them. Tools
+	//are the results which should be recalculated for existing submissions
+	//because of the change and All indicates that all results should be.
+	Validation struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Trigger   string        `bson:"trigger"`
+		User      string        `bson:"user"`
+		Time      int64         `bson:"time"`
+		Status    Status        `bson:"status"`
+		Error     string        `bson:"error"`
+		Tools     []string      `bson:"tools"`
+		All       bool          `bson:"all"`
+		Checks    []*Check      `bson:"checks"`
+	}
+
+	//Check is the outcome of running a tool on a file in the reference solution.
+	Check struct {
+		File    string `bson:"file"`
+		Tool    string `bson:"tool"`
+		Passed  bool   `bson:"passed"`
+		Message string `bson:"message"`
+	}
+
+	Status string
+)
+
+const (
+	RUNNING Status = "Running"
+	DONE    Status = "Done"
+	FAILED  Status = "Failed"
+)
+
+//NewValidation
+func NewValidation(pid bson.ObjectId, trigger, u string, tools []string, all bool) *Validation {
+	return &Validation{
+		Id:        bson.NewObjectId(),
+		ProjectId: pid,
+		Trigger:   trigger,
+		User:      u,
+		Time:      util.CurMilis(),
+		Status:    RUNNING,
+		Tools:     tools,
+		All:       all,
+		Checks:    make([]*Check, 0),
+	}
+}
+
+//Add adds the outcome of running tool t on file f to the Validation.
+func (v *Validation) Add(f, t string, passed bool, msg string) {
+	v.Checks = append(v.Checks, &Check{File: f, Tool: t, Passed: passed, Message: msg})
+}
+
+//Failures is the number of checks which failed.
+func (v *Validation) Failures() int {
+	n := 0
+	for _, c := range v.Checks {
+		if !c.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+//Passed checks whether the validation completed without any failures.
+func (v *Validation) Passed() bool {
+	return v.Status == DONE && v.Failures() == 0
+}
+
+//Reevaluate checks whether existing submissions need to be processed again because of the change.
+func (v *Validation) Reevaluate() bool {
+	return v.All || len(v.Tools) > 0
+}
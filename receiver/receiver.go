@@ -269,7 +269,7 @@ func (s *SubmissionHandler) Read() (bool, error) {
 				return false, e
 			}
 		}
-		if e = db.Add(db.FILES, f); e != nil {
+		if e = db.AddFile(f); e != nil {
 			return false, e
 		}
 		//Send file to be processed.
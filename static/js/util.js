@@ -273,6 +273,102 @@ var SkeletonDowload = {
     }
 }
 
+var TreeDownload = {
+    init: function() {
+        $(function() {
+            $.getJSON('projects', function(data) {
+                if (not(data['projects'])) {
+                    return;
+                }
+                var ps = data['projects'];
+                for (var i = 0; i < ps.length; i++) {
+                    $('#project-id').append('<option value="' + ps[i].Id + '">' + ps[i].Name + '</option>');
+                }
+                TreeDownload.addSubmissions(ps[0].Id);
+                $('#project-id').change(function() {
+                    TreeDownload.addSubmissions($(this).val());
+                });
+            });
+        });
+    },
+
+    addSubmissions: function() {
+        var id = $('#project-id').val();
+        $.getJSON('submissions?project-id=' + id, function(data) {
+            $('#submission-id').empty();
+            $('#submission-id').hide();
+            if (not(data['submissions'])) {
+                return;
+            }
+            $('#submission-id').show();
+            var ss = data['submissions'];
+            for (var i = 0; i < ss.length; i++) {
+                $('#submission-id').append('<option value="' + ss[i].Id + '">' + ss[i].User + ' \u2192 ' + new Date(ss[i].Time).toLocaleString() + '</option>');
+            }
+        });
+    }
+}
+
+var SarifDownload = {
+    init: function() {
+        $(function() {
+            SarifDownload.showScope();
+            $('#scope').change(SarifDownload.showScope);
+            $.getJSON('projects', function(data) {
+                if (not(data['projects'])) {
+                    return;
+                }
+                var ps = data['projects'];
+                for (var i = 0; i < ps.length; i++) {
+                    $('#project-id').append('<option value="' + ps[i].Id + '">' + ps[i].Name + '</option>');
+                }
+                SarifDownload.addSubmissions();
+                $('#project-id').change(SarifDownload.addSubmissions);
+                $('#submission-id').change(SarifDownload.addFiles);
+            });
+        });
+    },
+
+    showScope: function() {
+        var s = $('#scope').val();
+        $('#submission-group').toggle(s !== 'project');
+        $('#file-group').toggle(s === 'snapshot');
+    },
+
+    addSubmissions: function() {
+        var id = $('#project-id').val();
+        $.getJSON('submissions?project-id=' + id, function(data) {
+            $('#submission-id').empty();
+            $('#file-id').empty();
+            if (not(data['submissions'])) {
+                return;
+            }
+            var ss = data['submissions'];
+            for (var i = 0; i < ss.length; i++) {
+                $('#submission-id').append('<option value="' + ss[i].Id + '">' + ss[i].User + ' \u2192 ' + new Date(ss[i].Time).toLocaleString() + '</option>');
+            }
+            SarifDownload.addFiles();
+        });
+    },
+
+    addFiles: function() {
+        var id = $('#submission-id').val();
+        $.getJSON('files?submission-id=' + id, function(data) {
+            $('#file-id').empty();
+            if (not(data['files'])) {
+                return;
+            }
+            var fs = data['files'];
+            for (var i = 0; i < fs.length; i++) {
+                if (fs[i].Type !== 'src') {
+                    continue;
+                }
+                $('#file-id').append('<option value="' + fs[i].Id + '">' + fs[i].Name + ' \u2192 ' + new Date(fs[i].Time).toLocaleString() + '</option>');
+            }
+        });
+    }
+}
+
 var TestDowload = {
     init: function() {
         $(function() {
@@ -366,6 +462,29 @@ function not(v) {
     return v === null || v === undefined || v.length === 0;
 }
 
+var IssueView = {
+    init: function(fid) {
+        var filters = $('select.issue-filter[id$="-' + fid + '"]');
+        filters.change(function() {
+            IssueView.filter(fid, filters);
+        });
+    },
+
+    filter: function(fid, filters) {
+        $('#issues-' + fid + ' tbody tr').each(function() {
+            var row = $(this);
+            var show = true;
+            filters.each(function() {
+                var v = $(this).val();
+                if (v !== '' && row.attr('data-' + $(this).attr('data-field')) !== v) {
+                    show = false;
+                }
+            });
+            row.toggle(show);
+        });
+    }
+}
+
 var Analysis = {
     showToolCode: function(name, pid, title) {
         var id = 'toolcode-modal';
@@ -640,6 +759,7 @@ var EditView = {
             $('#project-id').val(p.Id);
             $('#project-name').val(p.Name);
             $('#project-description').val(p.Description);
+            $('#project-whole').prop('checked', p.WholeProject);
             $.getJSON('usernames', function(udata) {
                 var users = udata['usernames'];
                 if (not(users)) {
@@ -0,0 +1,160 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package benchmark
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type (
+	//compiler simulates compilation by making a copy of a script executable.
+	compiler struct {
+		runs int
+	}
+)
+
+func (c *compiler) Name() string {
+	return "Compiler"
+}
+
+func (c *compiler) Lang() tool.Language {
+	return tool.C
+}
+
+func (c *compiler) AddCP(string) {
+}
+
+func (c *compiler) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
+	c.runs++
+	d, e := ioutil.ReadFile(target.FilePath())
+	if e != nil {
+		return nil, e
+	}
+	return nil, ioutil.WriteFile(filepath.Join(target.PackagePath(), target.Name), d, 0755)
+}
+
+const (
+	script = "#!/bin/sh\nif [ \"$1\" = fail ]; then\n  echo oops >&2\n  exit 1\nfi\ncat > /dev/null\nsleep %s\n"
+)
+
+func TestNewWorkload(t *testing.T) {
+	pid := bson.NewObjectId()
+	w, e := NewWorkload(pid, "large", "C", "", "", "", 0, 0)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if w.Runs != DEFAULT_RUNS || w.Timeout != DEFAULT_TIMEOUT {
+		t.Errorf("Expected default runs and timeout but got %d and %d.", w.Runs, w.Timeout)
+	}
+	if _, e = NewWorkload(pid, "large-1", "C", "", "", "", 0, 0); e == nil {
+		t.Error("Expected error for invalid name.")
+	}
+	if _, e = NewWorkload(pid, "large", "C", "", "", "", MAX_RUNS+1, 0); e == nil {
+		t.Error("Expected error for too many runs.")
+	}
+}
+
+func TestNewMeasurement(t *testing.T) {
+	us := []*tool.Usage{
+		{Wall: 3 * time.Millisecond, CPU: 2 * time.Millisecond, Memory: 100},
+		{Wall: 5 * time.Millisecond, CPU: 4 * time.Millisecond, Memory: 300},
+	}
+	m := NewMeasurement("large", us)
+	if m.Runs != 2 || m.Wall != 4 || m.CPU != 3 || m.Memory != 300 {
+		t.Errorf("Invalid measurement %s.", m)
+	}
+	m.Reference = &Measurement{Wall: 2, CPU: 1.5, Memory: 150}
+	if m.WallRatio() != 2 || m.CPURatio() != 2 || m.MemoryRatio() != 2 {
+		t.Errorf("Invalid ratios %v, %v, %v.", m.WallRatio(), m.CPURatio(), m.MemoryRatio())
+	}
+	r := NewReport(bson.NewObjectId())
+	r.Add(m)
+	r.Add(&Measurement{Wall: 1, CPU: 1, Memory: 50})
+	if tot := r.Total(); tot.Wall != 5 || tot.Memory != 300 || tot.Reference != nil {
+		t.Errorf("Invalid total %s.", tot)
+	}
+}
+
+func TestRun(t *testing.T) {
+	d, e := ioutil.TempDir("", "benchmark")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(d)
+	target := tool.NewTarget("prog.c", "", filepath.Join(d, "src"), tool.C)
+	if e = util.SaveFile(target.FilePath(), []byte(fmt.Sprintf(script, "0.2"))); e != nil {
+		t.Fatal(e)
+	}
+	c := &compiler{}
+	if _, e = c.Run("", target); e != nil {
+		t.Fatal(e)
+	}
+	ref, e := util.ZipMap(map[string][]byte{"prog.c": []byte(fmt.Sprintf(script, "0"))})
+	if e != nil {
+		t.Fatal(e)
+	}
+	pid := bson.NewObjectId()
+	ws := []*Workload{
+		{ProjectId: pid, Name: "small", Input: "1 2 3", Runs: 2, Timeout: 5},
+		{ProjectId: pid, Name: "broken", Args: "fail", Runs: 2, Timeout: 5},
+		{ProjectId: pid, Name: "other", File: "other.c", Runs: 1, Timeout: 5},
+	}
+	tl, e := New(ws, tool.C, ref, c, filepath.Join(d, "tools"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := 0; i < 2; i++ {
+		r, e := tl.Run(bson.NewObjectId(), target)
+		if e != nil {
+			t.Fatal(e)
+		}
+		rep := r.(*Result).Report
+		if len(rep.Measurements) != 2 || rep.ReferenceError != "" {
+			t.Fatalf("Invalid report %s: %s.", rep, rep.ReferenceError)
+		}
+		s := rep.Measurements[0]
+		if !s.Complete() || s.Runs != 2 || s.Wall < 200 || s.Reference == nil || s.WallRatio() <= 1 {
+			t.Errorf("Invalid measurement %s with reference %s.", s, s.Reference)
+		}
+		b := rep.Measurements[1]
+		if b.Complete() || b.Error != "oops" || b.Runs != 0 || b.Reference != nil {
+			t.Errorf("Expected broken measurement but got %s.", b)
+		}
+	}
+	if c.runs != 2 {
+		t.Errorf("Expected reference to be compiled once but got %d.", c.runs-1)
+	}
+}
@@ -0,0 +1,174 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package benchmark
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"time"
+)
+
+type (
+	//Report contains a snapshot's measurements for each workload. ReferenceError
+	//describes why the reference solution could not be measured.
+	Report struct {
+		Id             bson.ObjectId  `bson:"_id"`
+		Measurements   []*Measurement `bson:"measurements"`
+		ReferenceError string         `bson:"referenceerror"`
+	}
+
+	//Measurement is the resource usage of a program on a workload. Wall and CPU are
+	//the mean times in milliseconds over all successful runs and Memory is the
+	//peak memory usage in kilobytes. A measurement is incomplete if a run timed out
+	//or failed. Reference is the reference solution's measurement on the same workload.
+	Measurement struct {
+		Workload  string       `bson:"workload"`
+		Runs      int          `bson:"runs"`
+		Wall      float64      `bson:"wall"`
+		CPU       float64      `bson:"cpu"`
+		Memory    int64        `bson:"memory"`
+		Timeout   bool         `bson:"timeout"`
+		Error     string       `bson:"error"`
+		Reference *Measurement `bson:"reference"`
+	}
+)
+
+//NewReport creates an empty Report.
+func NewReport(id bson.ObjectId) *Report {
+	return &Report{Id: id, Measurements: make([]*Measurement, 0)}
+}
+
+//NewMeasurement calculates the Measurement of workload n from the usage of each run.
+func NewMeasurement(n string, us []*tool.Usage) *Measurement {
+	m := &Measurement{Workload: n, Runs: len(us)}
+	if m.Runs == 0 {
+		return m
+	}
+	var w, c time.Duration
+	for _, u := range us {
+		w += u.Wall
+		c += u.CPU
+		if u.Memory > m.Memory {
+			m.Memory = u.Memory
+		}
+	}
+	m.Wall = millis(w / time.Duration(m.Runs))
+	m.CPU = millis(c / time.Duration(m.Runs))
+	return m
+}
+
+//millis converts d to milliseconds rounded to 2 decimal places.
+func millis(d time.Duration) float64 {
+	return util.Round(float64(d)/float64(time.Millisecond), 2)
+}
+
+//Add adds a Measurement to the Report.
+func (r *Report) Add(m *Measurement) {
+	r.Measurements = append(r.Measurements, m)
+}
+
+//Complete checks whether all the workloads were run successfully.
+func (r *Report) Complete() bool {
+	for _, m := range r.Measurements {
+		if !m.Complete() {
+			return false
+		}
+	}
+	return true
+}
+
+//Total sums the measurements of all workloads. The total's
+//Reference is only set if all workloads have a reference.
+func (r *Report) Total() *Measurement {
+	t := &Measurement{Workload: "Total", Reference: &Measurement{Workload: "Total"}}
+	for _, m := range r.Measurements {
+		t.add(m)
+		if m.Reference == nil {
+			t.Reference = nil
+		} else if t.Reference != nil {
+			t.Reference.add(m.Reference)
+		}
+	}
+	return t
+}
+
+func (m *Measurement) add(o *Measurement) {
+	m.Runs += o.Runs
+	m.Wall += o.Wall
+	m.CPU += o.CPU
+	if o.Memory > m.Memory {
+		m.Memory = o.Memory
+	}
+}
+
+//Complete checks whether all the Measurement's runs were successful.
+func (m *Measurement) Complete() bool {
+	return !m.Timeout && m.Error == ""
+}
+
+//WallRatio is the ratio of the wall time to the reference's wall time.
+func (m *Measurement) WallRatio() float64 {
+	if m.Reference == nil {
+		return 0
+	}
+	return ratio(m.Wall, m.Reference.Wall)
+}
+
+//CPURatio is the ratio of the CPU time to the reference's CPU time.
+func (m *Measurement) CPURatio() float64 {
+	if m.Reference == nil {
+		return 0
+	}
+	return ratio(m.CPU, m.Reference.CPU)
+}
+
+//MemoryRatio is the ratio of the peak memory to the reference's peak memory.
+func (m *Measurement) MemoryRatio() float64 {
+	if m.Reference == nil {
+		return 0
+	}
+	return ratio(float64(m.Memory), float64(m.Reference.Memory))
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return util.Round(a/b, 2)
+}
+
+//String
+func (r *Report) String() string {
+	return fmt.Sprintf("Id: %q; Measurements: %d", r.Id, len(r.Measurements))
+}
+
+//String
+func (m *Measurement) String() string {
+	return fmt.Sprintf("Workload: %s; Runs: %d; Wall: %vms; CPU: %vms; Memory: %dKB", m.Workload, m.Runs, m.Wall, m.CPU, m.Memory)
+}
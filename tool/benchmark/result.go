@@ -0,0 +1,126 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package benchmark
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+const (
+	NAME = "Benchmark"
+)
+
+type (
+	Result struct {
+		Id     bson.ObjectId `bson:"_id"`
+		FileId bson.ObjectId `bson:"fileid"`
+		Name   string        `bson:"name"`
+		Report *Report       `bson:"report"`
+		GridFS bool          `bson:"gridfs"`
+		Type   string        `bson:"type"`
+	}
+)
+
+func (r *Result) GetType() string {
+	return r.Type
+}
+
+//SetReport
+func (r *Result) SetReport(report result.Reporter) {
+	if report == nil {
+		r.Report = nil
+	} else {
+		r.Report = report.(*Report)
+	}
+}
+
+//OnGridFS
+func (r *Result) OnGridFS() bool {
+	return r.GridFS
+}
+
+//String
+func (r *Result) String() string {
+	return fmt.Sprintf("Id: %q; FileId: %q; Name: %s; \nReport: %s\n",
+		r.Id, r.FileId, r.Name, r.Report)
+}
+
+//GetName
+func (r *Result) GetName() string {
+	return r.Name
+}
+
+//GetId
+func (r *Result) GetId() bson.ObjectId {
+	return r.Id
+}
+
+//GetFileId
+func (r *Result) GetFileId() bson.ObjectId {
+	return r.FileId
+}
+
+func (r *Result) GetTestId() bson.ObjectId {
+	return ""
+}
+
+func (r *Result) Reporter() result.Reporter {
+	return r.Report
+}
+
+//ChartVals charts the total time and memory used for all workloads. The reference
+//solution's CPU time is charted as well so that it is clear when a snapshot
+//becomes as efficient as the reference.
+func (r *Result) ChartVals() []*result.ChartVal {
+	t := r.Report.Total()
+	vs := []*result.ChartVal{
+		&result.ChartVal{Name: "Wall Time (ms)", Y: t.Wall, FileId: r.FileId},
+		&result.ChartVal{Name: "CPU Time (ms)", Y: t.CPU, FileId: r.FileId},
+		&result.ChartVal{Name: "Peak Memory (MB)", Y: util.Round(float64(t.Memory)/1024, 2), FileId: r.FileId},
+	}
+	if t.Reference != nil {
+		vs = append(vs, &result.ChartVal{Name: "Reference CPU Time (ms)", Y: t.Reference.CPU, FileId: r.FileId})
+	}
+	return vs
+}
+
+func (r *Result) Template() string {
+	return "benchmarkresult"
+}
+
+//NewResult creates a new benchmark Result from report.
+func NewResult(fileId bson.ObjectId, report *Report) *Result {
+	return &Result{
+		Id:     report.Id,
+		FileId: fileId,
+		Name:   NAME,
+		Report: report,
+		Type:   NAME,
+	}
+}
@@ -0,0 +1,202 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package benchmark provides a tool which measures the time and memory a compiled
+//snapshot uses on teacher supplied workloads and compares it to a reference solution.
+package benchmark
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/config"
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	//Tool benchmarks a project's snapshots. The reference solution is measured
+	//by the same Tool so that both are measured on the same machine under similar load.
+	Tool struct {
+		workloads []*Workload
+		lang      tool.Language
+		cmd       string
+		//reference is the zipped source tree of the project's reference solution.
+		reference []byte
+		compiler  tool.Compiler
+		dir       string
+		//measured and failed store the outcome of measuring the
+		//reference for each target so that it is only measured once.
+		measured map[string]*Report
+		failed   map[string]error
+	}
+)
+
+//New creates a new Tool which runs ws on programs written in lang. If ref is not nil, it is
+//unzipped in toolDir, compiled with c and measured on the same workloads as each snapshot.
+func New(ws []*Workload, lang tool.Language, ref []byte, c tool.Compiler, toolDir string) (*Tool, error) {
+	t := &Tool{
+		workloads: ws,
+		lang:      lang,
+		reference: ref,
+		compiler:  c,
+		dir:       filepath.Join(toolDir, "reference"),
+		measured:  make(map[string]*Report),
+		failed:    make(map[string]error),
+	}
+	if lang == tool.JAVA {
+		p, e := config.JAVA.Path()
+		if e != nil {
+			return nil, e
+		}
+		t.cmd = p
+	}
+	return t, nil
+}
+
+//Lang
+func (t *Tool) Lang() tool.Language {
+	return t.lang
+}
+
+//Name
+func (t *Tool) Name() string {
+	return NAME
+}
+
+//Run measures target on each workload which applies to it. Targets without any
+//workloads are skipped. A snapshot which fails on a workload is not treated as an
+//error since its measurements for the other workloads are still useful.
+func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
+	ws := t.applicable(target)
+	if len(ws) == 0 {
+		return nil, nil
+	}
+	ref, re := t.measureReference(target)
+	r := NewReport(bson.NewObjectId())
+	if re != nil {
+		r.ReferenceError = re.Error()
+	}
+	for i, w := range ws {
+		m, e := t.measure(w, target)
+		if e != nil {
+			return nil, e
+		}
+		if ref != nil && ref.Measurements[i].Complete() {
+			m.Reference = ref.Measurements[i]
+		}
+		r.Add(m)
+	}
+	return NewResult(fileId, r), nil
+}
+
+func (t *Tool) applicable(target *tool.Target) []*Workload {
+	ws := make([]*Workload, 0, len(t.workloads))
+	for _, w := range t.workloads {
+		if w.Applies(target.FullName()) {
+			ws = append(ws, w)
+		}
+	}
+	return ws
+}
+
+//measureReference measures the reference solution's version of target. The
+//outcome is stored so that it is only measured once per Tool.
+func (t *Tool) measureReference(target *tool.Target) (*Report, error) {
+	if t.reference == nil {
+		return nil, nil
+	}
+	n := target.FullName()
+	if r, ok := t.measured[n]; ok {
+		return r, t.failed[n]
+	}
+	r, e := t._measureReference(target)
+	t.measured[n], t.failed[n] = r, e
+	return r, e
+}
+
+func (t *Tool) _measureReference(target *tool.Target) (*Report, error) {
+	if e := os.RemoveAll(t.dir); e != nil {
+		return nil, e
+	}
+	if e := util.Unzip(t.dir, t.reference); e != nil {
+		return nil, e
+	}
+	rt := tool.NewTarget(target.FullName(), target.Package, t.dir, t.lang)
+	if !util.Exists(rt.FilePath()) {
+		return nil, fmt.Errorf("reference solution has no file %s", rt.FilePath())
+	}
+	if _, e := t.compiler.Run("", rt); e != nil {
+		return nil, e
+	}
+	r := NewReport(bson.NewObjectId())
+	for _, w := range t.applicable(target) {
+		m, e := t.measure(w, rt)
+		if e != nil {
+			return nil, e
+		}
+		r.Add(m)
+	}
+	return r, nil
+}
+
+//measure runs target w.Runs times. Measuring stops at the first run which fails.
+func (t *Tool) measure(w *Workload, target *tool.Target) (*Measurement, error) {
+	a := append(t.args(target), w.Arguments()...)
+	us := make([]*tool.Usage, 0, w.Runs)
+	var to bool
+	var msg string
+	for i := 0; i < w.Runs; i++ {
+		r, e := tool.RunCommand(a, strings.NewReader(w.Input), w.MaxTime())
+		if tool.IsTimeout(e) {
+			to = true
+			break
+		} else if tool.IsEndError(e) {
+			msg = strings.TrimSpace(string(r.StdErr))
+			if msg == "" {
+				msg = e.Error()
+			}
+			break
+		} else if e != nil {
+			return nil, e
+		}
+		us = append(us, r.Usage)
+	}
+	m := NewMeasurement(w.Name, us)
+	m.Timeout, m.Error = to, msg
+	return m, nil
+}
+
+//args creates the command used to run target.
+func (t *Tool) args(target *tool.Target) []string {
+	if t.lang == tool.JAVA {
+		return []string{t.cmd, "-cp", target.Dir, target.Executable()}
+	}
+	return []string{filepath.Join(target.PackagePath(), target.Name)}
+}
@@ -0,0 +1,119 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package benchmark
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"regexp"
+	"strings"
+	"time"
+)
+
+type (
+	//Workload is a teacher supplied input a program is benchmarked with.
+	//The program is run Runs times with Args and Input as its standard input.
+	Workload struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Name      string        `bson:"name"`
+		Lang      string        `bson:"lang"`
+		Time      int64         `bson:"time"`
+		//File is the name of the source file the workload is run on. The
+		//workload is run on all the project's source files if it is empty.
+		File  string `bson:"file"`
+		Args  string `bson:"args"`
+		Input string `bson:"input"`
+		Runs  int    `bson:"runs"`
+		//Timeout is the maximum number of seconds a single run may take.
+		Timeout int `bson:"timeout"`
+	}
+)
+
+const (
+	DEFAULT_RUNS    = 3
+	MAX_RUNS        = 20
+	DEFAULT_TIMEOUT = 30
+)
+
+var (
+	validName = regexp.MustCompile(`^\w+$`)
+)
+
+//NewWorkload creates a new Workload for project pid. An error is returned if
+//the workload is invalid. Defaults are used for runs and timeout if they are not positive.
+func NewWorkload(pid bson.ObjectId, name, lang, file, args, input string, runs, timeout int) (*Workload, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid workload name %q, only letters, digits and underscores are allowed", name)
+	}
+	if !tool.Supported(tool.Language(lang)) {
+		return nil, fmt.Errorf("unsupported language %s", lang)
+	}
+	if runs <= 0 {
+		runs = DEFAULT_RUNS
+	} else if runs > MAX_RUNS {
+		return nil, fmt.Errorf("too many runs %d, at most %d are allowed", runs, MAX_RUNS)
+	}
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	return &Workload{
+		Id:        bson.NewObjectId(),
+		ProjectId: pid,
+		Name:      name,
+		Lang:      lang,
+		Time:      util.CurMilis(),
+		File:      strings.TrimSpace(file),
+		Args:      args,
+		Input:     input,
+		Runs:      runs,
+		Timeout:   timeout,
+	}, nil
+}
+
+//Applies checks whether the workload should be run on the file n.
+func (w *Workload) Applies(n string) bool {
+	return w.File == "" || w.File == n
+}
+
+//Arguments retrieves the command line arguments the program is run with.
+func (w *Workload) Arguments() []string {
+	return strings.Fields(w.Args)
+}
+
+//MaxTime is the maximum time a single run may take.
+func (w *Workload) MaxTime() time.Duration {
+	return time.Duration(w.Timeout) * time.Second
+}
+
+//String
+func (w *Workload) String() string {
+	return fmt.Sprintf("Id: %q; ProjectId: %q; Name: %s; Lang: %s; File: %s; Args: %s; Runs: %d; Timeout: %d",
+		w.Id, w.ProjectId, w.Name, w.Lang, w.File, w.Args, w.Runs, w.Timeout)
+}
@@ -25,11 +25,13 @@
 package checkstyle
 
 import (
+	"bytes"
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/util"
 	"labix.org/v2/mgo/bson"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -44,7 +46,7 @@ func TestRun(t *testing.T) {
 	if err != nil {
 		t.Errorf("Could not save file %q", err)
 	}
-	check, err := New()
+	check, err := New(nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -55,6 +57,86 @@ func TestRun(t *testing.T) {
 	os.Remove(filepath.Join(location, "checkstyle.xml"))
 }
 
+func TestConfig(t *testing.T) {
+	var e error
+	if checks, e = parseChecks(cfg); e != nil {
+		t.Fatal(e)
+	}
+	defer func() { checks = nil }()
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(checks))
+	}
+	pid := bson.NewObjectId()
+	if _, e = NewProfile(pid, NOVICE); e == nil || !strings.Contains(e.Error(), "ConstantName") {
+		t.Errorf("expected error listing unavailable checks, got %v", e)
+	}
+	profiles["Test"] = []string{"LineLength", "FileTabCharacter"}
+	defer delete(profiles, "Test")
+	p, e := NewProfile(pid, "Test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(p.Checks) != 2 || !p.Enabled("FileTabCharacter") || !p.Enabled("LineLength") || p.Enabled("JavadocMethod") {
+		t.Errorf("invalid profile %v", p.Checks)
+	}
+	if p, e = NewProfile(pid, ALL); e != nil {
+		t.Fatal(e)
+	} else if len(p.Checks) != 3 {
+		t.Errorf("expected all checks, got %v", p.Checks)
+	}
+	if _, e = NewProfile(pid, "Unknown"); e == nil {
+		t.Error("expected error for unknown profile")
+	}
+	c, e := NewConfig(pid, []*Check{&Check{Name: "LineLength", Severity: WARNING, Properties: []*Property{&Property{Name: "max", Value: "100"}}}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if c.Value("LineLength", "max") != "100" || c.Value("JavadocMethod", "scope") != "private" || c.Severity("LineLength") != WARNING {
+		t.Errorf("invalid config %v", c.Checks)
+	}
+	if checks[2].Properties[0].Value != "80" {
+		t.Error("available checks should not be modified")
+	}
+	for _, k := range []*Check{&Check{Name: "Unknown"}, &Check{Name: "LineLength", Severity: "fatal"}, &Check{Name: "LineLength", Properties: []*Property{&Property{Name: "min"}}}} {
+		if _, e = NewConfig(pid, []*Check{k}); e == nil {
+			t.Errorf("expected error for %v", k)
+		}
+	}
+	d, e := c.XML()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Contains(d, []byte(`<property name="max" value="100"></property>`)) {
+		t.Errorf("invalid xml %s", d)
+	}
+	cs, e := parseChecks(d)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(cs) != 1 || cs[0].Parent != TREE_WALKER || cs[0].Severity != WARNING {
+		t.Errorf("invalid checks %v", cs)
+	}
+}
+
+var cfg = []byte(`<?xml version="1.0"?>
+<!DOCTYPE module PUBLIC
+"-//Puppy Crawl//DTD Check Configuration 1.1//EN"
+"http://www.puppycrawl.com/dtds/configuration_1_1.dtd">
+<module name="Checker">
+  <module name="FileTabCharacter">
+    <property name="eachLine" value="false"/>
+  </module>
+  <module name="TreeWalker">
+    <module name="JavadocMethod">
+      <property name="scope" value="private"/>
+    </module>
+    <module name ="LineLength">
+      <property name="max" value="80"/>
+    </module>
+  </module>
+</module>
+`)
+
 var file = []byte(`
 package triangle;
 public class Triangle {
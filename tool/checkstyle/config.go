@@ -0,0 +1,356 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package checkstyle
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/godfried/impendulo/config"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"os"
+)
+
+type (
+	//Property is a property of a Checkstyle check such as a maximum or a format.
+	Property struct {
+		Name  string `xml:"name,attr" bson:"name"`
+		Value string `xml:"value,attr" bson:"value"`
+	}
+	//Check is a single Checkstyle module which can be enabled for a project.
+	//Parent is the module the check belongs to, either Checker or TreeWalker.
+	Check struct {
+		Name       string      `bson:"name"`
+		Parent     string      `bson:"parent"`
+		Severity   string      `bson:"severity"`
+		Properties []*Property `bson:"properties"`
+	}
+	//Config specifies the Checkstyle checks configured for a specific project.
+	Config struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Profile   string        `bson:"profile"`
+		Checks    []*Check      `bson:"checks"`
+	}
+	//module is used to read and write Checkstyle's xml configuration format.
+	module struct {
+		XMLName    xml.Name    `xml:"module"`
+		Name       string      `xml:"name,attr"`
+		Properties []*Property `xml:"property"`
+		Modules    []*module   `xml:"module"`
+	}
+)
+
+const (
+	CHECKER     = "Checker"
+	TREE_WALKER = "TreeWalker"
+	SEVERITY    = "severity"
+	INFO        = "info"
+	WARNING     = "warning"
+	ERROR       = "error"
+	IGNORE      = "ignore"
+	ALL         = "All"
+	SUN         = "Sun"
+	NOVICE      = "Novice"
+	HEADER      = `<?xml version="1.0"?>
+<!DOCTYPE module PUBLIC "-//Puppy Crawl//DTD Check Configuration 1.3//EN" "http://www.puppycrawl.com/dtds/configuration_1_3.dtd">
+`
+)
+
+var (
+	//checks caches the available checks. checksLock guards it.
+	checks     []*Check
+	checksLock sync.Mutex
+	//profiles are the built-in selections of checks. A nil selection enables every check.
+	profiles = map[string][]string{
+		ALL: nil,
+		SUN: []string{
+			"NewlineAtEndOfFile", "Translation", "FileLength", "FileTabCharacter",
+			"JavadocMethod", "JavadocType", "JavadocVariable", "JavadocStyle",
+			"ConstantName", "LocalFinalVariableName", "LocalVariableName", "MemberName",
+			"MethodName", "PackageName", "ParameterName", "StaticVariableName", "TypeName",
+			"AvoidStarImport", "IllegalImport", "RedundantImport", "UnusedImports",
+			"LineLength", "MethodLength", "ParameterNumber", "EmptyForIteratorPad",
+			"GenericWhitespace", "MethodParamPad", "NoWhitespaceAfter", "NoWhitespaceBefore",
+			"OperatorWrap", "ParenPad", "TypecastParenPad", "WhitespaceAfter", "WhitespaceAround",
+			"ModifierOrder", "RedundantModifier", "AvoidNestedBlocks", "EmptyBlock", "LeftCurly",
+			"NeedBraces", "RightCurly", "EmptyStatement", "EqualsHashCode", "HiddenField",
+			"IllegalInstantiation", "InnerAssignment", "MagicNumber", "MissingSwitchDefault",
+			"SimplifyBooleanExpression", "SimplifyBooleanReturn", "DesignForExtension",
+			"FinalClass", "HideUtilityClassConstructor", "InterfaceIsType", "VisibilityModifier",
+			"ArrayTypeStyle", "FinalParameters", "TodoComment", "UpperEll",
+		},
+		NOVICE: []string{
+			"FileTabCharacter", "ConstantName", "LocalVariableName", "MemberName", "MethodName",
+			"ParameterName", "StaticVariableName", "TypeName", "UnusedImports", "LineLength",
+			"MethodLength", "EmptyBlock", "NeedBraces", "EmptyStatement", "EqualsHashCode",
+			"InnerAssignment", "MissingSwitchDefault", "DefaultComesLast", "FallThrough",
+			"SimplifyBooleanExpression", "SimplifyBooleanReturn", "StringLiteralEquality",
+			"MultipleVariableDeclarations", "OneStatementPerLine", "CyclomaticComplexity",
+			"NestedIfDepth", "UpperEll",
+		},
+	}
+)
+
+//Checks loads the available checks from the Checkstyle configuration file
+//which can be set via the config file.
+func Checks() ([]*Check, error) {
+	checksLock.Lock()
+	defer checksLock.Unlock()
+	if checks != nil {
+		return checks, nil
+	}
+	cp, e := config.CHECKSTYLE_CFG.Path()
+	if e != nil {
+		return nil, e
+	}
+	f, e := os.Open(cp)
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+	cs, e := parseChecks(util.ReadBytes(f))
+	if e != nil {
+		return nil, e
+	}
+	checks = cs
+	return checks, nil
+}
+
+//parseChecks reads the checks present in a Checkstyle xml configuration.
+func parseChecks(d []byte) ([]*Check, error) {
+	var m module
+	if e := xml.Unmarshal(d, &m); e != nil {
+		return nil, e
+	}
+	if m.Name != CHECKER {
+		return nil, fmt.Errorf("invalid checkstyle configuration root %s", m.Name)
+	}
+	cs := make([]*Check, 0, len(m.Modules))
+	for _, c := range m.Modules {
+		if c.Name != TREE_WALKER {
+			cs = append(cs, newCheck(c, CHECKER))
+			continue
+		}
+		for _, t := range c.Modules {
+			cs = append(cs, newCheck(t, TREE_WALKER))
+		}
+	}
+	return cs, nil
+}
+
+//newCheck converts a module into a Check, separating its severity from its other properties.
+func newCheck(m *module, p string) *Check {
+	c := &Check{Name: strings.TrimSpace(m.Name), Parent: p, Properties: make([]*Property, 0, len(m.Properties))}
+	for _, p := range m.Properties {
+		if p.Name == SEVERITY {
+			c.Severity = p.Value
+			continue
+		}
+		c.Properties = append(c.Properties, &Property{Name: p.Name, Value: strings.Join(strings.Fields(p.Value), " ")})
+	}
+	return c
+}
+
+//Property retrieves the property named n of this check.
+func (c *Check) Property(n string) (*Property, error) {
+	for _, p := range c.Properties {
+		if p.Name == n {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown property %s for check %s", n, c.Name)
+}
+
+//Profiles lists the names of the built-in Checkstyle profiles.
+func Profiles() []string {
+	ps := make([]string, 0, len(profiles))
+	for p := range profiles {
+		ps = append(ps, p)
+	}
+	sort.Strings(ps)
+	return ps
+}
+
+//Severities lists the severities a check can be configured with.
+func Severities() []string {
+	return []string{INFO, WARNING, ERROR, IGNORE}
+}
+
+//NewProfile creates a new Config for a project from a built-in profile.
+//Each check in the profile uses its default properties. An error is returned
+//if any of the profile's checks are not available.
+func NewProfile(pid bson.ObjectId, n string) (*Config, error) {
+	p, ok := profiles[n]
+	if !ok {
+		return nil, fmt.Errorf("unknown checkstyle profile %s", n)
+	}
+	cs, e := Checks()
+	if e != nil {
+		return nil, e
+	}
+	var m []string
+	for _, k := range p {
+		if _, e := findCheck(cs, k); e != nil {
+			m = append(m, k)
+		}
+	}
+	if len(m) > 0 {
+		return nil, fmt.Errorf("checkstyle profile %s uses unavailable checks %s", n, strings.Join(m, ", "))
+	}
+	s := make([]*Check, 0, len(cs))
+	for _, c := range cs {
+		if p == nil || contains(p, c.Name) {
+			s = append(s, copyCheck(c))
+		}
+	}
+	return &Config{Id: bson.NewObjectId(), ProjectId: pid, Profile: n, Checks: s}, nil
+}
+
+//NewConfig creates a new Config for a project from a set of checks.
+//Each check, its severity and its properties are validated against the available checks.
+func NewConfig(pid bson.ObjectId, cs []*Check) (*Config, error) {
+	a, e := Checks()
+	if e != nil {
+		return nil, e
+	}
+	s := make([]*Check, 0, len(cs))
+	for _, c := range cs {
+		d, e := findCheck(a, c.Name)
+		if e != nil {
+			return nil, e
+		}
+		if c.Severity != "" && !contains(Severities(), c.Severity) {
+			return nil, fmt.Errorf("invalid severity %s for check %s", c.Severity, c.Name)
+		}
+		n := copyCheck(d)
+		n.Severity = c.Severity
+		for _, p := range c.Properties {
+			dp, e := n.Property(p.Name)
+			if e != nil {
+				return nil, e
+			}
+			dp.Value = strings.TrimSpace(p.Value)
+		}
+		s = append(s, n)
+	}
+	return &Config{Id: bson.NewObjectId(), ProjectId: pid, Checks: s}, nil
+}
+
+//findCheck searches cs for the check named n.
+func findCheck(cs []*Check, n string) (*Check, error) {
+	for _, c := range cs {
+		if c.Name == n {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown checkstyle check %s", n)
+}
+
+//contains is whether s is present in a.
+func contains(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+//copyCheck creates a deep copy of c so that the available checks are not modified.
+func copyCheck(c *Check) *Check {
+	n := &Check{Name: c.Name, Parent: c.Parent, Severity: c.Severity, Properties: make([]*Property, len(c.Properties))}
+	for i, p := range c.Properties {
+		n.Properties[i] = &Property{Name: p.Name, Value: p.Value}
+	}
+	return n
+}
+
+//Check retrieves the configured check named n.
+func (c *Config) Check(n string) (*Check, error) {
+	return findCheck(c.Checks, n)
+}
+
+//Enabled is whether the check named n is part of this configuration.
+func (c *Config) Enabled(n string) bool {
+	_, e := c.Check(n)
+	return e == nil
+}
+
+//Severity retrieves the severity configured for the check named n.
+func (c *Config) Severity(n string) string {
+	if k, e := c.Check(n); e == nil {
+		return k.Severity
+	}
+	return ""
+}
+
+//Value retrieves the value of property p configured for the check named n.
+//If the check is not enabled the default value is used.
+func (c *Config) Value(n, p string) string {
+	k, e := c.Check(n)
+	if e != nil {
+		cs, e := Checks()
+		if e != nil {
+			return ""
+		}
+		if k, e = findCheck(cs, n); e != nil {
+			return ""
+		}
+	}
+	if v, e := k.Property(p); e == nil {
+		return v.Value
+	}
+	return ""
+}
+
+//XML generates the Checkstyle xml configuration file for this configuration.
+func (c *Config) XML() ([]byte, error) {
+	t := &module{Name: TREE_WALKER}
+	r := &module{Name: CHECKER}
+	for _, k := range c.Checks {
+		m := &module{Name: k.Name, Properties: k.Properties}
+		if k.Severity != "" {
+			m.Properties = append([]*Property{&Property{Name: SEVERITY, Value: k.Severity}}, k.Properties...)
+		}
+		if k.Parent == CHECKER {
+			r.Modules = append(r.Modules, m)
+		} else {
+			t.Modules = append(t.Modules, m)
+		}
+	}
+	r.Modules = append(r.Modules, t)
+	d, e := xml.MarshalIndent(r, "", "  ")
+	if e != nil {
+		return nil, e
+	}
+	return bytes.Join([][]byte{[]byte(HEADER), d}, nil), nil
+}
@@ -25,12 +25,12 @@
 package checkstyle
 
 import (
-	"encoding/gob"
 	"encoding/xml"
 	"fmt"
 
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 	"github.com/godfried/impendulo/util"
 
 	"html/template"
@@ -72,10 +72,6 @@ type (
 	}
 )
 
-func init() {
-	gob.Register(new(Report))
-}
-
 //NewReport
 func NewReport(id bson.ObjectId, data []byte) (res *Report, err error) {
 	if err = xml.Unmarshal(data, &res); err != nil {
@@ -103,6 +99,33 @@ func (r *Report) Lines() []*result.Line {
 	return lines
 }
 
+//SARIF converts the errors found in the file named n into SARIF results.
+func (r *Report) SARIF(n string) []*sarif.Result {
+	f := r.File(n)
+	if f == nil {
+		return []*sarif.Result{}
+	}
+	rs := make([]*sarif.Result, 0, len(f.Errors))
+	for _, e := range f.Errors {
+		for _, l := range e.Lines {
+			sr := sarif.NewResult(e.Source, sarif.Level(e.Severity), string(e.Message), l, l)
+			sr.SetProperty(result.CATEGORY, category(e.Source))
+			rs = append(rs, sr)
+		}
+	}
+	return rs
+}
+
+//category determines the issue category of a Checkstyle check from its package.
+func category(source string) string {
+	for _, p := range []string{".design.", ".metrics.", ".sizes."} {
+		if strings.Contains(source, p) {
+			return result.DESIGN
+		}
+	}
+	return result.STYLE
+}
+
 //File
 func (r *Report) File(name string) *File {
 	for _, f := range r.Files {
@@ -29,6 +29,7 @@ import (
 
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 	"labix.org/v2/mgo/bson"
 )
 
@@ -128,3 +129,8 @@ func NewResult(fileId bson.ObjectId, data []byte) (*Result, error) {
 		Report: r,
 	}, nil
 }
+
+//SARIF
+func (r *Result) SARIF(n string) []*sarif.Result {
+	return r.Report.SARIF(n)
+}
@@ -44,16 +44,19 @@ type (
 	//Tool is an implementation of tool.Tool which allows
 	//us to run Checkstyle on a Java class.
 	Tool struct {
-		java string
-		cmd  string
-		cfg  string
+		java   string
+		cmd    string
+		cfg    string
+		config *Config
 	}
 )
 
 //New creates a new instance of the checkstyle Tool.
+//If c is nil the configured Checkstyle configuration file is used,
+//otherwise a configuration file is generated from c.
 //Any errors returned will of type config.ConfigError.
-func New() (tool *Tool, err error) {
-	tool = new(Tool)
+func New(c *Config) (tool *Tool, err error) {
+	tool = &Tool{config: c}
 	tool.java, err = config.JAVA.Path()
 	if err != nil {
 		return
@@ -76,11 +79,23 @@ func (this *Tool) Name() string {
 	return NAME
 }
 
-//Run runs checkstyle on the provided Java file. We make use of the configured Checkstyle configuration file.
+//Run runs checkstyle on the provided Java file. We make use of the project's Checkstyle configuration
+//if there is one and the configured Checkstyle configuration file otherwise.
 //Output is written to an xml file which is then read in and used to create a Checkstyle Result.
 func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
+	c := t.cfg
+	if t.config != nil {
+		d, e := t.config.XML()
+		if e != nil {
+			return nil, e
+		}
+		if c, e = util.SaveTemp(d); e != nil {
+			return nil, e
+		}
+		defer os.Remove(c)
+	}
 	o := filepath.Join(target.Dir, "checkstyle.xml")
-	a := []string{t.java, "-jar", t.cmd, "-f", "xml", "-c", t.cfg, "-o", o, "-r", target.Dir}
+	a := []string{t.java, "-jar", t.cmd, "-f", "xml", "-c", c, "-o", o, "-r", target.Dir}
 	defer os.Remove(o)
 	r, re := tool.RunCommand(a, nil, 30*time.Second)
 	rf, e := os.Open(o)
@@ -0,0 +1,142 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package diagnostic
+
+import (
+	"github.com/godfried/impendulo/tool"
+)
+
+//defaults are the built-in explanations of common novice mistakes for each language.
+var defaults = map[tool.Language][]*Explanation{
+	tool.JAVA: []*Explanation{
+		builtin(tool.JAVA, "undefined-symbol", `cannot find symbol`,
+			"You used a name (a variable, method or class) which Java does not know about at this point.",
+			"Check the spelling and capitalisation, that the variable is declared before it is used and in the same block, and that the class has been imported."),
+		builtin(tool.JAVA, "missing-semicolon", `^';' expected`,
+			"Java expects every statement to end with a semicolon.",
+			"Add a ';' at the end of the statement. The mistake is often on the line before the one reported."),
+		builtin(tool.JAVA, "unbalanced-braces", `reached end of file while parsing`,
+			"Java reached the end of the file while a class or method was still open.",
+			"Count your curly braces: every '{' needs a matching '}'."),
+		builtin(tool.JAVA, "misplaced-code", `class, interface, (or )?enum,? (or record )?expected`,
+			"There is code outside of a class, usually because a method was closed too early.",
+			"Look for an extra '}' above this line or a method written outside the class."),
+		builtin(tool.JAVA, "missing-parenthesis", `^'[()\[\]]' expected`,
+			"A bracket or parenthesis is missing.",
+			"Make sure every '(' has a matching ')' and every '[' a matching ']'."),
+		builtin(tool.JAVA, "illegal-start", `illegal start of (expression|type)`,
+			"Java found something it did not expect at the start of an expression or declaration.",
+			"Check for a missing '}' or ';' on the previous lines, or a method declared inside another method."),
+		builtin(tool.JAVA, "type-mismatch", `incompatible types`,
+			"A value of one type is used where a different type is required.",
+			"Compare the required and found types. You may need a conversion such as Integer.parseInt or a cast."),
+		builtin(tool.JAVA, "lossy-conversion", `possible (lossy conversion|loss of precision)`,
+			"Storing this value in a smaller type could lose information, for example a double in an int.",
+			"Use a cast such as (int) if you really want to drop the extra information, or change the variable's type."),
+		builtin(tool.JAVA, "missing-return", `missing return statement`,
+			"This method promises to return a value but there is a way to reach its end without returning one.",
+			"Make sure every path through the method, including the end of every if/else and loop, ends with a return."),
+		builtin(tool.JAVA, "uninitialised-variable", `might not have been initiali[sz]ed`,
+			"The variable is used before it has definitely been given a value.",
+			"Give the variable a value when you declare it or on every path before it is used."),
+		builtin(tool.JAVA, "unreachable-code", `unreachable statement`,
+			"This statement can never run, usually because it follows a return, break or continue.",
+			"Remove the statement or move it before the return, break or continue."),
+		builtin(tool.JAVA, "duplicate-declaration", `is already defined in`,
+			"The same name is declared twice in the same scope.",
+			"Rename one of them, or remove the type in front of the second use if you meant to reuse the variable."),
+		builtin(tool.JAVA, "static-context", `non-static (variable|method) .* cannot be referenced from a static context`,
+			"A static method such as main cannot use instance variables or methods directly.",
+			"Create an object and use it, or make the variable or method static."),
+		builtin(tool.JAVA, "wrong-arguments", `cannot be applied to (given types|\()`,
+			"A method or constructor was called with the wrong number or types of arguments.",
+			"Compare the arguments you passed with the required parameters shown in the message."),
+		builtin(tool.JAVA, "unreported-exception", `unreported exception .* must be caught or declared to be thrown`,
+			"This code can throw a checked exception which is neither caught nor declared.",
+			"Surround the code with try/catch or add 'throws' and the exception to the method's declaration."),
+		builtin(tool.JAVA, "wrong-file-name", `is public, should be declared in a file named`,
+			"A public class must be saved in a file with exactly the same name.",
+			"Rename the file or the class so that they match, including capitalisation."),
+		builtin(tool.JAVA, "else-without-if", `'else' without 'if'`,
+			"Java found an else which does not belong to an if.",
+			"Check for a ';' directly after the if's condition or missing braces around the if's statements."),
+		builtin(tool.JAVA, "bad-operands", `bad operand types? for (binary|unary) operator`,
+			"The operator cannot be used with these types, for example comparing a String with ==.",
+			"Check the types on both sides of the operator. Compare Strings with equals and booleans with && or ||."),
+		builtin(tool.JAVA, "unclosed-string", `unclosed (string|character) literal`,
+			"A string or character is missing its closing quote.",
+			"Add the closing '\"' or '''. Strings cannot continue onto the next line."),
+	},
+	tool.C: []*Explanation{
+		builtin(tool.C, "undeclared-identifier", `undeclared`,
+			"You used a name which has not been declared at this point.",
+			"Check the spelling, declare the variable before using it and include the header which declares it."),
+		builtin(tool.C, "implicit-declaration", `implicit declaration of function`,
+			"You called a function before the compiler has seen its declaration.",
+			"Include the right header file, for example stdio.h or stdlib.h, or add a prototype before the call."),
+		builtin(tool.C, "missing-semicolon", `expected ['‘];['’]`,
+			"C expects every statement to end with a semicolon.",
+			"Add a ';' at the end of the statement. The mistake is often on the line before the one reported."),
+		builtin(tool.C, "unbalanced-braces", `expected declaration or statement at end of input`,
+			"The compiler reached the end of the file while a block was still open.",
+			"Count your curly braces: every '{' needs a matching '}'."),
+		builtin(tool.C, "missing-return", `control reaches end of non-void function`,
+			"This function promises to return a value but can reach its end without returning one.",
+			"Make sure every path through the function ends with a return statement."),
+		builtin(tool.C, "uninitialised-variable", `(is|may be) used uninitiali[sz]ed`,
+			"The variable is used before it has been given a value, so it contains garbage.",
+			"Give the variable a value when you declare it."),
+		builtin(tool.C, "format-mismatch", `format ['‘].*['’] expects`,
+			"The printf or scanf format does not match the type of the argument.",
+			"Use %d for int, %ld for long, %f for double in printf (%lf in scanf), %c for char and %s for strings. Remember the & in scanf."),
+		builtin(tool.C, "type-mismatch", `incompatible (types|pointer type)|makes (integer from pointer|pointer from integer)`,
+			"A value of one type is used where a different type is required.",
+			"Check whether you need a pointer or the value it points to, and use & or * accordingly."),
+		builtin(tool.C, "wrong-arguments", `too (few|many) arguments to function`,
+			"A function was called with the wrong number of arguments.",
+			"Compare the call with the function's declaration."),
+		builtin(tool.C, "duplicate-declaration", `redefinition of|conflicting types for`,
+			"The same name is declared twice, or declared differently in two places.",
+			"Rename one of them or make the prototype match the definition."),
+		builtin(tool.C, "assignment-in-condition", `suggest parentheses around assignment used as truth value`,
+			"You assigned a value inside a condition, which is usually a typo for comparison.",
+			"Use == to compare values. A single = assigns."),
+		builtin(tool.C, "signed-comparison", `comparison (between|of integer expressions of different) signed`,
+			"A signed and an unsigned value are compared, which can give surprising results for negative numbers.",
+			"Make both values the same type, for example by declaring loop counters as size_t."),
+		builtin(tool.C, "unused-variable", `unused (variable|parameter)`,
+			"This variable is declared but never used.",
+			"Remove it, or check whether you meant to use it somewhere."),
+	},
+}
+
+//builtin creates a built-in catalogue entry. Built-in patterns are known to be valid.
+func builtin(lang tool.Language, k, pattern, text, hint string) *Explanation {
+	x, e := NewExplanation("", lang, k, pattern, text, hint)
+	if e != nil {
+		panic(e)
+	}
+	return x
+}
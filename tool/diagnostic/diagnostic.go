@@ -0,0 +1,202 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package diagnostic parses compiler output into structured diagnostics and
+//explains them in plain language using a catalogue of common mistakes.
+package diagnostic
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Diagnostic is a single error, warning or note reported by a compiler
+	//along with an explanation of what it means and a hint on how to fix it.
+	Diagnostic struct {
+		File        string `bson:"file"`
+		Line        int    `bson:"line"`
+		Column      int    `bson:"column"`
+		Level       string `bson:"level"`
+		Message     string `bson:"message"`
+		Detail      string `bson:"detail"`
+		Kind        string `bson:"kind"`
+		Explanation string `bson:"explanation"`
+		Hint        string `bson:"hint"`
+	}
+	//Explanation is a catalogue entry which explains the compiler messages matching its pattern.
+	//Built-in entries have no project while teachers' entries belong to a project.
+	Explanation struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid,omitempty"`
+		Lang      tool.Language `bson:"lang"`
+		Kind      string        `bson:"kind"`
+		Pattern   string        `bson:"pattern"`
+		Text      string        `bson:"text"`
+		Hint      string        `bson:"hint"`
+		Time      int64         `bson:"time"`
+		regex     *regexp.Regexp
+	}
+)
+
+const (
+	NAME    = "Explanations"
+	ERROR   = "error"
+	WARNING = "warning"
+	NOTE    = "note"
+)
+
+var (
+	header = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)? (?:fatal )?(error|warning|note): (.*)$`)
+	detail = regexp.MustCompile(`^\s+(symbol|location|required|found|reason):\s*(.*)$`)
+	kind   = regexp.MustCompile(`^[\w-]+$`)
+)
+
+//NewExplanation creates a new catalogue entry for a project. The pattern is a regular
+//expression which is matched against compiler messages.
+func NewExplanation(pid bson.ObjectId, lang tool.Language, k, pattern, text, hint string) (*Explanation, error) {
+	if !tool.Supported(lang) {
+		return nil, fmt.Errorf("unsupported language %s", lang)
+	}
+	if !kind.MatchString(k) {
+		return nil, fmt.Errorf("invalid kind %s", k)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("explanation for %s cannot be empty", k)
+	}
+	x := &Explanation{
+		Id:        bson.NewObjectId(),
+		ProjectId: pid,
+		Lang:      lang,
+		Kind:      k,
+		Pattern:   pattern,
+		Text:      strings.TrimSpace(text),
+		Hint:      strings.TrimSpace(hint),
+		Time:      util.CurMilis(),
+	}
+	if _, e := x.compile(); e != nil {
+		return nil, e
+	}
+	return x, nil
+}
+
+//compile compiles x's pattern once.
+func (x *Explanation) compile() (*regexp.Regexp, error) {
+	if x.regex != nil {
+		return x.regex, nil
+	}
+	if strings.TrimSpace(x.Pattern) == "" {
+		return nil, fmt.Errorf("pattern for %s cannot be empty", x.Kind)
+	}
+	r, e := regexp.Compile(x.Pattern)
+	if e != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %s", x.Pattern, e.Error())
+	}
+	x.regex = r
+	return r, nil
+}
+
+//Matches checks whether x explains the compiler message m.
+func (x *Explanation) Matches(m string) bool {
+	r, e := x.compile()
+	return e == nil && r.MatchString(m)
+}
+
+//Parse extracts the diagnostics from a compiler's output. Indented detail lines
+//such as javac's symbol and location lines are added to the preceding diagnostic.
+func Parse(data []byte) []*Diagnostic {
+	ds := make([]*Diagnostic, 0, 10)
+	var c *Diagnostic
+	for _, l := range bytes.Split(data, []byte("\n")) {
+		s := strings.TrimRight(string(l), "\r")
+		if m := header.FindStringSubmatch(s); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			col, _ := strconv.Atoi(m[3])
+			c = &Diagnostic{File: m[1], Line: n, Column: col, Level: m[4], Message: strings.TrimSpace(m[5])}
+			ds = append(ds, c)
+		} else if m := detail.FindStringSubmatch(s); m != nil && c != nil {
+			if c.Detail != "" {
+				c.Detail += "; "
+			}
+			c.Detail += m[1] + ": " + strings.Join(strings.Fields(m[2]), " ")
+		}
+	}
+	return ds
+}
+
+//Explain adds the explanation of the first matching catalogue entry to each diagnostic.
+func Explain(ds []*Diagnostic, c []*Explanation) []*Diagnostic {
+	for _, d := range ds {
+		for _, x := range c {
+			if x.Matches(d.Message) {
+				d.Kind = x.Kind
+				d.Explanation = x.Text
+				d.Hint = x.Hint
+				break
+			}
+		}
+	}
+	return ds
+}
+
+//Catalogue combines a project's entries with the built-in entries for a language.
+//The project's entries take precedence.
+func Catalogue(lang tool.Language, xs []*Explanation) []*Explanation {
+	return append(append(make([]*Explanation, 0, len(xs)+len(defaults[lang])), xs...), Defaults(lang)...)
+}
+
+//Defaults retrieves the built-in catalogue entries for a language.
+func Defaults(lang tool.Language) []*Explanation {
+	return defaults[lang]
+}
+
+//Title is a short description of the diagnostic used when displaying it next to the code.
+func (d *Diagnostic) Title() string {
+	if d.Kind != "" {
+		return util.Title(d.Level) + ": " + strings.Replace(d.Kind, "-", " ", -1)
+	}
+	return util.Title(d.Level)
+}
+
+//Description combines the diagnostic's message with its explanation and hint.
+func (d *Diagnostic) Description() string {
+	s := d.Message
+	if d.Detail != "" {
+		s += " (" + d.Detail + ")"
+	}
+	if d.Explanation != "" {
+		s += ". " + d.Explanation
+	}
+	if d.Hint != "" {
+		s += " Hint: " + d.Hint
+	}
+	return s
+}
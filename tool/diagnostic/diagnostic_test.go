@@ -0,0 +1,116 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package diagnostic
+
+import (
+	"github.com/godfried/impendulo/tool"
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	ds := Parse(javacOutput)
+	if len(ds) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(ds))
+	}
+	if d := ds[0]; d.File != "/tmp/src/triangle/Triangle.java" || d.Line != 5 || d.Column != 0 || d.Level != ERROR || d.Message != "cannot find symbol" || d.Detail != "symbol: variable heigth; location: class Triangle" {
+		t.Errorf("invalid diagnostic %+v", d)
+	}
+	if d := ds[1]; d.Line != 9 || d.Level != WARNING || d.Detail != "" {
+		t.Errorf("invalid diagnostic %+v", d)
+	}
+	ds = Parse(gccOutput)
+	if len(ds) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(ds))
+	}
+	if d := ds[0]; d.File != "main.c" || d.Line != 4 || d.Column != 3 || d.Level != WARNING {
+		t.Errorf("invalid diagnostic %+v", d)
+	}
+	if d := ds[1]; d.Line != 6 || d.Column != 10 || d.Level != ERROR || d.Message != "‘count’ undeclared (first use in this function)" {
+		t.Errorf("invalid diagnostic %+v", d)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	ds := Explain(Parse(javacOutput), Defaults(tool.JAVA))
+	if ds[0].Kind != "undefined-symbol" || ds[0].Explanation == "" || ds[0].Hint == "" {
+		t.Errorf("invalid explanation %+v", ds[0])
+	}
+	if ds[1].Kind != "" {
+		t.Errorf("expected no explanation for %+v", ds[1])
+	}
+	ds = Explain(Parse(gccOutput), Defaults(tool.C))
+	if ds[0].Kind != "implicit-declaration" || ds[1].Kind != "undeclared-identifier" {
+		t.Errorf("invalid explanations %+v %+v", ds[0], ds[1])
+	}
+	x, e := NewExplanation(bson.NewObjectId(), tool.JAVA, "misspelt-height", `variable heigth`, "Height is spelt with an h at the end.", "")
+	if e != nil {
+		t.Fatal(e)
+	}
+	ds = Parse(javacOutput)
+	ds[0].Message += " variable heigth"
+	Explain(ds, Catalogue(tool.JAVA, []*Explanation{x}))
+	if ds[0].Kind != "misspelt-height" {
+		t.Errorf("expected project explanation to take precedence, got %s", ds[0].Kind)
+	}
+	if ds[0].Title() != "Error: misspelt height" {
+		t.Errorf("invalid title %s", ds[0].Title())
+	}
+}
+
+func TestNewExplanation(t *testing.T) {
+	pid := bson.NewObjectId()
+	for _, a := range [][]string{
+		{"Python", "kind", "pattern", "text"},
+		{"Java", "a kind", "pattern", "text"},
+		{"Java", "kind", "(", "text"},
+		{"Java", "kind", "", "text"},
+		{"Java", "kind", "pattern", " "},
+	} {
+		if _, e := NewExplanation(pid, tool.Language(a[0]), a[1], a[2], a[3], ""); e == nil {
+			t.Errorf("expected error for %v", a)
+		}
+	}
+}
+
+var javacOutput = []byte(`/tmp/src/triangle/Triangle.java:5: error: cannot find symbol
+		int h = heigth - 2;
+		        ^
+  symbol:   variable heigth
+  location: class Triangle
+/tmp/src/triangle/Triangle.java:9: warning: [unchecked] unchecked call to add(E) as a member of the raw type List
+		l.add(h);
+		     ^
+1 error
+1 warning`)
+
+var gccOutput = []byte(`main.c: In function ‘main’:
+main.c:4:3: warning: implicit declaration of function ‘printf’ [-Wimplicit-function-declaration]
+   printf("%d", 1);
+   ^
+main.c:6:10: error: ‘count’ undeclared (first use in this function)
+   return count;
+          ^`)
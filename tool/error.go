@@ -27,6 +27,8 @@ package tool
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"syscall"
 )
 
 type (
@@ -96,6 +98,24 @@ func IsEndError(e error) bool {
 	return false
 }
 
+//ExitCode retrieves the exit status of the command which caused e.
+//ok is false if e is not an EndError caused by a non-zero exit status.
+func ExitCode(e error) (int, bool) {
+	ee, ok := e.(*EndError)
+	if !ok {
+		return 0, false
+	}
+	x, ok := ee.err.(*exec.ExitError)
+	if !ok {
+		return 0, false
+	}
+	w, ok := x.Sys().(syscall.WaitStatus)
+	if !ok {
+		return 0, false
+	}
+	return w.ExitStatus(), true
+}
+
 //Error
 func (t *TimeoutError) Error() string {
 	return fmt.Sprintf("command %q timed out", t.args)
@@ -0,0 +1,197 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package external
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"regexp"
+	"strings"
+)
+
+type (
+	//Config describes how an external tool is run for a project and
+	//how its output should be interpreted and displayed.
+	Config struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Name      string        `bson:"name"`
+		Lang      string        `bson:"lang"`
+		Time      int64         `bson:"time"`
+		//Command is the command line template used to run the tool.
+		//See Args for the placeholders it may contain.
+		Command string `bson:"command"`
+		Format  Format `bson:"format"`
+		//Pattern is the regular expression used to find issues in TEXT output.
+		Pattern string `bson:"pattern"`
+		Chart   Chart  `bson:"chart"`
+		//Timeout is the maximum number of seconds the tool may run for.
+		Timeout int `bson:"timeout"`
+	}
+
+	//Format is the format of an external tool's output.
+	Format string
+
+	//Chart specifies how an external tool's results are charted.
+	Chart string
+)
+
+const (
+	//CHECKSTYLE output is Checkstyle XML.
+	CHECKSTYLE Format = "checkstyle"
+	//JUNIT output is JUnit XML.
+	JUNIT Format = "junit"
+	//TEXT output is plain text in which issues are matched by a regular expression.
+	TEXT Format = "text"
+	//SARIF output is a SARIF log.
+	SARIF Format = "sarif"
+
+	//TOTAL charts the total number of issues found.
+	TOTAL Chart = "total"
+	//SEVERITY charts the number of issues found for each severity.
+	SEVERITY Chart = "severity"
+	//NONE means the tool's results are not charted.
+	NONE Chart = "none"
+
+	//Command placeholders.
+	FILE     = "{file}"
+	DIR      = "{dir}"
+	PKG_DIR  = "{pkgdir}"
+	FILENAME = "{name}"
+	CLASS    = "{class}"
+	CP       = "{cp}"
+	OUTPUT   = "{output}"
+
+	DEFAULT_TIMEOUT = 30
+)
+
+var (
+	validName = regexp.MustCompile(`^\w+$`)
+)
+
+//Formats retrieves the supported output formats.
+func Formats() []Format {
+	return []Format{CHECKSTYLE, JUNIT, SARIF, TEXT}
+}
+
+//Charts retrieves the supported chart types.
+func Charts() []Chart {
+	return []Chart{TOTAL, SEVERITY, NONE}
+}
+
+//Placeholders retrieves the placeholders which can be used in a Config's command.
+func Placeholders() []string {
+	return []string{FILE, DIR, PKG_DIR, FILENAME, CLASS, CP, OUTPUT}
+}
+
+//NewConfig creates a new external tool Config for project pid. An error is
+//returned if the configuration is invalid.
+func NewConfig(pid bson.ObjectId, name, lang, cmd string, f Format, pattern string, c Chart, timeout int) (*Config, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid tool name %q, only letters, digits and underscores are allowed", name)
+	}
+	if !tool.Supported(tool.Language(lang)) {
+		return nil, fmt.Errorf("unsupported language %s", lang)
+	}
+	if len(strings.Fields(cmd)) == 0 {
+		return nil, fmt.Errorf("no command specified for %s", name)
+	}
+	switch f {
+	case CHECKSTYLE, JUNIT, SARIF:
+	case TEXT:
+		if _, e := Matcher(pattern); e != nil {
+			return nil, e
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format %s", f)
+	}
+	switch c {
+	case TOTAL, SEVERITY, NONE:
+	default:
+		return nil, fmt.Errorf("unsupported chart type %s", c)
+	}
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	return &Config{
+		Id:        bson.NewObjectId(),
+		ProjectId: pid,
+		Name:      name,
+		Lang:      lang,
+		Time:      util.CurMilis(),
+		Command:   cmd,
+		Format:    f,
+		Pattern:   pattern,
+		Chart:     c,
+		Timeout:   timeout,
+	}, nil
+}
+
+//Matcher compiles an issue pattern. The pattern must contain a named group
+//called message and may contain groups called line, severity, rule, category and file.
+func Matcher(pattern string) (*regexp.Regexp, error) {
+	r, e := regexp.Compile(pattern)
+	if e != nil {
+		return nil, e
+	}
+	for _, n := range r.SubexpNames() {
+		if n == "message" {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("pattern %q has no message group", pattern)
+}
+
+//Args creates the arguments used to run the tool on target by replacing
+//the placeholders in the Config's command. Arguments are separated by
+//whitespace before the placeholders are replaced so paths may contain spaces.
+//The placeholders are:
+//
+//{file}: the target's path.
+//{dir}: the target's root directory.
+//{pkgdir}: the target's package directory.
+//{name}: the target's file name.
+//{class}: the target's fully qualified name.
+//{cp}: the classpath.
+//{output}: the path of the file the tool should write its output to.
+func (c *Config) Args(target *tool.Target, cp, output string) []string {
+	r := strings.NewReplacer(FILE, target.FilePath(), DIR, target.Dir, PKG_DIR, target.PackagePath(),
+		FILENAME, target.FullName(), CLASS, target.Executable(), CP, cp, OUTPUT, output)
+	a := strings.Fields(c.Command)
+	for i, s := range a {
+		a[i] = r.Replace(s)
+	}
+	return a
+}
+
+//String
+func (c *Config) String() string {
+	return fmt.Sprintf("Id: %q; ProjectId: %q; Name: %s; Lang: %s; Command: %s; Format: %s; Pattern: %s; Chart: %s; Timeout: %d",
+		c.Id, c.ProjectId, c.Name, c.Lang, c.Command, c.Format, c.Pattern, c.Chart, c.Timeout)
+}
@@ -0,0 +1,162 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package external
+
+import (
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/result"
+	"labix.org/v2/mgo/bson"
+
+	"reflect"
+	"testing"
+)
+
+func TestNewConfig(t *testing.T) {
+	pid := bson.NewObjectId()
+	if _, e := NewConfig(pid, "lint", "Java", "lint {file}", TEXT, `(?P<line>\d+): (?P<message>.*)`, TOTAL, 0); e != nil {
+		t.Error(e)
+	}
+	invalid := []struct {
+		name, cmd, pattern string
+		format             Format
+		chart              Chart
+	}{
+		{"lint-1", "lint {file}", "", CHECKSTYLE, TOTAL},
+		{"lint", "  ", "", CHECKSTYLE, TOTAL},
+		{"lint", "lint {file}", `(?P<line>\d+)`, TEXT, TOTAL},
+		{"lint", "lint {file}", "", "xml", TOTAL},
+		{"lint", "lint {file}", "", JUNIT, "pie"},
+	}
+	for _, i := range invalid {
+		if _, e := NewConfig(pid, i.name, "Java", i.cmd, i.format, i.pattern, i.chart, 0); e == nil {
+			t.Errorf("Expected error for %v.", i)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	c := &Config{Command: "lint -cp {cp} -o {output} --class={class} {pkgdir}/{name} {file} {dir}"}
+	target := tool.NewTarget("Triangle.java", "triangle", "/tmp/src dir", tool.JAVA)
+	expected := []string{"lint", "-cp", "/tmp/src dir:junit.jar", "-o", "/tmp/out", "--class=triangle.Triangle",
+		"/tmp/src dir/triangle/Triangle.java", "/tmp/src dir/triangle/Triangle.java", "/tmp/src dir"}
+	if a := c.Args(target, "/tmp/src dir:junit.jar", "/tmp/out"); !reflect.DeepEqual(a, expected) {
+		t.Errorf("Expected %q but got %q.", expected, a)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	tests := []struct {
+		cfg    *Config
+		data   string
+		issues Issues
+		tests  int
+	}{
+		{
+			&Config{Format: TEXT, Pattern: `(?m)^(?P<file>\S+):(?P<line>\d+): (?P<severity>\w+) \[(?P<rule>\w+)\] (?P<message>.*)$`},
+			"Triangle.java:12: WARNING [Unused] unused variable i\nTriangle.java:3: error [Syntax] missing ;\n",
+			Issues{
+				&Issue{Rule: "Syntax", Severity: "error", Category: result.OTHER, Message: "missing ;", File: "Triangle.java", Line: 3},
+				&Issue{Rule: "Unused", Severity: "warning", Category: result.OTHER, Message: "unused variable i", File: "Triangle.java", Line: 12},
+			},
+			0,
+		},
+		{
+			&Config{Format: CHECKSTYLE},
+			`<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0">
+<file name="triangle/Triangle.java">
+<error line="5" column="2" severity="warning" message="Missing a Javadoc comment." source="com.puppycrawl.tools.checkstyle.checks.javadoc.JavadocMethodCheck"/>
+</file>
+</checkstyle>`,
+			Issues{
+				&Issue{Rule: "javadoc.JavadocMethodCheck", Severity: "warning", Category: result.STYLE, Message: "Missing a Javadoc comment.", File: "triangle/Triangle.java", Line: 5},
+			},
+			0,
+		},
+		{
+			&Config{Format: JUNIT},
+			`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite errors="0" failures="1" name="triangle.TriangleTest" tests="2" time="0.01">
+<testcase classname="triangle.TriangleTest" name="testEmpty" time="0.001"/>
+<testcase classname="triangle.TriangleTest" name="testMax" time="0.002">
+<failure message="expected 5" type="junit.framework.AssertionFailedError">trace</failure>
+</testcase>
+</testsuite>`,
+			Issues{
+				&Issue{Rule: "testMax", Severity: "failure", Category: result.TEST, Message: "expected 5", File: "triangle.TriangleTest"},
+			},
+			2,
+		},
+	}
+	tests = append(tests, struct {
+		cfg    *Config
+		data   string
+		issues Issues
+		tests  int
+	}{
+		&Config{Format: SARIF},
+		`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "lint"}}, "results": [
+{"ruleId": "R1", "level": "error", "message": {"text": "bad"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "triangle/Triangle.java"}, "region": {"startLine": 4}}}], "properties": {"category": "security"}},
+{"ruleId": "R2", "message": {"text": "global"}}]}]}`,
+		Issues{
+			&Issue{Rule: "R2", Severity: "warning", Category: result.OTHER, Message: "global"},
+			&Issue{Rule: "R1", Severity: "error", Category: result.SECURITY, Message: "bad", File: "triangle/Triangle.java", Line: 4},
+		},
+		0,
+	})
+	for _, test := range tests {
+		r, e := NewReport(bson.NewObjectId(), test.cfg, []byte(test.data))
+		if e != nil {
+			t.Error(e)
+			continue
+		}
+		if r.Tests != test.tests {
+			t.Errorf("Expected %d tests but got %d.", test.tests, r.Tests)
+		}
+		if !reflect.DeepEqual(r.Issues, test.issues) {
+			t.Errorf("Expected %s but got %s.", test.issues, r.Issues)
+		}
+	}
+}
+
+func TestChartVals(t *testing.T) {
+	c := &Config{Name: "lint", Format: TEXT, Pattern: `(?m)^(?P<severity>\w+): (?P<message>.*)$`, Chart: SEVERITY}
+	r, e := NewResult(bson.NewObjectId(), c, []byte("warning: a\nerror: b\nwarning: c\n"))
+	if e != nil {
+		t.Error(e)
+	}
+	vs := r.ChartVals()
+	if len(vs) != 2 || vs[0].Name != "error" || vs[0].Y != 1 || vs[1].Name != "warning" || vs[1].Y != 2 {
+		t.Errorf("Unexpected chart values %v.", vs)
+	}
+	r.Chart = TOTAL
+	if vs = r.ChartVals(); len(vs) != 1 || vs[0].Y != 3 {
+		t.Errorf("Unexpected chart values %v.", vs)
+	}
+	r.Chart = NONE
+	if vs = r.ChartVals(); len(vs) != 0 {
+		t.Errorf("Unexpected chart values %v.", vs)
+	}
+}
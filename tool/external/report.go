@@ -0,0 +1,266 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package external
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool/checkstyle"
+	"github.com/godfried/impendulo/tool/junit"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	//Report is the result of running an external tool on a source file.
+	//The tool's output is converted into a list of issues regardless of its format.
+	Report struct {
+		Id     bson.ObjectId
+		Format Format
+		//Tests is the number of tests run if the output is JUnit XML.
+		Tests  int
+		Issues Issues
+	}
+
+	//Issue is a single problem reported by an external tool.
+	Issue struct {
+		Rule     string
+		Severity string
+		Category string
+		Message  string
+		File     string
+		Line     int
+	}
+
+	//Issues implements sort.Interface, ordering issues by line.
+	Issues []*Issue
+)
+
+const (
+	UNKNOWN_SEVERITY = "unknown"
+)
+
+//NewReport creates a Report from an external tool's output which is
+//in the format specified by c.
+func NewReport(id bson.ObjectId, c *Config, data []byte) (*Report, error) {
+	var is Issues
+	var t int
+	var e error
+	switch c.Format {
+	case CHECKSTYLE:
+		is, e = checkstyleIssues(data)
+	case JUNIT:
+		is, t, e = junitIssues(data)
+	case SARIF:
+		is, e = sarifIssues(data)
+	case TEXT:
+		is, e = textIssues(c.Pattern, data)
+	default:
+		e = fmt.Errorf("unsupported output format %s", c.Format)
+	}
+	if e != nil {
+		return nil, e
+	}
+	for _, i := range is {
+		if i.Category == "" {
+			i.Category = defaultCategory(c.Format)
+		}
+	}
+	sort.Sort(is)
+	return &Report{Id: id, Format: c.Format, Tests: t, Issues: is}, nil
+}
+
+//defaultCategory is the category of issues reported in format f
+//which do not specify their own.
+func defaultCategory(f Format) string {
+	switch f {
+	case CHECKSTYLE:
+		return result.STYLE
+	case JUNIT:
+		return result.TEST
+	default:
+		return result.OTHER
+	}
+}
+
+//checkstyleIssues extracts issues from Checkstyle XML output.
+func checkstyleIssues(data []byte) (Issues, error) {
+	r, e := checkstyle.NewReport("", data)
+	if e != nil {
+		return nil, e
+	}
+	is := make(Issues, 0, r.Errors)
+	for _, f := range r.Files {
+		for _, ce := range f.Errors {
+			for _, l := range ce.Lines {
+				is = append(is, &Issue{Rule: util.ShortName(ce.Source), Severity: ce.Severity, Message: string(ce.Message), File: f.Name, Line: l})
+			}
+		}
+	}
+	return is, nil
+}
+
+//junitIssues extracts failed test cases from JUnit XML output as issues.
+func junitIssues(data []byte) (Issues, int, error) {
+	r, e := junit.NewReport("", data)
+	if e != nil {
+		return nil, 0, e
+	}
+	is := make(Issues, 0, len(r.Results))
+	for _, tc := range r.Results {
+		if tc.Fail == nil {
+			continue
+		}
+		is = append(is, &Issue{Rule: tc.Name, Severity: "failure", Message: tc.Fail.Message, File: tc.ClassName})
+	}
+	return is, r.Tests, nil
+}
+
+//sarifIssues extracts the results of all runs in a SARIF log as issues.
+func sarifIssues(data []byte) (Issues, error) {
+	l, e := sarif.Parse(data)
+	if e != nil {
+		return nil, e
+	}
+	is := make(Issues, 0, 10)
+	for _, r := range l.Runs {
+		for _, sr := range r.Results {
+			lv := sr.Level
+			if lv == "" {
+				lv = sarif.WARNING
+			}
+			s, _ := sr.Lines()
+			c, _ := sr.Properties[result.CATEGORY].(string)
+			is = append(is, &Issue{Rule: sr.RuleId, Severity: lv, Category: c, Message: sr.Text(), File: sr.Uri(), Line: s})
+		}
+	}
+	return is, nil
+}
+
+//textIssues extracts issues from plain text output using pattern.
+//Each match of the pattern is an issue.
+func textIssues(pattern string, data []byte) (Issues, error) {
+	m, e := Matcher(pattern)
+	if e != nil {
+		return nil, e
+	}
+	ns := m.SubexpNames()
+	ms := m.FindAllStringSubmatch(string(data), -1)
+	is := make(Issues, 0, len(ms))
+	for _, sm := range ms {
+		i := &Issue{Severity: UNKNOWN_SEVERITY}
+		for j, v := range sm {
+			v = strings.TrimSpace(v)
+			switch ns[j] {
+			case "message":
+				i.Message = v
+			case "rule":
+				i.Rule = v
+			case result.CATEGORY:
+				i.Category = strings.ToLower(v)
+			case "file":
+				i.File = v
+			case "severity":
+				if v != "" {
+					i.Severity = strings.ToLower(v)
+				}
+			case "line":
+				i.Line, _ = strconv.Atoi(v)
+			}
+		}
+		is = append(is, i)
+	}
+	return is, nil
+}
+
+//SARIF converts the issues found in the file named n into SARIF results.
+//Issues which do not specify a file and failed tests are assumed to belong to it.
+func (r *Report) SARIF(n string) []*sarif.Result {
+	rs := make([]*sarif.Result, 0, len(r.Issues))
+	for _, i := range r.Issues {
+		if r.Format != JUNIT && i.File != "" && !sarif.Matches(i.File, n) {
+			continue
+		}
+		sr := sarif.NewResult(i.Rule, sarif.Level(i.Severity), i.Message, i.Line, i.Line)
+		sr.SetProperty(result.CATEGORY, i.Category)
+		rs = append(rs, sr)
+	}
+	return rs
+}
+
+//Success is true if no issues were found.
+func (r *Report) Success() bool {
+	return len(r.Issues) == 0
+}
+
+//Severities counts the number of issues of each severity.
+func (r *Report) Severities() map[string]int {
+	s := make(map[string]int)
+	for _, i := range r.Issues {
+		s[i.Severity]++
+	}
+	return s
+}
+
+//Lines retrieves the locations of all issues which have a line number.
+func (r *Report) Lines() []*result.Line {
+	ls := make([]*result.Line, 0, len(r.Issues))
+	for _, i := range r.Issues {
+		if i.Line <= 0 {
+			continue
+		}
+		ls = append(ls, &result.Line{Title: i.Rule, Description: i.Message, Start: i.Line, End: i.Line})
+	}
+	return ls
+}
+
+//String
+func (r *Report) String() string {
+	return fmt.Sprintf("Id: %q; Format: %s; Tests: %d; Issues: %s", r.Id, r.Format, r.Tests, r.Issues)
+}
+
+//String
+func (i *Issue) String() string {
+	return fmt.Sprintf("Rule: %s; Severity: %s; Category: %s; Message: %s; File: %s; Line: %d",
+		i.Rule, i.Severity, i.Category, i.Message, i.File, i.Line)
+}
+
+func (is Issues) Len() int {
+	return len(is)
+}
+
+func (is Issues) Swap(i, j int) {
+	is[i], is[j] = is[j], is[i]
+}
+
+func (is Issues) Less(i, j int) bool {
+	return is[i].Line < is[j].Line
+}
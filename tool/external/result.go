@@ -0,0 +1,153 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package external
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
+	"labix.org/v2/mgo/bson"
+
+	"sort"
+)
+
+const (
+	NAME = "External"
+)
+
+type (
+	Result struct {
+		Id     bson.ObjectId `bson:"_id"`
+		FileId bson.ObjectId `bson:"fileid"`
+		Name   string        `bson:"name"`
+		Chart  Chart         `bson:"chart"`
+		Report *Report       `bson:"report"`
+		GridFS bool          `bson:"gridfs"`
+		Type   string        `bson:"type"`
+	}
+)
+
+func (r *Result) GetType() string {
+	return r.Type
+}
+
+//SetReport
+func (r *Result) SetReport(report result.Reporter) {
+	if report == nil {
+		r.Report = nil
+	} else {
+		r.Report = report.(*Report)
+	}
+}
+
+//OnGridFS
+func (r *Result) OnGridFS() bool {
+	return r.GridFS
+}
+
+//String
+func (r *Result) String() string {
+	return fmt.Sprintf("Id: %q; FileId: %q; Name: %s; \nReport: %s\n",
+		r.Id, r.FileId, r.Name, r.Report)
+}
+
+//GetName
+func (r *Result) GetName() string {
+	return r.Name
+}
+
+//GetId
+func (r *Result) GetId() bson.ObjectId {
+	return r.Id
+}
+
+//GetFileId
+func (r *Result) GetFileId() bson.ObjectId {
+	return r.FileId
+}
+
+func (r *Result) GetTestId() bson.ObjectId {
+	return ""
+}
+
+func (r *Result) Reporter() result.Reporter {
+	return r.Report
+}
+
+//ChartVals charts the result according to its tool's chart type.
+func (r *Result) ChartVals() []*result.ChartVal {
+	switch r.Chart {
+	case TOTAL:
+		return []*result.ChartVal{
+			&result.ChartVal{Name: "Issues", Y: float64(len(r.Report.Issues)), FileId: r.FileId},
+		}
+	case SEVERITY:
+		s := r.Report.Severities()
+		ns := make([]string, 0, len(s))
+		for n := range s {
+			ns = append(ns, n)
+		}
+		sort.Strings(ns)
+		vs := make([]*result.ChartVal, len(ns))
+		for i, n := range ns {
+			vs[i] = &result.ChartVal{Name: n, Y: float64(s[n]), FileId: r.FileId}
+		}
+		return vs
+	}
+	return []*result.ChartVal{}
+}
+
+func (r *Result) Template() string {
+	return "externalresult"
+}
+
+func (r *Result) Lines() []*result.Line {
+	return r.Report.Lines()
+}
+
+//NewResult creates a new external tool Result from the tool's output.
+func NewResult(fileId bson.ObjectId, c *Config, data []byte) (*Result, error) {
+	id := bson.NewObjectId()
+	r, e := NewReport(id, c, data)
+	if e != nil {
+		return nil, e
+	}
+	return &Result{
+		Id:     id,
+		FileId: fileId,
+		Name:   c.Name,
+		Chart:  c.Chart,
+		GridFS: len(data) > tool.MAX_SIZE,
+		Type:   NAME,
+		Report: r,
+	}, nil
+}
+
+//SARIF
+func (r *Result) SARIF(n string) []*sarif.Result {
+	return r.Report.SARIF(n)
+}
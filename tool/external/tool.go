@@ -0,0 +1,93 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package external provides an Impendulo tool which runs an arbitrary command
+//described by a Config. This allows tools such as linters to be added to a
+//project without writing a dedicated tool package for each of them.
+package external
+
+import (
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type (
+	//Tool runs an external tool as specified by its Config.
+	Tool struct {
+		cfg *Config
+		cp  string
+	}
+)
+
+//New creates a new external Tool from c. cp is added to the
+//classpath which replaces the {cp} placeholder.
+func New(c *Config, cp string) *Tool {
+	return &Tool{cfg: c, cp: cp}
+}
+
+//Lang is the language specified in the tool's Config.
+func (t *Tool) Lang() tool.Language {
+	return tool.Language(t.cfg.Lang)
+}
+
+//Name is External:<name>
+func (t *Tool) Name() string {
+	return NAME + ":" + t.cfg.Name
+}
+
+//Run runs the external tool on target. The tool's output is read from the
+//{output} file if it was created and from standard output otherwise. A non-zero
+//exit status is not treated as an error since most tools use it to indicate that
+//issues were found.
+func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
+	o := filepath.Join(target.Dir, t.cfg.Name+".out")
+	defer os.Remove(o)
+	cp := target.Dir
+	if t.cp != "" {
+		cp += ":" + t.cp
+	}
+	r, e := tool.RunCommand(t.cfg.Args(target, cp, o), nil, time.Duration(t.cfg.Timeout)*time.Second)
+	if e != nil && !tool.IsEndError(e) {
+		return nil, e
+	}
+	d := r.StdOut
+	if f, fe := os.Open(o); fe == nil {
+		d = util.ReadBytes(f)
+		f.Close()
+	}
+	nr, ne := NewResult(fileId, t.cfg, d)
+	if ne != nil {
+		if e != nil {
+			ne = e
+		}
+		return nil, ne
+	}
+	return nr, nil
+}
@@ -0,0 +1,229 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package findbugs
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Config specifies the Findbugs settings configured for a specific project.
+	//Categories, patterns and classes are used to build Findbugs filter files.
+	Config struct {
+		Id              bson.ObjectId `bson:"_id"`
+		ProjectId       bson.ObjectId `bson:"projectid"`
+		Time            int64         `bson:"time"`
+		Effort          string        `bson:"effort"`
+		Confidence      string        `bson:"confidence"`
+		Rank            int           `bson:"rank"`
+		Relaxed         bool          `bson:"relaxed"`
+		Categories      []string      `bson:"categories"`
+		Excluded        []string      `bson:"excluded"`
+		Patterns        []string      `bson:"patterns"`
+		ExcludePatterns []string      `bson:"excludepatterns"`
+		Classes         []string      `bson:"classes"`
+	}
+	//filter is used to write Findbugs' xml filter format.
+	filter struct {
+		XMLName xml.Name `xml:"FindBugsFilter"`
+		Matches []*match `xml:"Match"`
+	}
+	match struct {
+		Bug   *bug   `xml:"Bug,omitempty"`
+		Class *class `xml:"Class,omitempty"`
+	}
+	bug struct {
+		Category string `xml:"category,attr,omitempty"`
+		Pattern  string `xml:"pattern,attr,omitempty"`
+	}
+	class struct {
+		Name string `xml:"name,attr"`
+	}
+)
+
+const (
+	MAX_RANK     = 20
+	HIGH         = "high"
+	MEDIUM       = "medium"
+	LOW          = "low"
+	EXPERIMENTAL = "experimental"
+)
+
+var (
+	efforts     = []string{"max", "more", "default", "less", "min"}
+	confidences = []string{HIGH, MEDIUM, LOW, EXPERIMENTAL}
+	categories  = []string{"BAD_PRACTICE", "CORRECTNESS", "EXPERIMENTAL", "I18N", "MALICIOUS_CODE",
+		"MT_CORRECTNESS", "NOISE", "PERFORMANCE", "SECURITY", "STYLE"}
+)
+
+//Efforts lists the analysis efforts Findbugs can be run with.
+func Efforts() []string {
+	return efforts
+}
+
+//Confidences lists the confidence thresholds Findbugs can be run with.
+func Confidences() []string {
+	return confidences
+}
+
+//Categories lists the Findbugs bug categories.
+func Categories() []string {
+	return categories
+}
+
+//String
+func (c *Config) String() string {
+	return "Type: findbugs.Config; Id: " + c.Id.Hex() +
+		"; ProjectId: " + c.ProjectId.Hex() +
+		"; Time: " + util.Date(c.Time)
+}
+
+//DefaultConfig creates a Findbugs configuration for a project
+//which reports every bug Findbugs can find.
+func DefaultConfig(pid bson.ObjectId) *Config {
+	return &Config{
+		Id:         bson.NewObjectId(),
+		ProjectId:  pid,
+		Time:       util.CurMilis(),
+		Effort:     "max",
+		Confidence: EXPERIMENTAL,
+		Rank:       MAX_RANK,
+		Relaxed:    true,
+	}
+}
+
+//NewConfig creates a new Findbugs configuration for a project.
+//The effort, confidence, rank and categories are checked against those Findbugs supports.
+func NewConfig(pid bson.ObjectId, effort, confidence string, rank int, relaxed bool, included, excluded, patterns, excludePatterns, classes []string) (*Config, error) {
+	if !contains(efforts, effort) {
+		return nil, fmt.Errorf("invalid findbugs effort %s", effort)
+	}
+	if !contains(confidences, confidence) {
+		return nil, fmt.Errorf("invalid findbugs confidence %s", confidence)
+	}
+	if rank < 1 || rank > MAX_RANK {
+		return nil, fmt.Errorf("findbugs rank %d not in range [1, %d]", rank, MAX_RANK)
+	}
+	for _, cs := range [][]string{included, excluded} {
+		for _, c := range cs {
+			if !contains(categories, c) {
+				return nil, fmt.Errorf("invalid findbugs category %s", c)
+			}
+		}
+	}
+	return &Config{
+		Id:              bson.NewObjectId(),
+		ProjectId:       pid,
+		Time:            util.CurMilis(),
+		Effort:          effort,
+		Confidence:      confidence,
+		Rank:            rank,
+		Relaxed:         relaxed,
+		Categories:      included,
+		Excluded:        excluded,
+		Patterns:        trim(patterns),
+		ExcludePatterns: trim(excludePatterns),
+		Classes:         trim(classes),
+	}, nil
+}
+
+//Args creates the Findbugs command line flags for this configuration.
+//Filter files are added separately since they need to be stored first.
+func (c *Config) Args() []string {
+	a := []string{"-effort:" + c.Effort, "-" + c.Confidence, "-maxRank", strconv.Itoa(c.Rank)}
+	if c.Relaxed {
+		a = append(a, "-relaxed")
+	}
+	if len(c.Categories) > 0 {
+		a = append(a, "-bugCategories", strings.Join(c.Categories, ","))
+	}
+	return a
+}
+
+//IncludeFilter creates a Findbugs filter which only reports the configured bug patterns.
+//It is nil if no bug patterns have been configured.
+func (c *Config) IncludeFilter() ([]byte, error) {
+	if len(c.Patterns) == 0 {
+		return nil, nil
+	}
+	return filterBytes([]*match{&match{Bug: &bug{Pattern: strings.Join(c.Patterns, ",")}}})
+}
+
+//ExcludeFilter creates a Findbugs filter which hides the excluded bug categories and patterns
+//as well as any bugs found in excluded classes such as those provided in a project's skeleton.
+//It is nil if nothing has been excluded.
+func (c *Config) ExcludeFilter() ([]byte, error) {
+	ms := make([]*match, 0, len(c.Excluded)+len(c.Classes)+1)
+	for _, e := range c.Excluded {
+		ms = append(ms, &match{Bug: &bug{Category: e}})
+	}
+	if len(c.ExcludePatterns) > 0 {
+		ms = append(ms, &match{Bug: &bug{Pattern: strings.Join(c.ExcludePatterns, ",")}})
+	}
+	for _, n := range c.Classes {
+		ms = append(ms, &match{Class: &class{Name: n}})
+	}
+	if len(ms) == 0 {
+		return nil, nil
+	}
+	return filterBytes(ms)
+}
+
+//filterBytes writes the matches to a Findbugs filter file.
+func filterBytes(ms []*match) ([]byte, error) {
+	d, e := xml.MarshalIndent(&filter{Matches: ms}, "", "  ")
+	if e != nil {
+		return nil, e
+	}
+	return bytes.Join([][]byte{[]byte(xml.Header), d}, nil), nil
+}
+
+//trim removes surrounding whitespace and empty values from a.
+func trim(a []string) []string {
+	t := make([]string, 0, len(a))
+	for _, s := range a {
+		if s = strings.TrimSpace(s); s != "" {
+			t = append(t, s)
+		}
+	}
+	return t
+}
+
+//contains is whether s is present in a.
+func contains(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
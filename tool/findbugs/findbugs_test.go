@@ -25,6 +25,7 @@
 package findbugs
 
 import (
+	"bytes"
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/javac"
 	"github.com/godfried/impendulo/util"
@@ -53,7 +54,7 @@ func TestRun(t *testing.T) {
 	if err != nil {
 		t.Errorf("Expected success, got %q", err)
 	}
-	findbugs, err := New()
+	findbugs, err := New(nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -64,6 +65,50 @@ func TestRun(t *testing.T) {
 	os.Remove(filepath.Join(location, "findbugs.xml"))
 }
 
+func TestConfig(t *testing.T) {
+	pid := bson.NewObjectId()
+	for _, a := range [][]interface{}{
+		{"none", HIGH, 10, []string{}},
+		{"max", "certain", 10, []string{}},
+		{"max", HIGH, 0, []string{}},
+		{"max", HIGH, MAX_RANK + 1, []string{}},
+		{"max", HIGH, 10, []string{"UNKNOWN"}},
+	} {
+		if _, e := NewConfig(pid, a[0].(string), a[1].(string), a[2].(int), false, a[3].([]string), nil, nil, nil, nil); e == nil {
+			t.Errorf("expected error for %v", a)
+		}
+	}
+	c, e := NewConfig(pid, "less", MEDIUM, 15, false, []string{"CORRECTNESS", "STYLE"}, []string{"EXPERIMENTAL"}, nil, []string{" DM_EXIT", ""}, []string{"~triangle\\.Skeleton.*"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	a := c.Args()
+	x := []string{"-effort:less", "-medium", "-maxRank", "15", "-bugCategories", "CORRECTNESS,STYLE"}
+	if len(a) != len(x) {
+		t.Fatalf("expected args %v, got %v", x, a)
+	}
+	for i, v := range x {
+		if a[i] != v {
+			t.Errorf("expected arg %s, got %s", v, a[i])
+		}
+	}
+	if d, e := c.IncludeFilter(); e != nil || d != nil {
+		t.Errorf("expected no include filter, got %s %v", d, e)
+	}
+	d, e := c.ExcludeFilter()
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, s := range []string{`<Bug category="EXPERIMENTAL"></Bug>`, `<Bug pattern="DM_EXIT"></Bug>`, `<Class name="~triangle\.Skeleton.*"></Class>`} {
+		if !bytes.Contains(d, []byte(s)) {
+			t.Errorf("expected %s in filter %s", s, d)
+		}
+	}
+	if d, e = DefaultConfig(pid).ExcludeFilter(); e != nil || d != nil {
+		t.Errorf("expected no exclude filter, got %s %v", d, e)
+	}
+}
+
 var file = []byte(`
 package triangle;
 public class Triangle {
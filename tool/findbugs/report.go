@@ -25,12 +25,12 @@
 package findbugs
 
 import (
-	"encoding/gob"
 	"encoding/xml"
 	"fmt"
 
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 
 	"html/template"
 
@@ -210,10 +210,6 @@ type (
 	}
 )
 
-func init() {
-	gob.Register(new(Report))
-}
-
 //NewReport
 func NewReport(id bson.ObjectId, data []byte) (*Report, error) {
 	var d *DummyReport
@@ -250,6 +246,48 @@ func (r *Report) String() string {
 	return fmt.Sprintf("Id: %q; Summary: %s", r.Id, r.Summary)
 }
 
+//SARIF converts the bugs found in the file named n into SARIF results.
+func (r *Report) SARIF(n string) []*sarif.Result {
+	rs := make([]*sarif.Result, 0, len(r.Instances))
+	for _, b := range r.Instances {
+		if b.Line == nil || (b.Line.File != n && !sarif.Matches(b.Line.Path, n)) {
+			continue
+		}
+		sr := sarif.NewResult(b.Type, priorityLevel(b.Priority), b.LongMessage, b.Line.Start, b.Line.End)
+		sr.SetProperty(result.CATEGORY, category(b.Category))
+		rs = append(rs, sr)
+	}
+	return rs
+}
+
+//category converts a Findbugs bug category into an issue category.
+func category(c string) string {
+	switch c {
+	case "CORRECTNESS", "MT_CORRECTNESS", "BAD_PRACTICE":
+		return result.BUG
+	case "PERFORMANCE":
+		return result.PERFORMANCE
+	case "MALICIOUS_CODE", "SECURITY":
+		return result.SECURITY
+	case "STYLE":
+		return result.STYLE
+	default:
+		return result.OTHER
+	}
+}
+
+//priorityLevel converts a Findbugs priority into a SARIF level.
+func priorityLevel(p int) string {
+	switch p {
+	case 1:
+		return sarif.ERROR
+	case 2:
+		return sarif.WARNING
+	default:
+		return sarif.NOTE
+	}
+}
+
 func (r *Report) Lines() []*result.Line {
 	lines := make([]*result.Line, 0, len(r.Instances))
 	for _, b := range r.Instances {
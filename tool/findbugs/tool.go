@@ -43,18 +43,23 @@ import (
 type (
 	//Findbugs is a tool.T used to run Findbugs on Java classes.
 	Tool struct {
-		cmd string
+		cmd    string
+		config *Config
 	}
 )
 
-//New creates a new instance of the Findbugs tool.
+//New creates a new instance of the Findbugs tool which runs with the settings in c.
+//If c is nil the default settings, which report every bug, are used.
 //If an error is returned, it will be due Findbugs not being configured correctly.
-func New() (*Tool, error) {
+func New(c *Config) (*Tool, error) {
 	p, e := config.FINDBUGS.Path()
 	if e != nil {
 		return nil, e
 	}
-	return &Tool{cmd: p}, nil
+	if c == nil {
+		c = DefaultConfig("")
+	}
+	return &Tool{cmd: p, config: c}, nil
 }
 
 //Lang is Java.
@@ -68,7 +73,7 @@ func (t *Tool) Name() string {
 }
 
 //Run executes Findbugs on the provided source file.
-//Findbugs is run with the following flags: -effort:max, -experimental, -relaxed.
+//Findbugs is run with the flags and filters specified by the Tool's configuration.
 //The result is written to an XML file which is then read and used to create a
 //Findbugs Result.
 func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
@@ -78,8 +83,23 @@ func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, er
 		return nil, e
 	}
 	o := filepath.Join(target.Dir, "findbugs.xml")
-	a := []string{jp, "-jar", t.cmd, "-textui", "-effort:max", "-experimental",
-		"-xml:withMessages", "-relaxed", "-output", o, target.PackagePath()}
+	a := append([]string{jp, "-jar", t.cmd, "-textui"}, t.config.Args()...)
+	for f, fn := range map[string]func() ([]byte, error){"-include": t.config.IncludeFilter, "-exclude": t.config.ExcludeFilter} {
+		d, e := fn()
+		if e != nil {
+			return nil, e
+		}
+		if d == nil {
+			continue
+		}
+		p, e := util.SaveTemp(d)
+		if e != nil {
+			return nil, e
+		}
+		defer os.Remove(p)
+		a = append(a, f, p)
+	}
+	a = append(a, "-xml:withMessages", "-output", o, target.PackagePath())
 	defer os.Remove(o)
 	//Run Findbugs and load result.
 	r, re := tool.RunCommand(a, nil, 30*time.Second)
@@ -0,0 +1,143 @@
+package gcc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Config specifies the gcc options configured for a specific project.
+	Config struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Time      int64         `bson:"time"`
+		Standard  string        `bson:"standard"`
+		Flags     []string      `bson:"flags"`
+		Warnings  []string      `bson:"warnings"`
+		Libraries []string      `bson:"libraries"`
+		//Pending configurations are not used on submissions until they have been
+		//validated against the project's reference solution and published.
+		Pending bool `bson:"pending"`
+	}
+)
+
+var (
+	DEFAULT_FLAGS = []string{"-Wall", "-Wextra", "-Wno-variadic-macros", "-pedantic", "-O0"}
+	standards     = []string{"c89", "c90", "c99", "c11", "gnu89", "gnu99", "gnu11"}
+	warningRegex  = regexp.MustCompile(`^[a-z0-9][a-z0-9=-]*$`)
+	libraryRegex  = regexp.MustCompile(`^[\w.+-]+$`)
+	//flagRegexes match the only flags which can be configured: optimisation, debugging,
+	//code generation, macro and diagnostic options which don't read or write other files.
+	flagRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`^-O([0-3sg]|fast)?$`),
+		regexp.MustCompile(`^-g([0-3]|gdb)?$`),
+		regexp.MustCompile(`^-f(no-)?[a-z0-9][a-z0-9-]*$`),
+		regexp.MustCompile(`^-D[A-Za-z_]\w*(=[\w.+-]*)?$`),
+		regexp.MustCompile(`^-U[A-Za-z_]\w*$`),
+		regexp.MustCompile(`^-W[a-z0-9][a-z0-9=-]*$`),
+		regexp.MustCompile(`^-(w|ansi|pedantic|pedantic-errors|pthread|m32|m64)$`),
+	}
+	//unsafeOptions are -f options which read or write files other than the source and binary.
+	unsafeOptions = []string{"dump-", "profile-", "auto-profile", "stack-usage", "callgraph-info", "record-gcc-switches", "save-", "test-coverage"}
+)
+
+//Standards lists the C language standards gcc can compile for.
+func Standards() []string {
+	return standards
+}
+
+//String
+func (c *Config) String() string {
+	return "Type: gcc.Config; Id: " + c.Id.Hex() +
+		"; ProjectId: " + c.ProjectId.Hex() +
+		"; Time: " + util.Date(c.Time)
+}
+
+//NewConfig creates a new gcc configuration for a project. An empty standard uses gcc's default.
+//Warnings and libraries may be given with or without their -W and -l prefixes.
+//Only the flags allowed by validFlag can be configured.
+func NewConfig(pid bson.ObjectId, standard string, flags, warnings, libraries []string) (*Config, error) {
+	if standard != "" && !validStandard(standard) {
+		return nil, fmt.Errorf("invalid c standard %s", standard)
+	}
+	for _, f := range flags {
+		if !validFlag(f) {
+			return nil, fmt.Errorf("gcc flag %s cannot be configured", f)
+		}
+	}
+	ws := make([]string, len(warnings))
+	for i, w := range warnings {
+		if ws[i] = strings.TrimPrefix(w, "-W"); !warningRegex.MatchString(ws[i]) {
+			return nil, fmt.Errorf("invalid gcc warning %s", w)
+		}
+	}
+	ls := make([]string, len(libraries))
+	for i, l := range libraries {
+		if ls[i] = strings.TrimPrefix(l, "-l"); !libraryRegex.MatchString(ls[i]) {
+			return nil, fmt.Errorf("invalid library %s", l)
+		}
+	}
+	return &Config{
+		Id:        bson.NewObjectId(),
+		ProjectId: pid,
+		Time:      util.CurMilis(),
+		Standard:  standard,
+		Flags:     flags,
+		Warnings:  ws,
+		Libraries: ls,
+	}, nil
+}
+
+//validFlag checks whether f is an optimisation, debugging, code generation,
+//macro or warning flag which doesn't cause gcc to read or write other files.
+func validFlag(f string) bool {
+	for _, o := range unsafeOptions {
+		if strings.HasPrefix(f, "-f"+o) || strings.HasPrefix(f, "-fno-"+o) {
+			return false
+		}
+	}
+	for _, r := range flagRegexes {
+		if r.MatchString(f) {
+			return true
+		}
+	}
+	return false
+}
+
+//validStandard checks whether gcc supports the standard s.
+func validStandard(s string) bool {
+	for _, v := range standards {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+//Args creates the gcc command line options for this configuration.
+//They must precede the source file.
+func (c *Config) Args() []string {
+	a := make([]string, 0, len(c.Flags)+len(c.Warnings)+1)
+	a = append(a, c.Flags...)
+	if c.Standard != "" {
+		a = append(a, "-std="+c.Standard)
+	}
+	for _, w := range c.Warnings {
+		a = append(a, "-W"+w)
+	}
+	return a
+}
+
+//Libs creates the gcc options used to link this configuration's libraries.
+//They must follow the source file.
+func (c *Config) Libs() []string {
+	a := make([]string, len(c.Libraries))
+	for i, l := range c.Libraries {
+		a[i] = "-l" + l
+	}
+	return a
+}
@@ -0,0 +1,58 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package gcc
+
+import (
+	"labix.org/v2/mgo/bson"
+
+	"strings"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	pid := bson.NewObjectId()
+	for _, f := range []string{"-o", "-ofoo", "-wrapper", "-fplugin=evil.so", "-B/tmp", "-specs=evil", "@flags", "-std=c99",
+		"-lm", "-Wl,-rpath", "-fdump-tree-all", "-fprofile-generate", "-I/etc", "-include", "O2"} {
+		if _, e := NewConfig(pid, "", []string{f}, nil, nil); e == nil {
+			t.Errorf("expected error for flag %s", f)
+		}
+	}
+	if _, e := NewConfig(pid, "c2x", nil, nil, nil); e == nil {
+		t.Error("expected error for invalid standard")
+	}
+	if _, e := NewConfig(pid, "", DEFAULT_FLAGS, nil, nil); e != nil {
+		t.Errorf("expected default flags to be valid: %v", e)
+	}
+	c, e := NewConfig(pid, "c99", []string{"-O2", "-g", "-fno-strict-aliasing", "-DDEBUG=1"}, []string{"-Wshadow", "error"}, []string{"-lm"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if a := strings.Join(c.Args(), " "); a != "-O2 -g -fno-strict-aliasing -DDEBUG=1 -std=c99 -Wshadow -Werror" {
+		t.Errorf("invalid args %s", a)
+	}
+	if l := strings.Join(c.Libs(), " "); l != "-lm" {
+		t.Errorf("invalid libraries %s", l)
+	}
+}
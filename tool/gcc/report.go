@@ -26,12 +26,13 @@ package gcc
 
 import (
 	"bytes"
-	"encoding/gob"
 	"fmt"
 	"time"
 
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/diagnostic"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 	"labix.org/v2/mgo/bson"
 
 	"strconv"
@@ -39,18 +40,15 @@ import (
 
 type (
 	Report struct {
-		Id       bson.ObjectId      `bson:"_id"`
-		Type     result.CompileType `bson:"type"`
-		Warnings int                `bson:"warnings"`
-		Errors   int                `bson:"errors"`
-		Data     []byte             `bson:"data"`
+		Id          bson.ObjectId            `bson:"_id"`
+		Type        result.CompileType       `bson:"type"`
+		Warnings    int                      `bson:"warnings"`
+		Errors      int                      `bson:"errors"`
+		Data        []byte                   `bson:"data"`
+		Diagnostics []*diagnostic.Diagnostic `bson:"diagnostics"`
 	}
 )
 
-func init() {
-	gob.Register(new(Report))
-}
-
 //NewReport
 func NewReport(id bson.ObjectId, data []byte) (*Report, error) {
 	data = bytes.TrimSpace(data)
@@ -69,19 +67,43 @@ func NewReport(id bson.ObjectId, data []byte) (*Report, error) {
 		t = result.WARNINGS
 	}
 	return &Report{
-		Id:       id,
-		Data:     data,
-		Warnings: wc,
-		Errors:   ec,
-		Type:     t,
+		Id:          id,
+		Data:        data,
+		Warnings:    wc,
+		Errors:      ec,
+		Type:        t,
+		Diagnostics: diagnostic.Explain(diagnostic.Parse(data), diagnostic.Defaults(tool.C)),
 	}, nil
 }
 
+//Explain explains the report's diagnostics using the catalogue c.
+func (r *Report) Explain(c []*diagnostic.Explanation) {
+	r.Diagnostics = diagnostic.Explain(diagnostic.Parse(r.Data), c)
+}
+
+//Lines positions the report's diagnostics in the compiled code.
+func (r *Report) Lines() []*result.Line {
+	ls := make([]*result.Line, len(r.Diagnostics))
+	for i, d := range r.Diagnostics {
+		ls[i] = &result.Line{Title: d.Title(), Description: d.Description(), Start: d.Line, End: d.Line}
+	}
+	return ls
+}
+
 //Success tells us if compilation finished with no errors or warnings.
 func (r *Report) Success() bool {
 	return r.Type == result.SUCCESS
 }
 
+//SARIF converts the compiler diagnostics for the file named n into SARIF results.
+func (r *Report) SARIF(n string) []*sarif.Result {
+	rs := sarif.Diagnostics(r.Data, n)
+	for _, sr := range rs {
+		sr.SetProperty(result.CATEGORY, result.COMPILE)
+	}
+	return rs
+}
+
 //Header generates a string which briefly describes the compilation.
 func (r *Report) Header() (header string) {
 	if r.Success() {
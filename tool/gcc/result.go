@@ -3,17 +3,19 @@ package gcc
 import (
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 	"labix.org/v2/mgo/bson"
 )
 
 type (
 	Result struct {
-		Id     bson.ObjectId `bson:"_id"`
-		FileId bson.ObjectId `bson:"fileid"`
-		Name   string        `bson:"name"`
-		Report *Report       `bson:"report"`
-		GridFS bool          `bson:"gridfs"`
-		Type   string        `bson:"type"`
+		Id      bson.ObjectId `bson:"_id"`
+		FileId  bson.ObjectId `bson:"fileid"`
+		Name    string        `bson:"name"`
+		Report  *Report       `bson:"report"`
+		GridFS  bool          `bson:"gridfs"`
+		Type    string        `bson:"type"`
+		Options []string      `bson:"options"`
 	}
 )
 
@@ -62,17 +64,33 @@ func (r *Result) GetType() string {
 }
 
 func NewResult(fileId bson.ObjectId, data []byte) (result.Tooler, error) {
+	return NewOptionsResult(fileId, data, nil)
+}
+
+//NewOptionsResult creates a new gcc result which records the options used when compiling.
+func NewOptionsResult(fileId bson.ObjectId, data []byte, options []string) (result.Tooler, error) {
 	id := bson.NewObjectId()
 	report, e := NewReport(id, data)
 	if e != nil {
 		return nil, e
 	}
 	return &Result{
-		Id:     id,
-		FileId: fileId,
-		Name:   NAME,
-		Report: report,
-		GridFS: len(data) > tool.MAX_SIZE,
-		Type:   NAME,
+		Id:      id,
+		FileId:  fileId,
+		Name:    NAME,
+		Report:  report,
+		GridFS:  len(data) > tool.MAX_SIZE,
+		Type:    NAME,
+		Options: options,
 	}, nil
 }
+
+//Lines
+func (r *Result) Lines() []*result.Line {
+	return r.Report.Lines()
+}
+
+//SARIF
+func (r *Result) SARIF(n string) []*sarif.Result {
+	return r.Report.SARIF(n)
+}
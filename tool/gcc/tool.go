@@ -3,16 +3,20 @@ package gcc
 import (
 	"github.com/godfried/impendulo/config"
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/diagnostic"
 	"github.com/godfried/impendulo/tool/result"
 	"labix.org/v2/mgo/bson"
 
+	"path/filepath"
 	"time"
 )
 
 type (
 	Tool struct {
-		cmd  string
-		path string
+		cmd       string
+		path      string
+		config    *Config
+		catalogue []*diagnostic.Explanation
 	}
 )
 
@@ -41,20 +45,64 @@ func (t *Tool) Name() string {
 func (t *Tool) AddCP(p string) {
 }
 
+//Configure makes t compile with the options in c.
+func (t *Tool) Configure(c *Config) {
+	t.config = c
+}
+
+//SetCatalogue makes t explain compiler diagnostics with the catalogue c
+//instead of the built-in catalogue.
+func (t *Tool) SetCatalogue(c []*diagnostic.Explanation) {
+	t.catalogue = c
+}
+
+//Options are the options used when compiling. The default flags are used
+//if t has not been configured.
+func (t *Tool) Options() []string {
+	if t.config == nil {
+		return DEFAULT_FLAGS
+	}
+	return append(t.config.Args(), t.config.Libs()...)
+}
+
+//Run compiles target. The executable is placed next to the source file so that it can be run by other tools.
 func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
-	a := []string{t.cmd, "-Wall", "-Wextra", "-Wno-variadic-macros", "-pedantic", "-O0", "-o", target.Name, target.FilePath()}
+	o := filepath.Join(target.PackagePath(), target.Name)
+	a := []string{t.cmd}
+	if t.config == nil {
+		a = append(a, DEFAULT_FLAGS...)
+	} else {
+		a = append(a, t.config.Args()...)
+	}
+	a = append(a, "-o", o, target.FilePath())
+	if t.config != nil {
+		a = append(a, t.config.Libs()...)
+	}
 	r, e := tool.RunCommand(a, nil, 30*time.Second)
 	if e != nil {
 		if !tool.IsEndError(e) {
 			return nil, e
 		}
-		nr, e2 := NewResult(fileId, r.StdErr)
+		nr, e2 := t.newResult(fileId, r.StdErr)
 		if e2 != nil {
 			return nil, e
 		}
 		return nr, tool.NewCompileError(target.FullName(), string(r.StdErr))
 	} else if r.HasStdErr() {
-		return NewResult(fileId, r.StdErr)
+		return t.newResult(fileId, r.StdErr)
+	}
+	return t.newResult(fileId, result.COMPILE_SUCCESS)
+}
+
+//newResult creates a gcc result which records t's options and whose
+//diagnostics are explained with t's catalogue.
+func (t *Tool) newResult(fileId bson.ObjectId, data []byte) (result.Tooler, error) {
+	r, e := NewOptionsResult(fileId, data, t.Options())
+	if e != nil {
+		return nil, e
+	}
+	if t.catalogue != nil {
+		r.(*Result).Report.Explain(t.catalogue)
 	}
-	return NewResult(fileId, result.COMPILE_SUCCESS)
+	return r, nil
 }
@@ -0,0 +1,191 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package iotest
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	//Case is an input/output test case for a project. The program is run with
+	//Args and Input as its standard input. It passes if its standard output
+	//matches Expected according to Mode and it exits with ExitCode.
+	Case struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Name      string        `bson:"name"`
+		Lang      string        `bson:"lang"`
+		Time      int64         `bson:"time"`
+		//File is the name of the source file the case is run on. The case
+		//is run on all the project's source files if it is empty.
+		File     string `bson:"file"`
+		Args     string `bson:"args"`
+		Input    string `bson:"input"`
+		Expected string `bson:"expected"`
+		ExitCode int    `bson:"exitcode"`
+		Mode     Mode   `bson:"mode"`
+		//Tolerance is the maximum difference allowed between numbers in NUMERIC mode.
+		Tolerance float64 `bson:"tolerance"`
+		//Hidden cases' input and output are only shown to teachers.
+		Hidden bool `bson:"hidden"`
+		//Pending cases are not run on submissions until they have been
+		//validated against the project's reference solution and published.
+		Pending bool `bson:"pending"`
+	}
+
+	//Mode specifies how a program's output is compared to the expected output.
+	Mode string
+)
+
+const (
+	//EXACT requires the output to be identical to the expected output.
+	EXACT Mode = "exact"
+	//WHITESPACE ignores differences in whitespace.
+	WHITESPACE Mode = "whitespace"
+	//REGEX treats the expected output as a regular expression which must match all of the output.
+	REGEX Mode = "regex"
+	//NUMERIC ignores differences in whitespace and allows numbers to differ by the case's tolerance.
+	NUMERIC Mode = "numeric"
+
+	DEFAULT_TOLERANCE = 1e-6
+)
+
+var (
+	validName = regexp.MustCompile(`^\w+$`)
+)
+
+//Modes retrieves the supported comparison modes.
+func Modes() []Mode {
+	return []Mode{EXACT, WHITESPACE, REGEX, NUMERIC}
+}
+
+//NewCase creates a new test Case for project pid. An error is
+//returned if the case is invalid.
+func NewCase(pid bson.ObjectId, name, lang, file, args, input, expected string, exit int, m Mode, tolerance float64, hidden bool) (*Case, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid case name %q, only letters, digits and underscores are allowed", name)
+	}
+	if !tool.Supported(tool.Language(lang)) {
+		return nil, fmt.Errorf("unsupported language %s", lang)
+	}
+	switch m {
+	case EXACT, WHITESPACE:
+	case REGEX:
+		if _, e := matcher(expected); e != nil {
+			return nil, e
+		}
+	case NUMERIC:
+		if tolerance < 0 {
+			return nil, fmt.Errorf("invalid tolerance %v", tolerance)
+		} else if tolerance == 0 {
+			tolerance = DEFAULT_TOLERANCE
+		}
+	default:
+		return nil, fmt.Errorf("unsupported comparison mode %s", m)
+	}
+	return &Case{
+		Id:        bson.NewObjectId(),
+		ProjectId: pid,
+		Name:      name,
+		Lang:      lang,
+		Time:      util.CurMilis(),
+		File:      strings.TrimSpace(file),
+		Args:      args,
+		Input:     input,
+		Expected:  expected,
+		ExitCode:  exit,
+		Mode:      m,
+		Tolerance: tolerance,
+		Hidden:    hidden,
+	}, nil
+}
+
+//Applies checks whether the case should be run on the file n.
+func (c *Case) Applies(n string) bool {
+	return c.File == "" || c.File == n
+}
+
+//Arguments retrieves the command line arguments the program is run with.
+func (c *Case) Arguments() []string {
+	return strings.Fields(c.Args)
+}
+
+//Match checks whether output matches the case's expected output.
+func (c *Case) Match(output string) bool {
+	switch c.Mode {
+	case EXACT:
+		return output == c.Expected
+	case WHITESPACE:
+		return strings.Join(strings.Fields(output), " ") == strings.Join(strings.Fields(c.Expected), " ")
+	case REGEX:
+		r, e := matcher(c.Expected)
+		return e == nil && r.MatchString(output)
+	case NUMERIC:
+		return numeric(strings.Fields(c.Expected), strings.Fields(output), c.Tolerance)
+	}
+	return false
+}
+
+//matcher compiles a REGEX case's pattern so that it must match the entire output.
+func matcher(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)\s*$`)
+}
+
+//numeric compares the words in expected and actual. Numbers are
+//equal if they differ by no more than tolerance.
+func numeric(expected, actual []string, tolerance float64) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i, x := range expected {
+		if x == actual[i] {
+			continue
+		}
+		a, e := strconv.ParseFloat(x, 64)
+		if e != nil {
+			return false
+		}
+		b, e := strconv.ParseFloat(actual[i], 64)
+		if e != nil || math.Abs(a-b) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+//String
+func (c *Case) String() string {
+	return fmt.Sprintf("Id: %q; ProjectId: %q; Name: %s; Lang: %s; File: %s; Args: %s; Mode: %s; ExitCode: %d; Hidden: %t",
+		c.Id, c.ProjectId, c.Name, c.Lang, c.File, c.Args, c.Mode, c.ExitCode, c.Hidden)
+}
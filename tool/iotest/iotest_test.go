@@ -0,0 +1,142 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package iotest
+
+import (
+	"github.com/godfried/impendulo/tool"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewCase(t *testing.T) {
+	pid := bson.NewObjectId()
+	c, e := NewCase(pid, "sum", "C", "", "1 2", "", "3", 0, NUMERIC, 0, false)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if c.Tolerance != DEFAULT_TOLERANCE {
+		t.Errorf("Expected default tolerance but got %v.", c.Tolerance)
+	}
+	invalid := []struct {
+		name, lang, expected string
+		mode                 Mode
+		tolerance            float64
+	}{
+		{"sum-1", "C", "3", EXACT, 0},
+		{"sum", "Python", "3", EXACT, 0},
+		{"sum", "C", "(3", REGEX, 0},
+		{"sum", "C", "3", "fuzzy", 0},
+		{"sum", "C", "3", NUMERIC, -1},
+	}
+	for _, i := range invalid {
+		if _, e := NewCase(pid, i.name, i.lang, "", "", "", i.expected, 0, i.mode, i.tolerance, false); e == nil {
+			t.Errorf("Expected error for %v.", i)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		c      *Case
+		output string
+		match  bool
+	}{
+		{&Case{Mode: EXACT, Expected: "Hello\n"}, "Hello\n", true},
+		{&Case{Mode: EXACT, Expected: "Hello\n"}, "Hello \n", false},
+		{&Case{Mode: WHITESPACE, Expected: "1 2\n3"}, " 1  2 3\n\n", true},
+		{&Case{Mode: WHITESPACE, Expected: "1 2 3"}, "1 23", false},
+		{&Case{Mode: REGEX, Expected: `Total: \d+`}, "Total: 42\n", true},
+		{&Case{Mode: REGEX, Expected: `Total: \d+`}, "Total: 42 items\n", false},
+		{&Case{Mode: NUMERIC, Expected: "area 3.14159", Tolerance: 0.001}, "area  3.1418\n", true},
+		{&Case{Mode: NUMERIC, Expected: "area 3.14159", Tolerance: 0.001}, "area 3.15", false},
+		{&Case{Mode: NUMERIC, Expected: "area 3.14159", Tolerance: 0.001}, "volume 3.14159", false},
+		{&Case{Mode: NUMERIC, Expected: "1 2", Tolerance: 0.001}, "1 2 3", false},
+	}
+	for _, test := range tests {
+		if m := test.c.Match(test.output); m != test.match {
+			t.Errorf("Expected match %t for %q in %s mode but got %t.", test.match, test.output, test.c.Mode, m)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	d, e := ioutil.TempDir("", "iotest")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(d)
+	//A compiled program is simulated by a script which echoes its input and exits with its argument.
+	s := "#!/bin/sh\ncat\nexit $1\n"
+	if e = ioutil.WriteFile(filepath.Join(d, "echo"), []byte(s), 0755); e != nil {
+		t.Fatal(e)
+	}
+	pid := bson.NewObjectId()
+	cs := []*Case{
+		{Name: "pass", Args: "0", Input: "hello\n", Expected: "hello\n", Mode: EXACT},
+		{Name: "output", Args: "0", Input: "hello\n", Expected: "bye\n", Mode: EXACT, Hidden: true},
+		{Name: "exit", Args: "3", Input: "1 2 ", Expected: "1 2", ExitCode: 3, Mode: WHITESPACE},
+		{Name: "wrongexit", Args: "1", Input: "1", Expected: "1", Mode: EXACT},
+		{Name: "other", File: "other.c", Mode: EXACT},
+	}
+	for _, c := range cs {
+		c.ProjectId = pid
+	}
+	tl, e := New(cs, tool.C)
+	if e != nil {
+		t.Fatal(e)
+	}
+	fid := bson.NewObjectId()
+	r, e := tl.Run(fid, tool.NewTarget("echo.c", "", d, tool.C))
+	if e != nil {
+		t.Fatal(e)
+	}
+	rep := r.(*Result).Report
+	if rep.Passed != 2 || rep.Failed != 2 {
+		t.Fatalf("Expected 2 passed and 2 failed but got %s.", rep)
+	}
+	expected := []struct {
+		passed bool
+		exit   int
+	}{{true, 0}, {false, 0}, {true, 3}, {false, 1}}
+	for i, x := range expected {
+		o := rep.Outcomes[i]
+		if o.Passed != x.passed || o.ExitCode != x.exit {
+			t.Errorf("Expected %s to have passed %t with exit code %d but got %t and %d.", o.Case, x.passed, x.exit, o.Passed, o.ExitCode)
+		}
+	}
+	if !rep.Outcomes[1].Hidden {
+		t.Error("Expected output case to be hidden.")
+	}
+	if tl, e = New(cs[4:], tool.C); e != nil {
+		t.Fatal(e)
+	}
+	if r, e = tl.Run(fid, tool.NewTarget("echo.c", "", d, tool.C)); e != nil || r != nil {
+		t.Errorf("Expected no result for file without cases but got %v, %v.", r, e)
+	}
+}
@@ -0,0 +1,157 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package iotest
+
+import (
+	"fmt"
+
+	"labix.org/v2/mgo/bson"
+
+	"strings"
+)
+
+type (
+	//Report contains the outcome of each case run on a snapshot.
+	Report struct {
+		Id       bson.ObjectId `bson:"_id"`
+		Passed   int           `bson:"passed"`
+		Failed   int           `bson:"failed"`
+		Outcomes []*Outcome    `bson:"outcomes"`
+	}
+
+	//Outcome is the result of running a single case. Diff is a unified
+	//diff of the expected and actual output of a failed case.
+	Outcome struct {
+		Case     string `bson:"case"`
+		Hidden   bool   `bson:"hidden"`
+		Mode     Mode   `bson:"mode"`
+		Args     string `bson:"args"`
+		Input    string `bson:"input"`
+		Expected string `bson:"expected"`
+		Output   string `bson:"output"`
+		Diff     string `bson:"diff"`
+		//ExpectedExit is the exit code the case expects and ExitCode the program's actual exit code.
+		ExpectedExit int    `bson:"expectedexit"`
+		ExitCode     int    `bson:"exitcode"`
+		Timeout      bool   `bson:"timeout"`
+		Passed       bool   `bson:"passed"`
+		Error        string `bson:"error"`
+	}
+
+	//DiffLine is a line in an Outcome's diff.
+	DiffLine string
+)
+
+const (
+	//MAX_OUTPUT is the maximum number of bytes of a program's output stored in an Outcome.
+	MAX_OUTPUT = 1 << 16
+)
+
+//NewReport creates an empty Report.
+func NewReport(id bson.ObjectId) *Report {
+	return &Report{Id: id, Outcomes: make([]*Outcome, 0)}
+}
+
+//NewOutcome creates the Outcome of running c. The case
+//passes if output matches and the program exited with the expected code.
+func NewOutcome(c *Case, output string, exit int) *Outcome {
+	return &Outcome{
+		Case:         c.Name,
+		Hidden:       c.Hidden,
+		Mode:         c.Mode,
+		Args:         c.Args,
+		Input:        c.Input,
+		Expected:     c.Expected,
+		Output:       truncate(output),
+		ExpectedExit: c.ExitCode,
+		ExitCode:     exit,
+		Passed:       exit == c.ExitCode && c.Match(output),
+	}
+}
+
+//truncate shortens s to MAX_OUTPUT bytes.
+func truncate(s string) string {
+	if len(s) > MAX_OUTPUT {
+		return s[:MAX_OUTPUT]
+	}
+	return s
+}
+
+//Add adds an Outcome to the Report.
+func (r *Report) Add(o *Outcome) {
+	if o.Passed {
+		r.Passed++
+	} else {
+		r.Failed++
+	}
+	r.Outcomes = append(r.Outcomes, o)
+}
+
+//Total is the number of cases which were run.
+func (r *Report) Total() int {
+	return r.Passed + r.Failed
+}
+
+//Success checks whether all the cases passed.
+func (r *Report) Success() bool {
+	return r.Failed == 0
+}
+
+//Size is the number of bytes of input and output stored in the Report.
+func (r *Report) Size() int {
+	s := 0
+	for _, o := range r.Outcomes {
+		s += len(o.Input) + len(o.Expected) + len(o.Output) + len(o.Diff)
+	}
+	return s
+}
+
+//DiffLines splits the Outcome's diff into lines, skipping the file headers.
+func (o *Outcome) DiffLines() []DiffLine {
+	ls := strings.Split(strings.TrimRight(o.Diff, "\n"), "\n")
+	for len(ls) > 0 && (strings.HasPrefix(ls[0], "---") || strings.HasPrefix(ls[0], "+++")) {
+		ls = ls[1:]
+	}
+	ds := make([]DiffLine, len(ls))
+	for i, l := range ls {
+		ds[i] = DiffLine(l)
+	}
+	return ds
+}
+
+//Added checks whether the line is only in the actual output.
+func (l DiffLine) Added() bool {
+	return strings.HasPrefix(string(l), "+")
+}
+
+//Removed checks whether the line is only in the expected output.
+func (l DiffLine) Removed() bool {
+	return strings.HasPrefix(string(l), "-")
+}
+
+//String
+func (r *Report) String() string {
+	return fmt.Sprintf("Id: %q; Passed: %d; Failed: %d", r.Id, r.Passed, r.Failed)
+}
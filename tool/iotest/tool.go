@@ -0,0 +1,130 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package iotest provides a tool which runs a program with input/output test
+//cases and compares its output to the cases' expected output.
+package iotest
+
+import (
+	"github.com/godfried/impendulo/config"
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/diff"
+	"github.com/godfried/impendulo/tool/result"
+	"labix.org/v2/mgo/bson"
+
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type (
+	//Tool runs a project's cases on its compiled snapshots.
+	Tool struct {
+		cases []*Case
+		lang  tool.Language
+		cmd   string
+	}
+)
+
+const (
+	TIMEOUT = 10 * time.Second
+)
+
+//New creates a new Tool which runs cs on programs written in lang.
+func New(cs []*Case, lang tool.Language) (*Tool, error) {
+	t := &Tool{cases: cs, lang: lang}
+	if lang == tool.JAVA {
+		p, e := config.JAVA.Path()
+		if e != nil {
+			return nil, e
+		}
+		t.cmd = p
+	}
+	return t, nil
+}
+
+//Lang
+func (t *Tool) Lang() tool.Language {
+	return t.lang
+}
+
+//Name
+func (t *Tool) Name() string {
+	return NAME
+}
+
+//Run runs each case which applies to target. Targets without
+//any cases are skipped. A case fails if it times out or its
+//output or exit code differs from the expected values.
+func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
+	r := NewReport(bson.NewObjectId())
+	for _, c := range t.cases {
+		if !c.Applies(target.FullName()) {
+			continue
+		}
+		o, e := t.run(c, target)
+		if e != nil {
+			return nil, e
+		}
+		r.Add(o)
+	}
+	if r.Total() == 0 {
+		return nil, nil
+	}
+	return NewResult(fileId, r), nil
+}
+
+//run runs a single case. Errors which are caused by
+//the program are stored in the Outcome instead of being returned.
+func (t *Tool) run(c *Case, target *tool.Target) (*Outcome, error) {
+	a := append(t.args(target), c.Arguments()...)
+	r, e := tool.RunCommand(a, strings.NewReader(c.Input), TIMEOUT)
+	if e != nil && !tool.IsEndError(e) && !tool.IsTimeout(e) {
+		return nil, e
+	}
+	var so string
+	if r != nil {
+		so = string(r.StdOut)
+	}
+	x, _ := tool.ExitCode(e)
+	o := NewOutcome(c, so, x)
+	if tool.IsTimeout(e) {
+		o.Timeout, o.Passed = true, false
+	} else if e != nil && r.HasStdErr() && !o.Passed {
+		o.Error = truncate(string(r.StdErr))
+	}
+	if !o.Passed && !o.Timeout && c.Mode != REGEX {
+		//The diff is only an aid so failing to create one doesn't matter.
+		o.Diff, _ = diff.Diff(c.Expected, o.Output)
+	}
+	return o, nil
+}
+
+//args creates the command used to run target.
+func (t *Tool) args(target *tool.Target) []string {
+	if t.lang == tool.JAVA {
+		return []string{t.cmd, "-cp", target.Dir, target.Executable()}
+	}
+	return []string{filepath.Join(target.PackagePath(), target.Name)}
+}
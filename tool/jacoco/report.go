@@ -1,7 +1,6 @@
 package jacoco
 
 import (
-	"encoding/gob"
 	"encoding/xml"
 	"fmt"
 
@@ -61,10 +60,6 @@ type (
 	}
 )
 
-func init() {
-	gob.Register(new(Report))
-}
-
 //NewReport
 func NewReport(id bson.ObjectId, xmlData, htmlData []byte, target *tool.Target) (*Report, error) {
 	var r *Report
@@ -0,0 +1,172 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package javac
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Config specifies the javac options configured for a specific project.
+	Config struct {
+		Id        bson.ObjectId `bson:"_id"`
+		ProjectId bson.ObjectId `bson:"projectid"`
+		Time      int64         `bson:"time"`
+		Source    string        `bson:"source"`
+		Target    string        `bson:"target"`
+		Lint      []string      `bson:"lint"`
+		Flags     []string      `bson:"flags"`
+		Jars      []*Jar        `bson:"jars"`
+		//Pending configurations are not used on submissions until they have been
+		//validated against the project's reference solution and published.
+		Pending bool `bson:"pending"`
+	}
+	//Jar is an additional jar file which is added to the classpath when compiling.
+	Jar struct {
+		Name string `bson:"name"`
+		Data []byte `bson:"data"`
+	}
+)
+
+var (
+	levels = []string{"1.5", "1.6", "1.7", "1.8"}
+	lints  = []string{"all", "cast", "classfile", "deprecation", "dep-ann", "divzero", "empty", "fallthrough", "finally", "options", "overrides", "path", "processing", "rawtypes", "serial", "static", "try", "unchecked", "varargs", "none"}
+	//flagRegex matches the only flags without values which can be configured: debugging
+	//and diagnostic options which don't read or write other files.
+	flagRegex = regexp.MustCompile(`^-(g|g:none|g:(lines|vars|source)(,(lines|vars|source))*|nowarn|verbose|deprecation|parameters|proc:none|Werror|Xdiags:(compact|verbose))$`)
+	//valueFlags maps the only flags which take a value in the following argument
+	//and can be configured to the values they accept.
+	valueFlags = map[string]*regexp.Regexp{
+		"-encoding":  regexp.MustCompile(`^[\w.:-]+$`),
+		"-Xmaxerrs":  regexp.MustCompile(`^\d+$`),
+		"-Xmaxwarns": regexp.MustCompile(`^\d+$`),
+	}
+)
+
+//Levels lists the Java language levels javac can compile for.
+func Levels() []string {
+	return levels
+}
+
+//Lints lists the warning categories which can be enabled via -Xlint.
+func Lints() []string {
+	return lints
+}
+
+//String
+func (c *Config) String() string {
+	return "Type: javac.Config; Id: " + c.Id.Hex() +
+		"; ProjectId: " + c.ProjectId.Hex() +
+		"; Time: " + util.Date(c.Time)
+}
+
+//NewConfig creates a new javac configuration for a project. An empty source or target level
+//uses javac's default level and no lint categories enables all recommended warnings.
+//Lint categories can be disabled by prefixing them with a '-'. Only the flags
+//matching flagRegex or valueFlags can be configured.
+func NewConfig(pid bson.ObjectId, source, target string, lint, flags []string, jars []*Jar) (*Config, error) {
+	for _, l := range []string{source, target} {
+		if l != "" && index(levels, l) == -1 {
+			return nil, fmt.Errorf("invalid java level %s", l)
+		}
+	}
+	if source != "" && target != "" && index(levels, target) < index(levels, source) {
+		return nil, fmt.Errorf("target level %s is lower than source level %s", target, source)
+	}
+	for _, l := range lint {
+		if index(lints, strings.TrimPrefix(l, "-")) == -1 {
+			return nil, fmt.Errorf("invalid lint category %s", l)
+		}
+	}
+	for i := 0; i < len(flags); i++ {
+		if flagRegex.MatchString(flags[i]) {
+			continue
+		}
+		r, ok := valueFlags[flags[i]]
+		if !ok {
+			return nil, fmt.Errorf("javac flag %s cannot be configured", flags[i])
+		}
+		if i++; i == len(flags) || !r.MatchString(flags[i]) {
+			return nil, fmt.Errorf("javac flag %s needs a valid value", flags[i-1])
+		}
+	}
+	for _, j := range jars {
+		if j.Name = filepath.Base(j.Name); filepath.Ext(j.Name) != ".jar" {
+			return nil, fmt.Errorf("%s is not a jar file", j.Name)
+		}
+	}
+	return &Config{
+		Id:        bson.NewObjectId(),
+		ProjectId: pid,
+		Time:      util.CurMilis(),
+		Source:    source,
+		Target:    target,
+		Lint:      lint,
+		Flags:     flags,
+		Jars:      jars,
+	}, nil
+}
+
+//Args creates the javac command line options for this configuration.
+func (c *Config) Args() []string {
+	a := make([]string, 0, len(c.Flags)+5)
+	if c.Source != "" {
+		a = append(a, "-source", c.Source)
+	}
+	if c.Target != "" {
+		a = append(a, "-target", c.Target)
+	}
+	if len(c.Lint) == 0 {
+		a = append(a, "-Xlint")
+	} else {
+		a = append(a, "-Xlint:"+strings.Join(c.Lint, ","))
+	}
+	return append(a, c.Flags...)
+}
+
+//JarNames lists the names of this configuration's jar files.
+func (c *Config) JarNames() []string {
+	ns := make([]string, len(c.Jars))
+	for i, j := range c.Jars {
+		ns[i] = j.Name
+	}
+	return ns
+}
+
+//index finds the position of s in a or -1 if it is not present.
+func index(a []string, s string) int {
+	for i, v := range a {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -30,9 +30,90 @@ import (
 	"labix.org/v2/mgo/bson"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
+func TestConfig(t *testing.T) {
+	pid := bson.NewObjectId()
+	for _, c := range []struct {
+		source, target string
+		lint, flags    []string
+		jars           []*Jar
+	}{
+		{"1.4", "", nil, nil, nil},
+		{"1.7", "1.6", nil, nil, nil},
+		{"", "", []string{"everything"}, nil, nil},
+		{"", "", nil, []string{"-cp"}, nil},
+		{"", "", nil, []string{"-Xlint:all"}, nil},
+		{"", "", nil, []string{"g"}, nil},
+		{"", "", nil, []string{"-J-Xmx1g"}, nil},
+		{"", "", nil, []string{"-processorpath", "/tmp"}, nil},
+		{"", "", nil, []string{"@/etc/passwd"}, nil},
+		{"", "", nil, []string{"-encoding"}, nil},
+		{"", "", nil, []string{"-Xmaxerrs", "-d"}, nil},
+		{"", "", nil, nil, []*Jar{&Jar{Name: "lib.zip"}}},
+	} {
+		if _, e := NewConfig(pid, c.source, c.target, c.lint, c.flags, c.jars); e == nil {
+			t.Errorf("expected error for %v", c)
+		}
+	}
+	c, e := NewConfig(pid, "1.6", "1.7", []string{"all", "-serial"}, []string{"-g", "-encoding", "UTF-8"}, []*Jar{&Jar{Name: "/tmp/lib.jar"}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if a := strings.Join(c.Args(), " "); a != "-source 1.6 -target 1.7 -Xlint:all,-serial -g -encoding UTF-8" {
+		t.Errorf("invalid args %s", a)
+	}
+	if c.Jars[0].Name != "lib.jar" {
+		t.Errorf("expected jar name lib.jar, got %s", c.Jars[0].Name)
+	}
+	d, e := NewConfig(pid, "", "", nil, nil, nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if a := strings.Join(d.Args(), " "); a != "-Xlint" {
+		t.Errorf("invalid args %s", a)
+	}
+}
+
+func TestFileDiagnostics(t *testing.T) {
+	data := []byte(`/tmp/src/Other.java:3: error: ';' expected
+public class Other
+                  ^
+/tmp/src/Triangle.java:5: warning: [cast] redundant cast to int
+		int i = (int) 1;
+		        ^
+/tmp/src/Triangle.java:7: error: cannot find symbol
+		foo();
+		^
+  symbol:   method foo()
+  location: class Triangle
+Note: Some input files use unchecked or unsafe operations.
+2 errors
+1 warning`)
+	exp := `/tmp/src/Triangle.java:5: warning: [cast] redundant cast to int
+		int i = (int) 1;
+		        ^
+/tmp/src/Triangle.java:7: error: cannot find symbol
+		foo();
+		^
+  symbol:   method foo()
+  location: class Triangle
+1 warning
+1 error`
+	if d := string(fileDiagnostics(data, "/tmp/src/Triangle.java")); d != exp {
+		t.Errorf("invalid diagnostics %s", d)
+	}
+	if d := fileDiagnostics(data, "/tmp/src/Missing.java"); len(d) != 0 {
+		t.Errorf("expected no diagnostics, got %s", d)
+	}
+	r := NewReport(bson.NewObjectId(), fileDiagnostics(data, "/tmp/src/Triangle.java"))
+	if !r.Errors() || r.Count != 1 || len(r.Diagnostics) != 2 {
+		t.Errorf("invalid report %v", r)
+	}
+}
+
 func TestRun(t *testing.T) {
 	location := filepath.Join(os.TempDir(), "triangle")
 	target := tool.NewTarget("Triangle.java", "", location, tool.JAVA)
@@ -26,8 +26,10 @@ package javac
 
 import (
 	"bytes"
-	"encoding/gob"
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/diagnostic"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 	"labix.org/v2/mgo/bson"
 
 	"strconv"
@@ -52,22 +54,35 @@ type (
 		Count int `bson:"count"`
 		//Data is what was generated by compilation.
 		Data []byte `bson:"data"`
+		//Diagnostics are the explained errors and warnings in Data.
+		Diagnostics []*diagnostic.Diagnostic `bson:"diagnostics"`
 	}
 )
 
-func init() {
-	gob.Register(new(Report))
-}
-
 //NewReport
 func NewReport(id bson.ObjectId, data []byte) *Report {
 	data = bytes.TrimSpace(data)
 	return &Report{
-		Id:    id,
-		Type:  getType(data),
-		Count: calcCount(data),
-		Data:  data,
+		Id:          id,
+		Type:        getType(data),
+		Count:       calcCount(data),
+		Data:        data,
+		Diagnostics: diagnostic.Explain(diagnostic.Parse(data), diagnostic.Defaults(tool.JAVA)),
+	}
+}
+
+//Explain explains the report's diagnostics using the catalogue c.
+func (r *Report) Explain(c []*diagnostic.Explanation) {
+	r.Diagnostics = diagnostic.Explain(diagnostic.Parse(r.Data), c)
+}
+
+//Lines positions the report's diagnostics in the compiled code.
+func (r *Report) Lines() []*result.Line {
+	ls := make([]*result.Line, len(r.Diagnostics))
+	for i, d := range r.Diagnostics {
+		ls[i] = &result.Line{Title: d.Title(), Description: d.Description(), Start: d.Line, End: d.Line}
 	}
+	return ls
 }
 
 //Errors tells us if there were errors during compilation.
@@ -85,6 +100,15 @@ func (r *Report) Warnings() bool {
 	return r.Type == result.WARNINGS
 }
 
+//SARIF converts the compiler diagnostics for the file named n into SARIF results.
+func (r *Report) SARIF(n string) []*sarif.Result {
+	rs := sarif.Diagnostics(r.Data, n)
+	for _, sr := range rs {
+		sr.SetProperty(result.CATEGORY, result.COMPILE)
+	}
+	return rs
+}
+
 //Header generates a string which briefly describes the compilation.
 func (r *Report) Header() string {
 	if r.Success() {
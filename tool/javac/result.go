@@ -27,6 +27,7 @@ package javac
 import (
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 	"labix.org/v2/mgo/bson"
 )
 
@@ -36,12 +37,13 @@ const (
 
 type (
 	Result struct {
-		Id     bson.ObjectId `bson:"_id"`
-		FileId bson.ObjectId `bson:"fileid"`
-		Name   string        `bson:"name"`
-		Report *Report       `bson:"report"`
-		GridFS bool          `bson:"gridfs"`
-		Type   string        `bson:"type"`
+		Id      bson.ObjectId `bson:"_id"`
+		FileId  bson.ObjectId `bson:"fileid"`
+		Name    string        `bson:"name"`
+		Report  *Report       `bson:"report"`
+		GridFS  bool          `bson:"gridfs"`
+		Type    string        `bson:"type"`
+		Options []string      `bson:"options"`
 	}
 )
 
@@ -119,3 +121,20 @@ func NewResult(fileId bson.ObjectId, data []byte) *Result {
 		Type:   NAME,
 	}
 }
+
+//NewOptionsResult creates a new javac result which records the options used when compiling.
+func NewOptionsResult(fileId bson.ObjectId, data []byte, options []string) *Result {
+	r := NewResult(fileId, data)
+	r.Options = options
+	return r
+}
+
+//Lines
+func (r *Result) Lines() []*result.Line {
+	return r.Report.Lines()
+}
+
+//SARIF
+func (r *Result) SARIF(n string) []*sarif.Result {
+	return r.Report.SARIF(n)
+}
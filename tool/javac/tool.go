@@ -29,16 +29,36 @@ package javac
 import (
 	"github.com/godfried/impendulo/config"
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/diagnostic"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
 	"labix.org/v2/mgo/bson"
 
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"time"
 )
 
+var (
+	//diagnosticHeader matches the first line of a javac error or warning.
+	diagnosticHeader = regexp.MustCompile(`^(.+?):\d+: (error|warning): `)
+	//summary matches javac's count of errors or warnings.
+	summary = regexp.MustCompile(`^\d+ (error|warning)s?$`)
+	//otherErrors is reported when a whole project compilation fails only
+	//because of errors in files other than the target.
+	otherErrors = []byte("Other files in the submission did not compile.\n0 errors")
+)
+
 type (
 	Tool struct {
-		cmd string
-		cp  string
+		cmd       string
+		cp        string
+		whole     bool
+		config    *Config
+		catalogue []*diagnostic.Explanation
 	}
 )
 
@@ -51,6 +71,17 @@ func New(cp string) (*Tool, error) {
 	return &Tool{cp: cp, cmd: p}, nil
 }
 
+//NewProject creates a new javac instance which compiles all the Java source
+//files in a target's directory together with the target.
+func NewProject(cp string) (*Tool, error) {
+	t, e := New(cp)
+	if e != nil {
+		return nil, e
+	}
+	t.whole = true
+	return t, nil
+}
+
 //Lang is Java.
 func (t *Tool) Lang() tool.Language {
 	return tool.JAVA
@@ -68,25 +99,134 @@ func (t *Tool) AddCP(s string) {
 	t.cp += s
 }
 
-//Run compiles the Java source file specified by t. We compile with maximum warnings and compile
+//Configure makes t compile with the options in c. The configuration's jar files
+//are stored in dir and added to the classpath.
+func (t *Tool) Configure(c *Config, dir string) error {
+	for _, j := range c.Jars {
+		p := filepath.Join(dir, j.Name)
+		if e := util.SaveFile(p, j.Data); e != nil {
+			return e
+		}
+		t.AddCP(p)
+	}
+	t.config = c
+	return nil
+}
+
+//SetCatalogue makes t explain compiler diagnostics with the catalogue c
+//instead of the built-in catalogue.
+func (t *Tool) SetCatalogue(c []*diagnostic.Explanation) {
+	t.catalogue = c
+}
+
+//Options are the options used when compiling. All recommended warnings
+//are enabled if t has not been configured.
+func (t *Tool) Options() []string {
+	if t.config == nil {
+		return []string{"-Xlint"}
+	}
+	return t.config.Args()
+}
+
+//Run compiles the Java source file specified by t. We compile with the configured options and compile
 //classes implicitly loaded by the source code. All compilation results will be stored (success,
-//errors and warnings).
+//errors and warnings) along with the options used.
 func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
 	cp := t.cp
 	if cp != "" {
 		cp += ":"
 	}
 	cp += target.Dir
-	a := []string{t.cmd, "-cp", cp + ":" + target.Dir, "-implicit:class", "-Xlint", target.FilePath()}
+	o := t.Options()
+	a := append(append([]string{t.cmd, "-cp", cp + ":" + target.Dir, "-implicit:class"}, o...), target.FilePath())
+	if t.whole {
+		ss, e := sources(target)
+		if e != nil {
+			return nil, e
+		}
+		a = append(a, ss...)
+	}
 	r, e := tool.RunCommand(a, nil, 30*time.Second)
+	if e != nil && !tool.IsEndError(e) {
+		return nil, e
+	}
+	d := r.StdErr
+	if t.whole {
+		d = fileDiagnostics(d, target.FilePath())
+	}
 	if e != nil {
-		if !tool.IsEndError(e) {
-			return nil, e
+		if len(d) == 0 {
+			d = otherErrors
 		}
-		return NewResult(fileId, r.StdErr), tool.NewCompileError(target.FullName(), string(r.StdErr))
-	} else if r.HasStdErr() {
+		return t.newResult(fileId, d, o), tool.NewCompileError(target.FullName(), string(d))
+	} else if len(d) > 0 {
 		//Compiler warnings.
-		return NewResult(fileId, r.StdErr), nil
+		return t.newResult(fileId, d, o), nil
+	}
+	return t.newResult(fileId, result.COMPILE_SUCCESS, o), nil
+}
+
+//fileDiagnostics keeps only the diagnostics in javac's output data which concern the
+//file at p and adds a summary of their number. This stops errors and warnings in the
+//other files of a whole project compilation being reported as the target's.
+func fileDiagnostics(data []byte, p string) []byte {
+	b := new(bytes.Buffer)
+	es, ws := 0, 0
+	keep := false
+	for _, l := range bytes.Split(data, []byte("\n")) {
+		if m := diagnosticHeader.FindSubmatch(l); m != nil {
+			if keep = string(m[1]) == p; keep && string(m[2]) == "error" {
+				es++
+			} else if keep {
+				ws++
+			}
+		} else if summary.Match(l) || bytes.HasPrefix(l, []byte("Note: ")) {
+			keep = false
+		}
+		if keep {
+			b.Write(l)
+			b.WriteByte('\n')
+		}
+	}
+	if ws > 0 {
+		b.WriteString(count(ws, "warning") + "\n")
+	}
+	if es > 0 {
+		b.WriteString(count(es, "error") + "\n")
 	}
-	return NewResult(fileId, result.COMPILE_SUCCESS), nil
+	return bytes.TrimSpace(b.Bytes())
+}
+
+//count describes n occurences of s in javac's summary format.
+func count(n int, s string) string {
+	if n != 1 {
+		s += "s"
+	}
+	return strconv.Itoa(n) + " " + s
+}
+
+//newResult creates a javac result whose diagnostics are explained with t's catalogue.
+func (t *Tool) newResult(fileId bson.ObjectId, data []byte, o []string) *Result {
+	r := NewOptionsResult(fileId, data, o)
+	if t.catalogue != nil {
+		r.Report.Explain(t.catalogue)
+	}
+	return r
+}
+
+//sources retrieves the paths of all Java source files other than target
+//in target's directory.
+func sources(target *tool.Target) ([]string, error) {
+	ss := make([]string, 0, 10)
+	fp := target.FilePath()
+	e := filepath.Walk(target.Dir, func(p string, i os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		if !i.IsDir() && filepath.Ext(p) == "."+target.Ext && p != fp {
+			ss = append(ss, p)
+		}
+		return nil
+	})
+	return ss, e
 }
@@ -45,6 +45,9 @@ type (
 		Target    *tool.Target  `bson:"target"`
 		//Contains configured JPF properties
 		Data []byte `bson:"data"`
+		//Pending configurations are not used on submissions until they have been
+		//validated against the project's reference solution and published.
+		Pending bool `bson:"pending"`
 	}
 	empty struct{}
 )
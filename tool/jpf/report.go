@@ -25,7 +25,6 @@
 package jpf
 
 import (
-	"encoding/gob"
 	"encoding/xml"
 	"fmt"
 
@@ -120,11 +119,6 @@ type (
 	}
 )
 
-func init() {
-	//Register with gob so that we can store it in GridFS
-	gob.Register(new(Report))
-}
-
 //NewReport generates a new Report from the provided XML data.
 func NewReport(id bson.ObjectId, data []byte) (r *Report, e error) {
 	if e = xml.Unmarshal(data, &r); e != nil {
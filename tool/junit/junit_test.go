@@ -85,6 +85,121 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestNewReport(t *testing.T) {
+	r, e := NewReport(bson.NewObjectId(), reportXML)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if r.Tests != 3 || r.Skipped != 1 || len(r.Results) != 3 || r.Success() {
+		t.Errorf("Unexpected report %s.", r)
+	}
+	expected := []Status{ERROR, SKIPPED, PASSED}
+	for i, c := range r.Results {
+		if c.Status() != expected[i] {
+			t.Errorf("Expected %s for %s, got %s.", expected[i], c.Name, c.Status())
+		}
+	}
+	if p := r.Results[0].Problem(); p == nil || p.Message != "null" {
+		t.Errorf("Unexpected problem %v.", p)
+	}
+	if p := r.Results[2].Problem(); p != nil {
+		t.Errorf("Expected no problem, got %v.", p)
+	}
+	if vs := (&Result{Report: r}).ChartVals(); len(vs) != 5 || vs[2].Y != 0 || vs[4].Y != 1 {
+		t.Errorf("Unexpected chart values %v.", vs)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := map[string]Framework{
+		"import junit.framework.TestCase;":                  JUNIT3,
+		"import org.junit.Test;\nimport org.junit.Rule;":    JUNIT4,
+		"import org.junit.jupiter.api.Test;":                JUNIT5,
+		"import static org.junit.jupiter.api.Assertions.*;": JUNIT5,
+	}
+	for src, f := range tests {
+		if d := Detect([]byte(src)); d != f {
+			t.Errorf("Expected %s for %q, got %s.", f, src, d)
+		}
+	}
+	if JUNIT3.Platform() || !JUNIT4.Platform() || !JUNIT5.Platform() {
+		t.Error("Expected only JUnit 4 and 5 tests to run on the platform.")
+	}
+}
+
+func TestFailingMethods(t *testing.T) {
+	r, e := NewReport(bson.NewObjectId(), []byte(`<testsuite errors="1" failures="1" skipped="0" name="testing.AllTests" tests="3" time="0.2">
+<testcase classname="testing.AllTests" name="testSum(int) > [1] 1" method="testSum" time="0.1"><failure message="expected 1" type="org.opentest4j.AssertionFailedError">trace</failure></testcase>
+<testcase classname="testing.AllTests" name="testSum(int) > [2] 2" method="testSum" time="0.1"><error message="null" type="java.lang.NullPointerException">trace</error></testcase>
+<testcase classname="testing.AllTests" name="testValid()" method="testValid" time="0.1"/>
+</testsuite>`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if ms := r.FailingMethods(); len(ms) != 1 || ms[0] != "testSum" {
+		t.Errorf("Expected testSum to be rerun, got %v.", ms)
+	}
+}
+
+func TestTestRunner(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "missing")
+	j := &Tool{
+		test:   tool.NewTarget("AllTests.java", "testing", dir, tool.JAVA),
+		runner: tool.NewTarget("TestRunner.java", "testing", dir, tool.JAVA),
+	}
+	if _, _, e := j.testRunner(); e == nil {
+		t.Error("Expected error detecting framework of missing test file.")
+	}
+	j.SetFramework(JUNIT3)
+	r, _, e := j.testRunner()
+	if e != nil {
+		t.Error(e)
+	} else if r != j.runner {
+		t.Errorf("Expected ant runner for %s, got %s.", JUNIT3, r)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	r, e := NewReport(bson.NewObjectId(), reportXML)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if ms := r.FailingMethods(); len(ms) != 1 || ms[0] != "testNull" {
+		t.Errorf("Expected only testNull to be rerun, got %v.", ms)
+	}
+	//Testcases which passed in the first run are not considered even if a rerun includes them.
+	rerun, e := NewReport(bson.NewObjectId(), []byte(`<testsuite errors="0" failures="1" skipped="0" name="testing.AllTests" tests="2" time="0.2">
+<testcase classname="testing.AllTests" name="testValid" time="0.1"><failure message="expected true" type="junit.framework.AssertionFailedError">trace</failure></testcase>
+<testcase classname="testing.AllTests" name="testNull" time="0.2"/>
+</testsuite>`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	r.Merge(rerun)
+	if r.Runs != 2 || !r.Success() || r.Skipped != 1 || r.Tests != 3 {
+		t.Errorf("Unexpected report %s.", r)
+	}
+	fs := r.FlakyCases()
+	if len(fs) != 1 || fs[0].Name != "testNull" {
+		t.Errorf("Unexpected flaky cases %s.", fs)
+	}
+	if ms := r.FailingMethods(); len(ms) != 0 {
+		t.Errorf("Expected no failing methods, got %v.", ms)
+	}
+	if c := r.Results[0]; c.Status() != PASSED || c.Time != 0.2 {
+		t.Errorf("Expected rerun outcome for %s, got %s.", c.Name, c)
+	}
+	if c := r.Results[2]; c.Status() != PASSED {
+		t.Errorf("Expected %s to keep passing, got %s.", c.Name, c.Status())
+	}
+}
+
+var reportXML = []byte(`<testsuite errors="1" failures="0" skipped="1" name="testing.AllTests" tests="3" time="0.2">
+<testcase classname="testing.AllTests" name="testValid" time="0.1"/>
+<testcase classname="testing.AllTests" name="testNull" time="0.1"><error message="null" type="java.lang.NullPointerException">trace</error></testcase>
+<testcase classname="testing.AllTests" name="testSlow" time="0"><skipped/></testcase>
+</testsuite>`)
+
 var validFile = []byte(`
 package triangle;
  
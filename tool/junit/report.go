@@ -25,7 +25,6 @@
 package junit
 
 import (
-	"encoding/gob"
 	"encoding/xml"
 	"fmt"
 
@@ -45,23 +44,35 @@ type (
 		Errors int `xml:"errors,attr"`
 		//Failures is the number of test cases which produced
 		//an invalid result.
-		Failures int     `xml:"failures,attr"`
-		Name     string  `xml:"name,attr"`
-		Tests    int     `xml:"tests,attr"`
-		Time     float64 `xml:"time,attr"`
-		//Results is all the failed testcases.
+		Failures int `xml:"failures,attr"`
+		//Skipped is the number of test cases which were ignored.
+		Skipped int     `xml:"skipped,attr"`
+		Name    string  `xml:"name,attr"`
+		Tests   int     `xml:"tests,attr"`
+		Time    float64 `xml:"time,attr"`
+		//Results is all the testcases which were run.
 		Results TestCases `xml:"testcase"`
+		//Runs is the number of times the tests were run.
+		Runs int `xml:"-"`
 	}
 
-	//TestCase represents a failed testcase
+	//TestCase represents a testcase and its outcome. Fail is set if the testcase
+	//produced an invalid result, Err if it caused a runtime exception and Skip
+	//if it was ignored.
 	TestCase struct {
 		ClassName string   `xml:"classname,attr"`
 		Name      string   `xml:"name,attr"`
 		Time      float64  `xml:"time,attr"`
 		Fail      *Failure `xml:"failure"`
+		Err       *Failure `xml:"error"`
+		Skip      *Failure `xml:"skipped"`
+		//Method is the name of the method declaring the testcase if it differs from Name.
+		Method string `xml:"method,attr"`
+		//Flaky is set if the testcase's outcome differed between runs.
+		Flaky bool `xml:"-"`
 	}
 
-	//TestCases represents all the testcases which a class failed.
+	//TestCases represents all the testcases which a class ran.
 	//It implements sort.Sort
 	TestCases []*TestCase
 
@@ -71,11 +82,17 @@ type (
 		Type    string `xml:"type,attr"`
 		Value   string `xml:",innerxml"`
 	}
+
+	//Status is the outcome of running a testcase.
+	Status string
 )
 
-func init() {
-	gob.Register(new(Report))
-}
+const (
+	PASSED  Status = "Passed"
+	FAILED  Status = "Failed"
+	ERROR   Status = "Error"
+	SKIPPED Status = "Skipped"
+)
 
 //NewReport
 func NewReport(id bson.ObjectId, data []byte) (res *Report, err error) {
@@ -89,9 +106,73 @@ func NewReport(id bson.ObjectId, data []byte) (res *Report, err error) {
 	}
 	sort.Sort(res.Results)
 	res.Id = id
+	res.Runs = 1
 	return
 }
 
+//Merge adds the outcomes of a rerun of the failing tests, o, to the report. Only testcases
+//which are still failing are considered: they pass if they passed in the rerun and are
+//flaky if their outcome changed.
+func (this *Report) Merge(o *Report) {
+	cs := make(map[string]*TestCase, len(o.Results))
+	for _, c := range o.Results {
+		cs[c.ClassName+"."+c.Name] = c
+	}
+	for _, c := range this.Results {
+		if !c.Failing() {
+			continue
+		}
+		n, ok := cs[c.ClassName+"."+c.Name]
+		if !ok {
+			continue
+		}
+		if n.Status() != c.Status() {
+			c.Flaky = true
+		}
+		if c.Status() != PASSED && n.Status() == PASSED {
+			c.Time, c.Fail, c.Err, c.Skip = n.Time, nil, nil, nil
+		}
+	}
+	this.Runs++
+	this.Errors, this.Failures, this.Skipped = 0, 0, 0
+	for _, c := range this.Results {
+		switch c.Status() {
+		case ERROR:
+			this.Errors++
+		case FAILED:
+			this.Failures++
+		case SKIPPED:
+			this.Skipped++
+		}
+	}
+}
+
+//FailingMethods retrieves the names of the methods declaring the testcases which
+//failed or caused errors.
+func (this *Report) FailingMethods() []string {
+	var ms []string
+	seen := make(map[string]bool)
+	for _, c := range this.Results {
+		m := c.MethodName()
+		if c.Failing() && !seen[m] {
+			seen[m] = true
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+//FlakyCases retrieves the testcases whose outcomes differed between runs.
+func (this *Report) FlakyCases() TestCases {
+	var cs TestCases
+	for _, c := range this.Results {
+		if c.Flaky {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
 //Success
 func (this *Report) Success() bool {
 	return this.Errors == 0 && this.Failures == 0
@@ -123,6 +204,52 @@ func (this *TestCase) IsFailure() bool {
 	return this.Fail != nil && len(strings.TrimSpace(this.Fail.Type)) > 0
 }
 
+//IsError
+func (this *TestCase) IsError() bool {
+	return this.Err != nil
+}
+
+//Failing checks whether the testcase failed or caused an error.
+func (this *TestCase) Failing() bool {
+	return this.IsError() || this.IsFailure()
+}
+
+//MethodName is the name of the method declaring the testcase.
+func (this *TestCase) MethodName() string {
+	if this.Method != "" {
+		return this.Method
+	}
+	return this.Name
+}
+
+//Status determines the testcase's outcome.
+func (this *TestCase) Status() Status {
+	switch {
+	case this.IsError():
+		return ERROR
+	case this.IsFailure():
+		return FAILED
+	case this.Skip != nil:
+		return SKIPPED
+	default:
+		return PASSED
+	}
+}
+
+//Problem retrieves the details of why the testcase didn't pass, nil if it did.
+func (this *TestCase) Problem() *Failure {
+	switch this.Status() {
+	case ERROR:
+		return this.Err
+	case FAILED:
+		return this.Fail
+	case SKIPPED:
+		return this.Skip
+	default:
+		return nil
+	}
+}
+
 //Len
 func (this TestCases) Len() int {
 	return len(this)
@@ -91,12 +91,21 @@ func (r *Result) Reporter() result.Reporter {
 	return r.Report
 }
 
-//ChartVals
+//ChartVals retrieves the number of failures and errors followed by
+//each testcase's outcome, 1 if it passed and 0 otherwise.
 func (r *Result) ChartVals() []*result.ChartVal {
-	return []*result.ChartVal{
+	vs := []*result.ChartVal{
 		&result.ChartVal{Name: "Failures", Y: float64(r.Report.Failures), FileId: r.FileId},
 		&result.ChartVal{Name: "Errors", Y: float64(r.Report.Errors), FileId: r.FileId},
 	}
+	for _, c := range r.Report.Results {
+		y := 0.0
+		if c.Status() == PASSED {
+			y = 1.0
+		}
+		vs = append(vs, &result.ChartVal{Name: c.Name, Y: y, FileId: r.FileId})
+	}
+	return vs
 }
 
 func (r *Result) Template() string {
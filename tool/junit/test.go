@@ -26,6 +26,7 @@ package junit
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/util"
@@ -46,6 +47,16 @@ type (
 		Test []byte `bson:"test"`
 		//The data files needed for the test stored in a zip archive
 		Data []byte `bson:"data"`
+		//Pending tests are not run on submissions until they have been
+		//validated against the project's reference solution and published.
+		Pending bool `bson:"pending"`
+		//Framework is the version of JUnit the test is written for.
+		Framework Framework `bson:"framework"`
+		//Reruns is the number of times failing tests are run again
+		//to determine whether they are flaky.
+		Reruns int `bson:"reruns"`
+		//Replaces is the live test which a pending test replaces once it is published.
+		Replaces bson.ObjectId `bson:"replaces,omitempty"`
 	}
 
 	Type     int
@@ -53,6 +64,10 @@ type (
 		ID   Type
 		Name string
 	}
+
+	//Framework is a version of JUnit. JUnit 3 tests are run by ant
+	//while JUnit 4 and 5 tests are run on the JUnit Platform.
+	Framework string
 )
 
 const (
@@ -61,6 +76,17 @@ const (
 	USER
 )
 
+const (
+	//MAX_RERUNS is the maximum number of times failing tests can be rerun.
+	MAX_RERUNS = 5
+)
+
+const (
+	JUNIT3 Framework = "JUnit 3"
+	JUNIT4 Framework = "JUnit 4"
+	JUNIT5 Framework = "JUnit 5"
+)
+
 func TestTypes() []TestType {
 	return []TestType{{DEFAULT, DEFAULT.String()}, {USER, USER.String()}, {ADMIN, ADMIN.String()}}
 }
@@ -90,5 +116,33 @@ func NewTest(projectId bson.ObjectId, name string, tipe Type, target *tool.Targe
 		Target:    target,
 		Test:      test,
 		Data:      data,
+		Framework: Detect(test),
+	}
+}
+
+//SetReruns sets the number of times the test is rerun when it fails.
+func (t *Test) SetReruns(n int) error {
+	if n < 0 || n > MAX_RERUNS {
+		return fmt.Errorf("invalid number of reruns %d, at most %d are allowed", n, MAX_RERUNS)
+	}
+	t.Reruns = n
+	return nil
+}
+
+//Detect determines which version of JUnit a test is written
+//for from the packages it uses.
+func Detect(test []byte) Framework {
+	switch {
+	case bytes.Contains(test, []byte("org.junit.jupiter")):
+		return JUNIT5
+	case bytes.Contains(test, []byte("org.junit.")):
+		return JUNIT4
+	default:
+		return JUNIT3
 	}
 }
+
+//Platform checks whether tests written for f are run on the JUnit Platform.
+func (f Framework) Platform() bool {
+	return f == JUNIT4 || f == JUNIT5
+}
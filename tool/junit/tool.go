@@ -28,6 +28,7 @@ package junit
 
 import (
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/godfried/impendulo/config"
@@ -39,15 +40,18 @@ import (
 
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type (
 	//Tool is a tool.T used to run Tool tests on a Java source file.
 	Tool struct {
-		cp, name             string
-		dataLocation         string
-		test, target, runner *tool.Target
-		testId               bson.ObjectId
+		cp, name                       string
+		dataLocation                   string
+		test, target, runner, platform *tool.Target
+		testId                         bson.ObjectId
+		reruns                         int
+		framework                      Framework
 	}
 )
 
@@ -72,11 +76,33 @@ func New(test, target *tool.Target, toolDir string, testId bson.ObjectId) (*Tool
 		dataLocation: filepath.Join(test.PackagePath(), "data"),
 		test:         test,
 		runner:       tool.NewTarget("TestRunner.java", "testing", toolDir, tool.JAVA),
+		platform:     tool.NewTarget("PlatformRunner.java", "testing", toolDir, tool.JAVA),
 		target:       target,
 		testId:       testId,
 	}, nil
 }
 
+//testRunner retrieves the runner for the test along with the classpath it needs.
+//JUnit 4 and 5 tests are run on the JUnit Platform, older tests by ant.
+func (t *Tool) testRunner() (*tool.Target, string, error) {
+	f := t.framework
+	if f == "" {
+		d, e := ioutil.ReadFile(t.test.FilePath())
+		if e != nil {
+			return nil, "", e
+		}
+		f = Detect(d)
+	}
+	if !f.Platform() {
+		return t.runner, t.cp, nil
+	}
+	p, e := config.JUNIT_PLATFORM.Path()
+	if e != nil {
+		return nil, "", e
+	}
+	return t.platform, t.cp + ":" + p, nil
+}
+
 //Lang is Java
 func (t *Tool) Lang() tool.Language {
 	return tool.JAVA
@@ -86,8 +112,25 @@ func (t *Tool) Name() string {
 	return NAME + ":" + t.test.Name
 }
 
+//SetReruns sets the number of times failing tests are run again.
+func (t *Tool) SetReruns(n int) {
+	t.reruns = n
+}
+
+//SetFramework sets the version of JUnit the tests are written for.
+//It is detected from the test file if it has not been set.
+func (t *Tool) SetFramework(f Framework) {
+	t.framework = f
+}
+
+//Target is the class which the tests are run on.
+func (t *Tool) Target() *tool.Target {
+	return t.target
+}
+
 //Run runs a JUnit test on the provided Java source file. The source and test files are first
-//compiled and we run the tests via a Java runner class which uses ant to generate XML output.
+//compiled and we run the tests via a Java runner class which generates XML output. JUnit 3
+//tests are run with ant while JUnit 4 and 5 tests are run on the JUnit Platform.
 func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
 	if t.target.Executable() != target.Executable() {
 		return nil, fmt.Errorf("file executable %s does not match expected executable %s", target.Executable(), t.target.Executable())
@@ -96,7 +139,10 @@ func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, er
 	if e != nil {
 		return nil, e
 	}
-	cp := t.cp
+	rn, cp, e := t.testRunner()
+	if e != nil {
+		return nil, e
+	}
 	if cp != "" {
 		cp += ":"
 	}
@@ -109,30 +155,57 @@ func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, er
 	if _, e = c.Run(fileId, t.test); e != nil {
 		return nil, e
 	}
-	if _, e = c.Run(fileId, t.runner); e != nil {
+	if _, e = c.Run(fileId, rn); e != nil {
 		return nil, e
 	}
 	//Set the arguments
 	on := t.test.Name + "_junit"
 	od := target.PackagePath()
 	of := filepath.Join(od, t.test.Name+"_junit.xml")
-	a := []string{jp, "-cp", cp, t.runner.Executable(), t.test.Executable(), t.dataLocation, on, od}
-	defer os.Remove(of)
+	a := []string{jp, "-cp", cp, rn.Executable(), t.test.Executable(), t.dataLocation, on, od}
 	//Run the tests and load the result
-	r, re := tool.RunCommand(a, nil, 30*time.Second)
-	rf, oe := os.Open(of)
-	if oe != nil {
-		if re != nil {
-			return nil, re
-		}
-		return nil, fmt.Errorf("could not run junit: %q.", string(r.StdErr))
+	d, re := execute(a, of)
+	if d == nil {
+		return nil, re
 	}
-	nr, e := NewResult(fileId, t.testId, t.test.Name, util.ReadBytes(rf))
+	nr, e := NewResult(fileId, t.testId, t.test.Name, d)
 	if e != nil {
 		if re != nil {
 			e = re
 		}
 		return nil, e
 	}
+	//Failing tests are run again to determine whether they are flaky.
+	for i := 0; i < t.reruns && !nr.Report.Success(); i++ {
+		ms := nr.Report.FailingMethods()
+		if len(ms) == 0 {
+			break
+		}
+		if d, _ = execute(append(a, strings.Join(ms, ",")), of); d == nil {
+			break
+		}
+		rr, e := NewReport(nr.Id, d)
+		if e != nil {
+			break
+		}
+		nr.Report.Merge(rr)
+	}
 	return nr, nil
 }
+
+//execute runs the tests with command a and reads the XML output they write to of.
+//Tests which fail can still produce output so it is returned along with any error
+//from running the command.
+func execute(a []string, of string) ([]byte, error) {
+	defer os.Remove(of)
+	r, re := tool.RunCommand(a, nil, 30*time.Second)
+	rf, oe := os.Open(of)
+	if oe != nil {
+		if re != nil {
+			return nil, re
+		}
+		return nil, fmt.Errorf("could not run junit: %q.", string(r.StdErr))
+	}
+	defer rf.Close()
+	return util.ReadBytes(rf), re
+}
@@ -11,10 +11,13 @@ type (
 		ProjectId bson.ObjectId `bson:"projectid"`
 		Time      int64         `bson:"time"`
 		Data      []byte        `bson:"data"`
+		//Pending configurations are not used on submissions until they have been
+		//validated against the project's reference solution and published.
+		Pending bool `bson:"pending"`
 	}
 )
 
 func NewMakefile(projectId bson.ObjectId, data []byte) *Makefile {
 	id := bson.NewObjectId()
-	return &Makefile{id, projectId, util.CurMilis(), data}
+	return &Makefile{Id: id, ProjectId: projectId, Time: util.CurMilis(), Data: data}
 }
@@ -3,6 +3,7 @@ package make
 import (
 	"github.com/godfried/impendulo/config"
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/diagnostic"
 	"github.com/godfried/impendulo/tool/gcc"
 	"github.com/godfried/impendulo/tool/result"
 	"github.com/godfried/impendulo/util"
@@ -13,8 +14,9 @@ import (
 
 type (
 	Tool struct {
-		cmd  string
-		path string
+		cmd       string
+		path      string
+		catalogue []*diagnostic.Explanation
 	}
 )
 
@@ -40,6 +42,12 @@ func New(mf *Makefile, dir string) (*Tool, error) {
 func (t *Tool) AddCP(p string) {
 }
 
+//SetCatalogue makes t explain compiler diagnostics with the catalogue c
+//instead of the built-in catalogue.
+func (t *Tool) SetCatalogue(c []*diagnostic.Explanation) {
+	t.catalogue = c
+}
+
 //Lang
 func (t *Tool) Lang() tool.Language {
 	return tool.C
@@ -57,14 +65,26 @@ func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, er
 			return nil, e
 		}
 		//Unsuccessfull compile.
-		nr, e := gcc.NewResult(fileId, r.StdErr)
+		nr, e := t.newResult(fileId, r.StdErr)
 		if e != nil {
 			return nil, e
 		}
 		return nr, tool.NewCompileError(target.FullName(), string(r.StdErr))
 	} else if r.HasStdErr() {
 		//Compiler warnings.
-		return gcc.NewResult(fileId, r.StdErr)
+		return t.newResult(fileId, r.StdErr)
+	}
+	return t.newResult(fileId, result.COMPILE_SUCCESS)
+}
+
+//newResult creates a gcc result whose diagnostics are explained with t's catalogue.
+func (t *Tool) newResult(fileId bson.ObjectId, data []byte) (result.Tooler, error) {
+	r, e := gcc.NewResult(fileId, data)
+	if e != nil {
+		return nil, e
+	}
+	if t.catalogue != nil {
+		r.(*gcc.Result).Report.Explain(t.catalogue)
 	}
-	return gcc.NewResult(fileId, result.COMPILE_SUCCESS)
+	return r, nil
 }
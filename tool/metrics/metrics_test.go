@@ -0,0 +1,149 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package metrics
+
+import (
+	"labix.org/v2/mgo/bson"
+
+	"testing"
+)
+
+var (
+	javaSrc = `package triangle;
+
+import java.util.List;
+
+/**
+ * A triangle { with braces in a comment.
+ */
+public class Triangle {
+	private int[] sides = {1, 2, 3};
+
+	public Triangle(int a, int b, int c) {
+		sides = new int[]{a, b, c};
+	}
+
+	//Checks whether the triangle is valid.
+	public boolean valid() {
+		String s = "if (while) {";
+		if (sides[0] > 0 && sides[1] > 0 || sides[2] > 0) {
+			for (int i = 0; i < 3; i++) {
+				if (sides[i] > 10) {
+					return false;
+				}
+			}
+		}
+		return sides.length == 3 ? true : false;
+	}
+
+	public int count(List<? extends Number> ns) throws Exception {
+		Runnable r = new Runnable() {
+			public void run() {
+				try {
+					System.out.println('{');
+				} catch (Exception e) {
+				}
+			}
+		};
+		switch (ns.size()) {
+		case 0:
+			return 0;
+		case 1:
+			return 1;
+		default:
+			return 2;
+		}
+	}
+}
+`
+	cSrc = `#include <stdio.h>
+#define MAX(a, b) { (a) > (b) ? (a) : (b) }
+
+struct point {
+	int x, y;
+};
+
+/* Adds two numbers. */
+int add(int a, int b) {
+	while (a > 0) {
+		a--;
+		b++;
+	}
+	return b;
+}
+
+int main(void) {
+	printf("%d\n", add(1, 2));
+	return 0;
+}`
+)
+
+func TestNewReport(t *testing.T) {
+	r := NewReport(bson.NewObjectId(), []byte(javaSrc))
+	if r.Lines != 46 || r.Code != 37 || r.Comments != 4 || r.Classes != 1 {
+		t.Errorf("Unexpected report %s.", r)
+	}
+	expected := []*Method{
+		&Method{Name: "Triangle", Start: 11, End: 13, Complexity: 1, Depth: 0},
+		&Method{Name: "valid", Start: 16, End: 26, Complexity: 7, Depth: 3},
+		&Method{Name: "count", Start: 28, End: 45, Complexity: 3, Depth: 1},
+		&Method{Name: "run", Start: 30, End: 35, Complexity: 2, Depth: 1},
+	}
+	if len(r.Methods) != len(expected) {
+		t.Fatalf("Expected %d methods but got %v.", len(expected), r.Methods)
+	}
+	for i, m := range expected {
+		if *r.Methods[i] != *m {
+			t.Errorf("Expected %s but got %s.", m, r.Methods[i])
+		}
+	}
+	if r.Complexity != 13 || r.MaxComplexity != 7 || r.Depth != 3 || r.MethodLength != 18 {
+		t.Errorf("Unexpected report %s.", r)
+	}
+	r = NewReport(bson.NewObjectId(), []byte(cSrc))
+	if r.Lines != 20 || r.Code != 16 || r.Comments != 1 || r.Classes != 1 || len(r.Methods) != 2 {
+		t.Fatalf("Unexpected report %s %v.", r, r.Methods)
+	}
+	if m := r.Methods[0]; m.Name != "add" || m.Complexity != 2 || m.Length() != 7 {
+		t.Errorf("Unexpected method %s.", m)
+	}
+	if m := r.Methods[1]; m.Name != "main" || m.Complexity != 1 || m.End != 20 {
+		t.Errorf("Unexpected method %s.", m)
+	}
+}
+
+func TestChartVals(t *testing.T) {
+	r := NewResult(bson.NewObjectId(), []byte(cSrc))
+	vs := r.ChartVals()
+	if len(vs) != len(Criteria()) || vs[0].Name != LINES || vs[0].Y != 16 {
+		t.Errorf("Unexpected chart values %v.", vs)
+	}
+	if v := r.ChartVal(METHODS); v == nil || v.Y != 2 {
+		t.Errorf("Unexpected chart value %v.", v)
+	}
+	if v := r.ChartVal("Unknown"); v != nil {
+		t.Errorf("Unexpected chart value %v.", v)
+	}
+}
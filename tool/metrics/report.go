@@ -0,0 +1,357 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package metrics
+
+import (
+	"fmt"
+
+	"labix.org/v2/mgo/bson"
+
+	"regexp"
+	"strings"
+)
+
+type (
+	//Report contains the size and complexity metrics of a source file.
+	//Code is the number of lines containing code and Comments the number of
+	//lines containing comments. Complexity is the total cyclomatic complexity
+	//of the file's methods, Depth the deepest nesting of blocks within a method
+	//and MethodLength the number of lines in the longest method.
+	Report struct {
+		Id            bson.ObjectId `bson:"_id"`
+		Lines         int           `bson:"lines"`
+		Code          int           `bson:"code"`
+		Comments      int           `bson:"comments"`
+		Classes       int           `bson:"classes"`
+		Complexity    int           `bson:"complexity"`
+		MaxComplexity int           `bson:"maxcomplexity"`
+		Depth         int           `bson:"depth"`
+		MethodLength  int           `bson:"methodlength"`
+		Methods       []*Method     `bson:"methods"`
+	}
+
+	//Method contains the metrics of a single method or function.
+	Method struct {
+		Name       string `bson:"name"`
+		Start      int    `bson:"start"`
+		End        int    `bson:"end"`
+		Complexity int    `bson:"complexity"`
+		Depth      int    `bson:"depth"`
+	}
+
+	//block is an open block of code.
+	block struct {
+		kind   int
+		method *Method
+	}
+)
+
+const (
+	//The kinds of blocks.
+	OTHER = iota
+	CLASS
+	METHOD
+	INITIALISER
+)
+
+var (
+	classMatcher     = regexp.MustCompile(`\b(class|interface|enum|struct|union)\s+\w+[^()]*$`)
+	anonymousMatcher = regexp.MustCompile(`\bnew\s+[\w.<>\[\], ]+\(.*\)\s*$`)
+	methodMatcher    = regexp.MustCompile(`(\w+)\s*\(([^()]|\([^()]*\))*\)\s*(throws\s+[\w.,\s]+)?$`)
+	keywords         = map[string]bool{
+		"if": true, "for": true, "while": true, "switch": true, "catch": true, "synchronized": true,
+		"return": true, "new": true, "else": true, "do": true, "try": true, "sizeof": true,
+	}
+	decisions = map[string]bool{"if": true, "for": true, "while": true, "case": true, "catch": true}
+)
+
+//NewReport calculates the metrics of the source file data. C preprocessor
+//directives are counted as code but otherwise ignored.
+func NewReport(id bson.ObjectId, data []byte) *Report {
+	r := &Report{Id: id, Methods: make([]*Method, 0, 10)}
+	c := r.clean(data)
+	r.analyse(c)
+	for _, m := range r.Methods {
+		r.Complexity += m.Complexity
+		if m.Complexity > r.MaxComplexity {
+			r.MaxComplexity = m.Complexity
+		}
+		if m.Depth > r.Depth {
+			r.Depth = m.Depth
+		}
+		if l := m.Length(); l > r.MethodLength {
+			r.MethodLength = l
+		}
+	}
+	return r
+}
+
+//clean counts the file's lines and removes comments, the contents of literals
+//and preprocessor directives from data, retaining all line breaks.
+func (r *Report) clean(data []byte) []byte {
+	const (
+		code = iota
+		line
+		block
+		str
+		char
+	)
+	c := make([]byte, len(data))
+	s := code
+	hasCode, hasComment, directive := false, false, false
+	endLine := func() {
+		r.Lines++
+		if hasCode {
+			r.Code++
+		}
+		if hasComment {
+			r.Comments++
+		}
+		hasCode, hasComment = false, false
+	}
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		c[i] = ' '
+		if b == '\n' {
+			c[i] = b
+			endLine()
+			if s == line {
+				s = code
+			}
+			if directive && (i == 0 || data[i-1] != '\\') {
+				directive = false
+			}
+			continue
+		}
+		var next byte
+		if i+1 < len(data) {
+			next = data[i+1]
+		}
+		switch s {
+		case code:
+			switch {
+			case b == '/' && next == '/':
+				s = line
+				hasComment = true
+				i++
+				c[i] = ' '
+			case b == '/' && next == '*':
+				s = block
+				hasComment = true
+				i++
+				c[i] = ' '
+			case b == ' ' || b == '\t' || b == '\r':
+			default:
+				hasCode = true
+				if b == '#' && strings.TrimSpace(string(c[lineStart(c, i):i])) == "" {
+					directive = true
+				}
+				if directive {
+					continue
+				}
+				c[i] = b
+				if b == '"' {
+					s = str
+				} else if b == '\'' {
+					s = char
+				}
+			}
+		case line:
+		case block:
+			if b == '*' && next == '/' {
+				s = code
+				hasComment = true
+				i++
+				c[i] = ' '
+			} else if b != ' ' && b != '\t' && b != '\r' {
+				hasComment = true
+			}
+		case str, char:
+			hasCode = true
+			if b == '\\' {
+				i++
+				if i < len(data) && data[i] == '\n' {
+					c[i] = '\n'
+					endLine()
+				}
+			} else if (s == str && b == '"') || (s == char && b == '\'') {
+				c[i] = b
+				s = code
+			}
+		}
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		endLine()
+	}
+	return c
+}
+
+//lineStart retrieves the index of the start of the line containing index i.
+func lineStart(d []byte, i int) int {
+	for i > 0 && d[i-1] != '\n' {
+		i--
+	}
+	return i
+}
+
+//analyse finds the classes and methods in the cleaned source c and
+//calculates the complexity and nesting depth of each method.
+func (r *Report) analyse(c []byte) {
+	var bs []*block
+	var h []byte
+	l, parens := 1, 0
+	current := func() *Method {
+		for i := len(bs) - 1; i >= 0; i-- {
+			if bs[i].kind == CLASS {
+				return nil
+			} else if bs[i].kind == METHOD {
+				return bs[i].method
+			}
+		}
+		return nil
+	}
+	for i := 0; i < len(c); i++ {
+		b := c[i]
+		switch {
+		case b == '\n':
+			l++
+			h = append(h, ' ')
+		case isWordStart(b):
+			j := i + 1
+			for j < len(c) && isWordPart(c[j]) {
+				j++
+			}
+			w := string(c[i:j])
+			if m := current(); m != nil && decisions[w] {
+				m.Complexity++
+			}
+			h = append(h, c[i:j]...)
+			i = j - 1
+		case b == '{':
+			var p *block
+			if len(bs) > 0 {
+				p = bs[len(bs)-1]
+			}
+			bl := r.open(strings.TrimSpace(string(h)), l, current(), p)
+			bs = append(bs, bl)
+			if bl.kind == OTHER {
+				if m := current(); m != nil {
+					d := 0
+					for k := len(bs) - 1; k >= 0 && bs[k].kind == OTHER; k-- {
+						d++
+					}
+					if d > m.Depth {
+						m.Depth = d
+					}
+				}
+			}
+			h, parens = h[:0], 0
+		case b == '}':
+			if len(bs) > 0 {
+				if bl := bs[len(bs)-1]; bl.kind == METHOD {
+					bl.method.End = l
+				}
+				bs = bs[:len(bs)-1]
+			}
+			h, parens = h[:0], 0
+		case b == ';' && parens == 0:
+			h = h[:0]
+		default:
+			if b == '(' {
+				parens++
+			} else if b == ')' && parens > 0 {
+				parens--
+			}
+			if m := current(); m != nil && isDecision(c, i) {
+				m.Complexity++
+			}
+			h = append(h, b)
+		}
+	}
+}
+
+//open determines what kind of block is opened by header h on line l in block p.
+//Methods can only be declared in a class or at the top level.
+func (r *Report) open(h string, l int, m *Method, p *block) *block {
+	switch {
+	case (p != nil && p.kind == INITIALISER) || strings.HasSuffix(h, "=") || strings.HasSuffix(h, "]") || strings.HasSuffix(h, ","):
+		return &block{kind: INITIALISER}
+	case anonymousMatcher.MatchString(h):
+		return &block{kind: CLASS}
+	case classMatcher.MatchString(h):
+		r.Classes++
+		return &block{kind: CLASS}
+	case m == nil && (p == nil || p.kind == CLASS):
+		ms := methodMatcher.FindStringSubmatch(h)
+		if ms == nil || keywords[ms[1]] {
+			break
+		}
+		nm := &Method{Name: ms[1], Start: l, End: l, Complexity: 1}
+		r.Methods = append(r.Methods, nm)
+		return &block{kind: METHOD, method: nm}
+	}
+	return &block{kind: OTHER}
+}
+
+//isDecision checks whether the operator at index i of c is a logical
+//operator or a conditional operator. Java wildcards aren't counted.
+func isDecision(c []byte, i int) bool {
+	switch c[i] {
+	case '&', '|':
+		return i+1 < len(c) && c[i+1] == c[i] && (i == 0 || c[i-1] != c[i])
+	case '?':
+		for j := i - 1; j >= 0; j-- {
+			if c[j] == ' ' || c[j] == '\t' || c[j] == '\n' {
+				continue
+			}
+			return c[j] != '<' && c[j] != ','
+		}
+	}
+	return false
+}
+
+func isWordStart(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isWordPart(b byte) bool {
+	return isWordStart(b) || (b >= '0' && b <= '9')
+}
+
+//Length is the number of lines in the method.
+func (m *Method) Length() int {
+	return m.End - m.Start + 1
+}
+
+//String
+func (m *Method) String() string {
+	return fmt.Sprintf("Name: %s; Lines: %d-%d; Complexity: %d; Depth: %d", m.Name, m.Start, m.End, m.Complexity, m.Depth)
+}
+
+//String
+func (r *Report) String() string {
+	return fmt.Sprintf("Id: %q; Lines: %d; Code: %d; Comments: %d; Classes: %d; Methods: %d; Complexity: %d",
+		r.Id, r.Lines, r.Code, r.Comments, r.Classes, len(r.Methods), r.Complexity)
+}
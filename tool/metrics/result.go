@@ -0,0 +1,145 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool/result"
+	"labix.org/v2/mgo/bson"
+)
+
+const (
+	NAME = "Metrics"
+	//The names of the metrics charted for each snapshot.
+	LINES          = "Lines of Code"
+	CLASSES        = "Classes"
+	METHODS        = "Methods"
+	COMPLEXITY     = "Complexity"
+	MAX_COMPLEXITY = "Max Complexity"
+	DEPTH          = "Nesting Depth"
+	METHOD_LENGTH  = "Method Length"
+)
+
+type (
+	Result struct {
+		Id     bson.ObjectId `bson:"_id"`
+		FileId bson.ObjectId `bson:"fileid"`
+		Name   string        `bson:"name"`
+		Report *Report       `bson:"report"`
+		GridFS bool          `bson:"gridfs"`
+		Type   string        `bson:"type"`
+	}
+)
+
+//Criteria retrieves the names of the metrics which are charted for each snapshot
+//and can therefore be used to compare submissions.
+func Criteria() []string {
+	return []string{LINES, CLASSES, METHODS, COMPLEXITY, MAX_COMPLEXITY, DEPTH, METHOD_LENGTH}
+}
+
+func (r *Result) GetType() string {
+	return r.Type
+}
+
+//SetReport
+func (r *Result) SetReport(report result.Reporter) {
+	if report == nil {
+		r.Report = nil
+	} else {
+		r.Report = report.(*Report)
+	}
+}
+
+//OnGridFS
+func (r *Result) OnGridFS() bool {
+	return r.GridFS
+}
+
+//String
+func (r *Result) String() string {
+	return fmt.Sprintf("Id: %q; FileId: %q; Name: %s; \nReport: %s\n",
+		r.Id, r.FileId, r.Name, r.Report.String())
+}
+
+//GetName
+func (r *Result) GetName() string {
+	return r.Name
+}
+
+//GetId
+func (r *Result) GetId() bson.ObjectId {
+	return r.Id
+}
+
+//GetFileId
+func (r *Result) GetFileId() bson.ObjectId {
+	return r.FileId
+}
+
+func (r *Result) GetTestId() bson.ObjectId {
+	return ""
+}
+
+func (r *Result) Reporter() result.Reporter {
+	return r.Report
+}
+
+//ChartVals charts each of the metrics in Criteria, lines of code first.
+func (r *Result) ChartVals() []*result.ChartVal {
+	vs := []int{r.Report.Code, r.Report.Classes, len(r.Report.Methods), r.Report.Complexity,
+		r.Report.MaxComplexity, r.Report.Depth, r.Report.MethodLength}
+	cs := make([]*result.ChartVal, len(vs))
+	for i, n := range Criteria() {
+		cs[i] = &result.ChartVal{Name: n, Y: float64(vs[i]), FileId: r.FileId}
+	}
+	return cs
+}
+
+//ChartVal retrieves the value of the metric named n or nil if there is no such metric.
+func (r *Result) ChartVal(n string) *result.ChartVal {
+	for _, v := range r.ChartVals() {
+		if v.Name == n {
+			return v
+		}
+	}
+	return nil
+}
+
+func (r *Result) Template() string {
+	return "metricsresult"
+}
+
+//NewResult calculates the metrics of source file data.
+func NewResult(fileId bson.ObjectId, data []byte) *Result {
+	id := bson.NewObjectId()
+	return &Result{
+		Id:     id,
+		FileId: fileId,
+		Name:   NAME,
+		Report: NewReport(id, data),
+		Type:   NAME,
+	}
+}
@@ -0,0 +1,67 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package metrics calculates size and complexity metrics of Java and C source files.
+//The metrics are calculated directly from the source code so that they are cheap
+//enough to calculate for every snapshot, even those which don't compile.
+package metrics
+
+import (
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/result"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+)
+
+type (
+	//Tool is an implementation of tool.T which calculates a source file's metrics.
+	Tool struct {
+		lang tool.Language
+	}
+)
+
+//New creates a metrics Tool for source files in language l.
+func New(l tool.Language) *Tool {
+	return &Tool{lang: l}
+}
+
+//Lang
+func (t *Tool) Lang() tool.Language {
+	return t.lang
+}
+
+//Name is Metrics
+func (t *Tool) Name() string {
+	return NAME
+}
+
+//Run calculates the metrics of the target's source file.
+func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
+	d, e := ioutil.ReadFile(target.FilePath())
+	if e != nil {
+		return nil, e
+	}
+	return NewResult(fileId, d), nil
+}
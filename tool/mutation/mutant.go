@@ -0,0 +1,213 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mutation
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	//Mutant is a copy of a source file with a single small change. A test suite
+	//kills a mutant if at least one of its tests fails when run against it.
+	Mutant struct {
+		Operator    string `bson:"operator"`
+		Line        int    `bson:"line"`
+		Original    string `bson:"original"`
+		Replacement string `bson:"replacement"`
+		Status      string `bson:"status"`
+		//Killer is the name of the first test which failed when run against the mutant.
+		Killer string `bson:"killer"`
+		offset int
+	}
+)
+
+const (
+	//The mutation operators, named after the equivalent PIT mutators.
+	CONDITIONALS_BOUNDARY = "Conditionals Boundary"
+	NEGATE_CONDITIONALS   = "Negate Conditionals"
+	MATH                  = "Math"
+	INCREMENTS            = "Increments"
+	BOOLEANS              = "Booleans"
+	//The statuses of a mutant.
+	KILLED   = "Killed"
+	SURVIVED = "Survived"
+	INVALID  = "Invalid"
+)
+
+var (
+	//mutations maps each operator and keyword which can be mutated to its
+	//mutation operator and replacements.
+	mutations = map[string]struct {
+		operator     string
+		replacements []string
+	}{
+		"<":     {CONDITIONALS_BOUNDARY, []string{"<=", ">="}},
+		"<=":    {CONDITIONALS_BOUNDARY, []string{"<", ">"}},
+		">":     {CONDITIONALS_BOUNDARY, []string{">=", "<="}},
+		">=":    {CONDITIONALS_BOUNDARY, []string{">", "<"}},
+		"==":    {NEGATE_CONDITIONALS, []string{"!="}},
+		"!=":    {NEGATE_CONDITIONALS, []string{"=="}},
+		"+":     {MATH, []string{"-"}},
+		"-":     {MATH, []string{"+"}},
+		"*":     {MATH, []string{"/"}},
+		"/":     {MATH, []string{"*"}},
+		"%":     {MATH, []string{"*"}},
+		"++":    {INCREMENTS, []string{"--"}},
+		"--":    {INCREMENTS, []string{"++"}},
+		"true":  {BOOLEANS, []string{"false"}},
+		"false": {BOOLEANS, []string{"true"}},
+	}
+	//operators are all Java operators which start with a character that can be mutated,
+	//longest first, so that only complete operators are mutated.
+	operators = []string{
+		">>>=", "<<=", ">>=", ">>>", "->", "++", "--", "==", "!=", "<=", ">=",
+		"+=", "-=", "*=", "/=", "%=", "<<", ">>", "<", ">", "+", "-", "*", "/", "%",
+	}
+)
+
+//Mutants creates a mutant for each possible mutation of the Java source in data.
+//Comments and literals are not mutated and neither are angle brackets which appear
+//to enclose generic type parameters. The mutants are ordered by their position in data.
+func Mutants(data []byte) []*Mutant {
+	ms := make([]*Mutant, 0, 20)
+	s := string(data)
+	l := 1
+	add := func(i int, o string) {
+		m, ok := mutations[o]
+		if !ok {
+			return
+		}
+		for _, r := range m.replacements {
+			ms = append(ms, &Mutant{Operator: m.operator, Line: l, Original: o, Replacement: r, offset: i})
+		}
+	}
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '\n':
+			l++
+			i++
+		case strings.HasPrefix(s[i:], "//"):
+			if j := strings.Index(s[i:], "\n"); j != -1 {
+				i += j
+			} else {
+				i = len(s)
+			}
+		case strings.HasPrefix(s[i:], "/*"):
+			j := skip(s, i+2, "*/")
+			l += strings.Count(s[i:j], "\n")
+			i = j
+		case c == '"' || c == '\'':
+			j := literalEnd(s, i)
+			l += strings.Count(s[i:j], "\n")
+			i = j
+		case isWord(c):
+			j := i + 1
+			for j < len(s) && (isWord(s[j]) || (s[j] >= '0' && s[j] <= '9')) {
+				j++
+			}
+			add(i, s[i:j])
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(s) && (isWord(s[j]) || (s[j] >= '0' && s[j] <= '9') || s[j] == '.') {
+				j++
+			}
+			i = j
+		default:
+			o := string(c)
+			for _, op := range operators {
+				if strings.HasPrefix(s[i:], op) {
+					o = op
+					break
+				}
+			}
+			if !generic(s, i, o) {
+				add(i, o)
+			}
+			i += len(o)
+		}
+	}
+	return ms
+}
+
+//Apply creates the mutant's source from the original source data.
+func (m *Mutant) Apply(data []byte) []byte {
+	r := make([]byte, 0, len(data)+len(m.Replacement))
+	r = append(r, data[:m.offset]...)
+	r = append(r, m.Replacement...)
+	return append(r, data[m.offset+len(m.Original):]...)
+}
+
+//String
+func (m *Mutant) String() string {
+	return fmt.Sprintf("%s: line %d: %s changed to %s", m.Operator, m.Line, m.Original, m.Replacement)
+}
+
+//generic checks whether the angle bracket o at index i encloses generic type parameters.
+//This is assumed if it is adjacent to a type name or another angle bracket.
+func generic(s string, i int, o string) bool {
+	if o != "<" && o != ">" && o != ">>" && o != ">>>" {
+		return false
+	}
+	if i+len(o) < len(s) && (s[i+len(o)] == '>' || s[i+len(o)] == '(') {
+		return true
+	}
+	j := i - 1
+	for j >= 0 && isWord(s[j]) {
+		j--
+	}
+	return j+1 < i && s[j+1] >= 'A' && s[j+1] <= 'Z' || i > 0 && (s[i-1] == '>' || s[i-1] == '?')
+}
+
+//skip retrieves the index after the first occurrence of t in s from index i
+//or the length of s if t doesn't occur.
+func skip(s string, i int, t string) int {
+	j := strings.Index(s[i:], t)
+	if j == -1 {
+		return len(s)
+	}
+	return i + j + len(t)
+}
+
+//literalEnd retrieves the index after the string or character literal starting at index i.
+func literalEnd(s string, i int) int {
+	q := s[i]
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return i
+}
+
+func isWord(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$'
+}
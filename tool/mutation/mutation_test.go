@@ -0,0 +1,117 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mutation
+
+import (
+	"labix.org/v2/mgo/bson"
+
+	"strings"
+	"testing"
+)
+
+var (
+	src = `package triangle;
+
+import java.util.List;
+import java.util.ArrayList;
+
+public class Triangle {
+	private List<Integer> sides = new ArrayList<>();
+
+	//Checks whether a <= b.
+	public boolean valid(int a, int b) {
+		String s = "a < b";
+		if (a <= b && a != 0) {
+			a++;
+			return a * 2 > b;
+		}
+		return false;
+	}
+}
+`
+)
+
+func TestMutants(t *testing.T) {
+	ms := Mutants([]byte(src))
+	exp := []struct {
+		line     int
+		original string
+		replaced []string
+	}{
+		{12, "<=", []string{"<", ">"}},
+		{12, "!=", []string{"=="}},
+		{13, "++", []string{"--"}},
+		{14, "*", []string{"/"}},
+		{14, ">", []string{">=", "<="}},
+		{16, "false", []string{"true"}},
+	}
+	i := 0
+	for _, x := range exp {
+		for _, r := range x.replaced {
+			if i >= len(ms) {
+				t.Fatalf("Expected more than %d mutants.", len(ms))
+			}
+			m := ms[i]
+			if m.Line != x.line || m.Original != x.original || m.Replacement != r {
+				t.Errorf("Expected mutant %d to change %s to %s on line %d but got %s.", i, x.original, r, x.line, m)
+			}
+			i++
+		}
+	}
+	if i != len(ms) {
+		t.Errorf("Expected %d mutants but got %d.", i, len(ms))
+	}
+	if a := string(ms[3].Apply([]byte(src))); !strings.Contains(a, "\ta--;") || strings.Contains(a, "a++") || len(a) != len(src) {
+		t.Errorf("Expected increment to be mutated but got %q.", a)
+	}
+}
+
+func TestSample(t *testing.T) {
+	ms := make([]*Mutant, 50)
+	for i := range ms {
+		ms[i] = &Mutant{Line: i}
+	}
+	s := sample(ms, 20)
+	if len(s) != 20 || s[0].Line != 0 || s[19].Line != 47 {
+		t.Errorf("Invalid sample %v.", s)
+	}
+	if len(sample(ms[:10], 20)) != 10 {
+		t.Error("Expected all mutants to be sampled.")
+	}
+}
+
+func TestReport(t *testing.T) {
+	r := NewReport(bson.NewObjectId(), 3)
+	for _, s := range []string{KILLED, KILLED, KILLED, SURVIVED, INVALID} {
+		r.Add(&Mutant{Operator: MATH, Line: 5, Original: "+", Replacement: "-", Status: s})
+	}
+	if r.Killed != 3 || r.Survived != 1 || r.Invalid != 1 || r.Score() != 75 {
+		t.Errorf("Invalid report %s.", r)
+	}
+	ls := r.Lines()
+	if len(ls) != 1 || ls[0].Start != 5 || ls[0].Description != "+ changed to -" {
+		t.Errorf("Expected surviving mutant's line but got %v.", ls)
+	}
+}
@@ -0,0 +1,107 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package mutation
+
+import (
+	"fmt"
+
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+)
+
+type (
+	//Report contains the mutants created from a snapshot and whether a test suite
+	//killed them. Invalid mutants didn't compile and are not used to calculate the
+	//score. Skipped is the number of possible mutants which were not created
+	//because there were more than MAX_MUTANTS.
+	Report struct {
+		Id       bson.ObjectId `bson:"_id"`
+		Tests    int           `bson:"tests"`
+		Killed   int           `bson:"killed"`
+		Survived int           `bson:"survived"`
+		Invalid  int           `bson:"invalid"`
+		Skipped  int           `bson:"skipped"`
+		Mutants  []*Mutant     `bson:"mutants"`
+	}
+)
+
+//NewReport creates an empty Report for a test suite containing n tests.
+func NewReport(id bson.ObjectId, n int) *Report {
+	return &Report{Id: id, Tests: n, Mutants: make([]*Mutant, 0, MAX_MUTANTS)}
+}
+
+//Add adds a mutant which has been run to the Report.
+func (r *Report) Add(m *Mutant) {
+	switch m.Status {
+	case KILLED:
+		r.Killed++
+	case SURVIVED:
+		r.Survived++
+	default:
+		r.Invalid++
+	}
+	r.Mutants = append(r.Mutants, m)
+}
+
+//Score is the percentage of valid mutants which were killed.
+func (r *Report) Score() float64 {
+	if r.Killed+r.Survived == 0 {
+		return 0
+	}
+	return util.Round(float64(r.Killed)/float64(r.Killed+r.Survived)*100.0, 2)
+}
+
+//Survivors retrieves the mutants which the test suite didn't kill.
+func (r *Report) Survivors() []*Mutant {
+	ms := make([]*Mutant, 0, r.Survived)
+	for _, m := range r.Mutants {
+		if m.Status == SURVIVED {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+//Lines marks the lines containing mutants which survived.
+func (r *Report) Lines() []*result.Line {
+	ms := r.Survivors()
+	ls := make([]*result.Line, len(ms))
+	for i, m := range ms {
+		ls[i] = &result.Line{
+			Title:       m.Operator + " mutant survived",
+			Description: m.Original + " changed to " + m.Replacement,
+			Start:       m.Line,
+			End:         m.Line,
+		}
+	}
+	return ls
+}
+
+//String
+func (r *Report) String() string {
+	return fmt.Sprintf("Id: %q; Tests: %d; Killed: %d; Survived: %d; Invalid: %d; Skipped: %d; Score: %f",
+		r.Id, r.Tests, r.Killed, r.Survived, r.Invalid, r.Skipped, r.Score())
+}
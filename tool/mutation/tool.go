@@ -0,0 +1,176 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package mutation assesses the quality of a test suite by running it against
+//mutants of the code it tests, in the same way as PIT (http://pitest.org/).
+//Each mutant contains a single small change such as a negated condition or a
+//changed arithmetic operator. A good test suite fails on most mutants.
+package mutation
+
+import (
+	"errors"
+
+	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/javac"
+	"github.com/godfried/impendulo/tool/junit"
+	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/util"
+	"labix.org/v2/mgo/bson"
+
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+type (
+	//Tool is a tool.T which runs a JUnit test suite against mutants of a Java source file.
+	Tool struct {
+		dir          string
+		test, target *tool.Target
+		junit        *junit.Tool
+		testId       bson.ObjectId
+	}
+)
+
+const (
+	//MAX_MUTANTS is the maximum number of mutants run for a snapshot.
+	MAX_MUTANTS = 20
+)
+
+var (
+	FailingError = errors.New("tests fail on the original code")
+)
+
+//New creates a new instance of the mutation Tool. test is the JUnit test suite which is
+//run against mutants of target. toolDir is the location of the submission's tool directory.
+func New(test, target *tool.Target, toolDir string, testId bson.ObjectId) (*Tool, error) {
+	j, e := junit.New(test, target, toolDir, testId)
+	if e != nil {
+		return nil, e
+	}
+	return &Tool{
+		dir:    filepath.Join(toolDir, "mutants", testId.Hex()),
+		test:   test,
+		target: target,
+		junit:  j,
+		testId: testId,
+	}, nil
+}
+
+//Lang is Java
+func (t *Tool) Lang() tool.Language {
+	return tool.JAVA
+}
+
+func (t *Tool) Name() string {
+	return NAME + ":" + t.test.Name
+}
+
+//Run runs the test suite against mutants of the provided Java source file. The test suite
+//must pass on the original file since a failing suite would appear to kill every mutant.
+//If there are more than MAX_MUTANTS possible mutants, an evenly spread sample is run.
+func (t *Tool) Run(fileId bson.ObjectId, target *tool.Target) (result.Tooler, error) {
+	if t.target.Executable() != target.Executable() {
+		return nil, nil
+	}
+	d, e := ioutil.ReadFile(target.FilePath())
+	if e != nil {
+		return nil, e
+	}
+	r, e := t.junit.Run(fileId, target)
+	if e != nil {
+		return nil, e
+	}
+	jr := r.(*junit.Result).Report
+	if !jr.Success() {
+		return nil, FailingError
+	}
+	c, e := javac.New("")
+	if e != nil {
+		return nil, e
+	}
+	defer os.RemoveAll(t.dir)
+	all := Mutants(d)
+	ms := sample(all, MAX_MUTANTS)
+	rp := NewReport(bson.NewObjectId(), jr.Tests)
+	rp.Skipped = len(all) - len(ms)
+	for _, m := range ms {
+		if e = t.kill(fileId, target, c, m, d); e != nil {
+			return nil, e
+		}
+		rp.Add(m)
+	}
+	return NewResult(fileId, t.testId, t.test.Name, rp), nil
+}
+
+//kill runs the test suite against mutant m of target, whose source is data, and
+//sets the mutant's status. The mutant is created in a copy of target's directory.
+func (t *Tool) kill(fileId bson.ObjectId, target *tool.Target, c *javac.Tool, m *Mutant, data []byte) error {
+	if e := os.RemoveAll(t.dir); e != nil {
+		return e
+	}
+	if e := util.Copy(t.dir, target.Dir); e != nil {
+		return e
+	}
+	mt := tool.NewTarget(target.FullName(), target.Package, t.dir, target.Lang)
+	if e := util.SaveFile(mt.FilePath(), m.Apply(data)); e != nil {
+		return e
+	}
+	if _, e := c.Run(fileId, mt); e != nil {
+		m.Status = INVALID
+		return nil
+	}
+	r, e := t.junit.Run(fileId, mt)
+	if e != nil {
+		//The suite passed on the original code so it was the mutant which caused
+		//the tests to time out or crash.
+		m.Status = KILLED
+		return nil
+	}
+	jr := r.(*junit.Result).Report
+	if jr.Success() {
+		m.Status = SURVIVED
+		return nil
+	}
+	m.Status = KILLED
+	for _, tc := range jr.Results {
+		if tc.IsFailure() {
+			m.Killer = tc.Name
+			break
+		}
+	}
+	return nil
+}
+
+//sample retrieves n mutants evenly spread through ms.
+func sample(ms []*Mutant, n int) []*Mutant {
+	if len(ms) <= n {
+		return ms
+	}
+	s := make([]*Mutant, n)
+	for i := range s {
+		s[i] = ms[i*len(ms)/n]
+	}
+	return s
+}
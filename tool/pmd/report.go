@@ -25,12 +25,12 @@
 package pmd
 
 import (
-	"encoding/gob"
 	"encoding/xml"
 	"fmt"
 
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 
 	"html/template"
 
@@ -74,10 +74,6 @@ type (
 	}
 )
 
-func init() {
-	gob.Register(new(Report))
-}
-
 //NewReport generates a new Report from XML generated by PMD.
 func NewReport(id bson.ObjectId, data []byte) (*Report, error) {
 	var r *Report
@@ -122,6 +118,54 @@ func (r *Report) String() string {
 	return s + "}\n"
 }
 
+//SARIF converts the violations found in the file named n into SARIF results.
+func (r *Report) SARIF(n string) []*sarif.Result {
+	f := r.File(n)
+	if f == nil {
+		return []*sarif.Result{}
+	}
+	rs := make([]*sarif.Result, 0, len(f.Violations))
+	for _, v := range f.Violations {
+		for i, s := range v.Starts {
+			sr := sarif.NewResult(v.Rule, priorityLevel(v.Priority), v.Description, s, v.Ends[i])
+			sr.SetProperty("ruleset", v.RuleSet)
+			sr.SetProperty(result.CATEGORY, category(v.RuleSet))
+			rs = append(rs, sr)
+		}
+	}
+	return rs
+}
+
+//category determines the issue category of a PMD rule from its ruleset.
+func category(ruleset string) string {
+	switch strings.ToLower(ruleset) {
+	case "design", "code size", "coupling":
+		return result.DESIGN
+	case "optimization":
+		return result.PERFORMANCE
+	case "security code guidelines":
+		return result.SECURITY
+	case "junit":
+		return result.TEST
+	case "naming", "braces", "code style", "comments", "import statements", "unnecessary", "controversial", "unused code":
+		return result.STYLE
+	default:
+		return result.BUG
+	}
+}
+
+//priorityLevel converts a PMD priority into a SARIF level.
+func priorityLevel(p int) string {
+	switch {
+	case p <= 2:
+		return sarif.ERROR
+	case p <= 4:
+		return sarif.WARNING
+	default:
+		return sarif.NOTE
+	}
+}
+
 //File retrieves a File whose name ends with the provided name.
 func (r *Report) File(name string) *File {
 	for _, f := range r.Files {
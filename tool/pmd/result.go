@@ -27,6 +27,7 @@ package pmd
 import (
 	"github.com/godfried/impendulo/tool"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
 	"labix.org/v2/mgo/bson"
 )
 
@@ -125,3 +126,8 @@ func NewResult(fileId bson.ObjectId, data []byte) (*Result, error) {
 		Report: r,
 	}, nil
 }
+
+//SARIF
+func (r *Result) SARIF(n string) []*sarif.Result {
+	return r.Report.SARIF(n)
+}
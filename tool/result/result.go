@@ -27,6 +27,7 @@ package result
 import (
 	"fmt"
 
+	"github.com/godfried/impendulo/tool/sarif"
 	"labix.org/v2/mgo/bson"
 
 	"strings"
@@ -94,6 +95,13 @@ type (
 		Template() string
 	}
 
+	//Sarifer is used to export the issues found by a tool in the SARIF format.
+	Sarifer interface {
+		GetName() string
+		//SARIF converts the issues found in the file named n into SARIF results.
+		SARIF(n string) []*sarif.Result
+	}
+
 	Coder interface {
 		GetName() string
 		Lines() []*Line
@@ -0,0 +1,267 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package sarif provides data structures for reading and writing analysis
+//results in the Static Analysis Results Interchange Format (SARIF) version 2.1.0.
+//See http://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html for more information.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	//Log is the root of a SARIF file.
+	Log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []*Run `json:"runs"`
+	}
+
+	//Run contains the results of a single analysis tool.
+	Run struct {
+		Tool    *Tool     `json:"tool"`
+		Results []*Result `json:"results"`
+	}
+
+	//Tool describes the analysis tool which produced a Run.
+	Tool struct {
+		Driver *Driver `json:"driver"`
+	}
+
+	Driver struct {
+		Name           string `json:"name"`
+		InformationUri string `json:"informationUri,omitempty"`
+	}
+
+	//Result is a single issue detected by an analysis tool.
+	Result struct {
+		RuleId     string                 `json:"ruleId,omitempty"`
+		Level      string                 `json:"level,omitempty"`
+		Message    *Message               `json:"message"`
+		Locations  []*Location            `json:"locations,omitempty"`
+		Properties map[string]interface{} `json:"properties,omitempty"`
+	}
+
+	Message struct {
+		Text string `json:"text"`
+	}
+
+	Location struct {
+		PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+	}
+
+	PhysicalLocation struct {
+		ArtifactLocation *ArtifactLocation `json:"artifactLocation,omitempty"`
+		Region           *Region           `json:"region,omitempty"`
+	}
+
+	ArtifactLocation struct {
+		Uri string `json:"uri"`
+	}
+
+	//Region specifies the lines and columns of a Location.
+	Region struct {
+		StartLine   int `json:"startLine,omitempty"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+)
+
+var (
+	//diagnostic matches compiler diagnostics of the form file:line[:column]: level: [rule] message [rule]
+	//as produced by javac and gcc.
+	diagnostic = regexp.MustCompile(`(?m)^(.+?):(\d+):(?:(\d+):)? (?:fatal )?(error|warning|note): (?:\[([\w-]+)\] )?(.*?)(?: \[(-W[^\]]+)\])?$`)
+)
+
+const (
+	VERSION = "2.1.0"
+	SCHEMA  = "https://json.schemastore.org/sarif-2.1.0.json"
+	//Result levels.
+	ERROR   = "error"
+	WARNING = "warning"
+	NOTE    = "note"
+	NONE    = "none"
+)
+
+//NewLog creates an empty SARIF Log.
+func NewLog() *Log {
+	return &Log{Schema: SCHEMA, Version: VERSION, Runs: make([]*Run, 0, 10)}
+}
+
+//Parse reads a SARIF Log from JSON data.
+func Parse(data []byte) (*Log, error) {
+	var l *Log
+	if e := json.Unmarshal(data, &l); e != nil {
+		return nil, e
+	}
+	if l == nil || l.Runs == nil {
+		return nil, fmt.Errorf("no runs found in SARIF log")
+	}
+	return l, nil
+}
+
+//Run retrieves the Log's Run for the tool named n. A new Run is created
+//if the Log does not have one yet.
+func (l *Log) Run(n string) *Run {
+	for _, r := range l.Runs {
+		if r.Tool != nil && r.Tool.Driver != nil && r.Tool.Driver.Name == n {
+			return r
+		}
+	}
+	r := NewRun(n)
+	l.Runs = append(l.Runs, r)
+	return r
+}
+
+//JSON encodes the Log.
+func (l *Log) JSON() ([]byte, error) {
+	return json.MarshalIndent(l, "", "  ")
+}
+
+//NewRun creates an empty Run for the tool named n.
+func NewRun(n string) *Run {
+	return &Run{Tool: &Tool{Driver: &Driver{Name: n}}, Results: make([]*Result, 0, 10)}
+}
+
+//Name retrieves the name of the tool which produced the Run.
+func (r *Run) Name() string {
+	if r.Tool == nil || r.Tool.Driver == nil {
+		return ""
+	}
+	return r.Tool.Driver.Name
+}
+
+//NewResult creates a Result for an issue spanning lines start to end.
+//A line of 0 means the issue has no specific location.
+func NewResult(rule, level, msg string, start, end int) *Result {
+	r := &Result{RuleId: rule, Level: level, Message: &Message{Text: strings.TrimSpace(msg)}}
+	if start > 0 {
+		if end < start {
+			end = start
+		}
+		r.Locations = []*Location{&Location{PhysicalLocation: &PhysicalLocation{Region: &Region{StartLine: start, EndLine: end}}}}
+	}
+	return r
+}
+
+//physical retrieves the Result's first physical location, creating it if it doesn't exist.
+func (r *Result) physical() *PhysicalLocation {
+	if len(r.Locations) == 0 {
+		r.Locations = []*Location{&Location{}}
+	}
+	if r.Locations[0].PhysicalLocation == nil {
+		r.Locations[0].PhysicalLocation = &PhysicalLocation{}
+	}
+	return r.Locations[0].PhysicalLocation
+}
+
+//SetUri sets the uri of the artifact in which the issue was detected.
+func (r *Result) SetUri(u string) {
+	r.physical().ArtifactLocation = &ArtifactLocation{Uri: u}
+}
+
+//Uri retrieves the uri of the artifact in which the issue was detected.
+func (r *Result) Uri() string {
+	if len(r.Locations) == 0 || r.Locations[0].PhysicalLocation == nil || r.Locations[0].PhysicalLocation.ArtifactLocation == nil {
+		return ""
+	}
+	return r.Locations[0].PhysicalLocation.ArtifactLocation.Uri
+}
+
+//Lines retrieves the first and last line of the issue. Both are 0 if it has no region.
+func (r *Result) Lines() (int, int) {
+	if len(r.Locations) == 0 || r.Locations[0].PhysicalLocation == nil || r.Locations[0].PhysicalLocation.Region == nil {
+		return 0, 0
+	}
+	g := r.Locations[0].PhysicalLocation.Region
+	if g.EndLine < g.StartLine {
+		return g.StartLine, g.StartLine
+	}
+	return g.StartLine, g.EndLine
+}
+
+//Text retrieves the Result's message.
+func (r *Result) Text() string {
+	if r.Message == nil {
+		return ""
+	}
+	return r.Message.Text
+}
+
+//SetProperty adds a property to the Result's property bag.
+func (r *Result) SetProperty(k string, v interface{}) {
+	if r.Properties == nil {
+		r.Properties = make(map[string]interface{})
+	}
+	r.Properties[k] = v
+}
+
+//Level converts a tool specific severity into a SARIF level.
+func Level(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "error", "fatal", "high", "failure", "1":
+		return ERROR
+	case "info", "information", "note", "low", "3":
+		return NOTE
+	case "ignore", "none":
+		return NONE
+	default:
+		return WARNING
+	}
+}
+
+//Matches checks whether uri refers to the file named n.
+func Matches(uri, n string) bool {
+	return uri == n || strings.HasSuffix(uri, "/"+n) || strings.HasSuffix(uri, "\\"+n)
+}
+
+//Diagnostics extracts the compiler diagnostics reported for the file named n
+//from a compiler's output.
+func Diagnostics(data []byte, n string) []*Result {
+	ms := diagnostic.FindAllSubmatch(data, -1)
+	rs := make([]*Result, 0, len(ms))
+	for _, m := range ms {
+		if !Matches(string(m[1]), n) {
+			continue
+		}
+		l, _ := strconv.Atoi(string(m[2]))
+		rule := string(m[5])
+		if rule == "" {
+			rule = string(m[7])
+		}
+		r := NewResult(rule, Level(string(m[4])), string(m[6]), l, l)
+		if c, e := strconv.Atoi(string(m[3])); e == nil {
+			r.Locations[0].PhysicalLocation.Region.StartColumn = c
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
@@ -0,0 +1,95 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package sarif
+
+import (
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	javac := []byte(`/tmp/src/triangle/Triangle.java:5: error: ';' expected
+		int height = triangle.length - 2
+		                                ^
+/tmp/src/triangle/Triangle.java:9: warning: [unchecked] unchecked call to add(E)
+/tmp/src/triangle/Util.java:3: error: cannot find symbol
+2 errors
+1 warning`)
+	gcc := []byte(`main.c:3:5: warning: unused variable 'i' [-Wunused-variable]
+main.c:7:1: error: expected ';' before '}' token`)
+	tests := []struct {
+		data  []byte
+		name  string
+		rules []string
+		lines []int
+		level []string
+	}{
+		{javac, "Triangle.java", []string{"", "unchecked"}, []int{5, 9}, []string{ERROR, WARNING}},
+		{gcc, "main.c", []string{"-Wunused-variable", ""}, []int{3, 7}, []string{WARNING, ERROR}},
+	}
+	for _, test := range tests {
+		rs := Diagnostics(test.data, test.name)
+		if len(rs) != len(test.rules) {
+			t.Errorf("Expected %d results for %s but got %d.", len(test.rules), test.name, len(rs))
+			continue
+		}
+		for i, r := range rs {
+			s, _ := r.Lines()
+			if r.RuleId != test.rules[i] || s != test.lines[i] || r.Level != test.level[i] {
+				t.Errorf("Unexpected result %s %s %d for %s.", r.RuleId, r.Level, s, test.name)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	l := NewLog()
+	r := NewResult("rule", WARNING, " message ", 3, 0)
+	r.SetUri("triangle/Triangle.java")
+	r.SetProperty("user", "student")
+	run := l.Run("tool")
+	run.Results = append(run.Results, r)
+	if l.Run("tool") != run {
+		t.Error("Expected existing run.")
+	}
+	d, e := l.JSON()
+	if e != nil {
+		t.Error(e)
+	}
+	p, e := Parse(d)
+	if e != nil {
+		t.Error(e)
+	}
+	if len(p.Runs) != 1 || p.Runs[0].Name() != "tool" || len(p.Runs[0].Results) != 1 {
+		t.Fatalf("Unexpected log %s.", d)
+	}
+	pr := p.Runs[0].Results[0]
+	s, en := pr.Lines()
+	if pr.RuleId != "rule" || pr.Text() != "message" || pr.Uri() != "triangle/Triangle.java" || s != 3 || en != 3 || pr.Properties["user"] != "student" {
+		t.Errorf("Unexpected result %s.", d)
+	}
+	if _, e = Parse([]byte(`{"version": "2.1.0"}`)); e == nil {
+		t.Error("Expected error for log without runs.")
+	}
+}
@@ -0,0 +1,373 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package similarity
+
+import (
+	"github.com/godfried/impendulo/project"
+	"labix.org/v2/mgo/bson"
+
+	"hash/fnv"
+	"strings"
+)
+
+type (
+	//Source is the token stream of a submission's source files. The files'
+	//tokens are separated by a token which never matches another token.
+	Source struct {
+		files  []*file
+		tokens []uint64
+		lines  []int
+		file   []int
+		ignore []bool
+	}
+
+	file struct {
+		id   bson.ObjectId
+		name string
+	}
+
+	//Grams is a set of hashes of MIN_MATCH consecutive tokens.
+	Grams map[uint64]bool
+
+	//Pair is the similarity between the source of two submissions. Similarity is
+	//the fraction of both submissions' tokens which are part of Matches.
+	Pair struct {
+		Id         bson.ObjectId `bson:"_id"`
+		ProjectId  bson.ObjectId `bson:"projectid"`
+		A          *Side         `bson:"a"`
+		B          *Side         `bson:"b"`
+		Similarity float64       `bson:"similarity"`
+		Matched    int           `bson:"matched"`
+		Matches    []*Match      `bson:"matches"`
+		Time       int64         `bson:"time"`
+	}
+
+	//Side is one of the submissions in a Pair. Tokens is the number of the submission's
+	//tokens which were compared and Appearance, if set, the snapshot in which the largest
+	//part of the code it shares with the other submission appeared.
+	Side struct {
+		SubId      bson.ObjectId `bson:"subid"`
+		User       string        `bson:"user"`
+		Tokens     int           `bson:"tokens"`
+		Appearance *Appearance   `bson:"appearance,omitempty"`
+		source     *Source
+		grams      map[bson.ObjectId]Grams
+		total      int
+	}
+
+	//Match is a sequence of tokens found in both submissions of a Pair.
+	Match struct {
+		Tokens int     `bson:"tokens"`
+		A      *Region `bson:"a"`
+		B      *Region `bson:"b"`
+	}
+
+	//Region is the lines of a file containing a Match.
+	Region struct {
+		FileId bson.ObjectId `bson:"fileid"`
+		Name   string        `bson:"name"`
+		Start  int           `bson:"start"`
+		End    int           `bson:"end"`
+	}
+
+	//Appearance is a snapshot in which a fraction of the code a submission
+	//shares with another submission was added to one of its files.
+	Appearance struct {
+		FileId   bson.ObjectId `bson:"fileid"`
+		Name     string        `bson:"name"`
+		Time     int64         `bson:"time"`
+		Fraction float64       `bson:"fraction"`
+	}
+)
+
+const (
+	//MIN_MATCH is the minimum number of tokens in a Match.
+	MIN_MATCH = 9
+	//THRESHOLD is the similarity from which a Pair's snapshots are checked
+	//for shared code which appeared suddenly.
+	THRESHOLD = 0.3
+	//SUDDEN is the fraction of shared code which must have appeared in a
+	//single snapshot for its appearance to be flagged.
+	SUDDEN = 0.5
+)
+
+//NewSource creates an empty Source.
+func NewSource() *Source {
+	return &Source{files: make([]*file, 0, 5)}
+}
+
+//Add tokenises the file named n with id and contents data and adds it to the Source.
+func (s *Source) Add(id bson.ObjectId, n string, data []byte) {
+	fi := len(s.files)
+	s.files = append(s.files, &file{id: id, name: n})
+	for _, t := range Tokenise(data) {
+		s.tokens = append(s.tokens, hash(t.Text))
+		s.lines = append(s.lines, t.Line)
+		s.file = append(s.file, fi)
+		s.ignore = append(s.ignore, false)
+	}
+	s.tokens = append(s.tokens, 0)
+	s.lines = append(s.lines, 0)
+	s.file = append(s.file, fi)
+	s.ignore = append(s.ignore, true)
+}
+
+//Len is the number of tokens in the Source which aren't ignored.
+func (s *Source) Len() int {
+	n := 0
+	for _, i := range s.ignore {
+		if !i {
+			n++
+		}
+	}
+	return n
+}
+
+//Exclude ignores all tokens which are part of a gram in g. This is used to
+//prevent code provided by a project's skeleton from being matched.
+func (s *Source) Exclude(g Grams) {
+	for i := range s.tokens {
+		if h, ok := s.gram(i); ok && g[h] {
+			for j := i; j < i+MIN_MATCH; j++ {
+				s.ignore[j] = true
+			}
+		}
+	}
+}
+
+//Grams retrieves all grams in the Source.
+func (s *Source) Grams() Grams {
+	g := make(Grams, len(s.tokens))
+	for i := range s.tokens {
+		if h, ok := s.gram(i); ok {
+			g[h] = true
+		}
+	}
+	return g
+}
+
+//gram calculates the hash of the MIN_MATCH tokens starting at index i.
+//False is returned if they are not all in the same file.
+func (s *Source) gram(i int) (uint64, bool) {
+	if i+MIN_MATCH > len(s.tokens) {
+		return 0, false
+	}
+	var h uint64
+	for _, t := range s.tokens[i : i+MIN_MATCH] {
+		if t == 0 {
+			return 0, false
+		}
+		h = h*1099511628211 + t
+	}
+	return h, true
+}
+
+//region retrieves the Region containing the n tokens starting at index i.
+func (s *Source) region(i, n int) *Region {
+	f := s.files[s.file[i]]
+	return &Region{FileId: f.id, Name: f.name, Start: s.lines[i], End: s.lines[i+n-1]}
+}
+
+//NewSide creates a Side for the submission sid by user u with source s.
+func NewSide(sid bson.ObjectId, u string, s *Source) *Side {
+	return &Side{SubId: sid, User: u, Tokens: s.Len(), source: s, grams: make(map[bson.ObjectId]Grams)}
+}
+
+//addGrams records the grams of the n matched tokens starting at index i.
+func (s *Side) addGrams(i, n int) {
+	id := s.source.files[s.source.file[i]].id
+	g, ok := s.grams[id]
+	if !ok {
+		g = make(Grams)
+		s.grams[id] = g
+	}
+	for j := i; j+MIN_MATCH <= i+n; j++ {
+		if h, ok := s.source.gram(j); ok && !g[h] {
+			g[h] = true
+			s.total++
+		}
+	}
+}
+
+//MatchedFiles retrieves the ids of the Side's files which contain matches.
+func (s *Side) MatchedFiles() []bson.ObjectId {
+	ids := make([]bson.ObjectId, 0, len(s.grams))
+	for id := range s.grams {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+//Appear checks the snapshots fs, sorted by time, of the file matching id for the
+//snapshot in which the largest part of the code shared with the other submission
+//appeared. The Side's Appearance is updated if this is larger than its current one.
+//The first snapshot is never considered since code can't appear suddenly in it.
+func (s *Side) Appear(id bson.ObjectId, fs []*project.File) {
+	g := s.grams[id]
+	if s.total == 0 || len(g) == 0 {
+		return
+	}
+	p := 0.0
+	for i, f := range fs {
+		src := NewSource()
+		src.Add(f.Id, f.Name, f.Data)
+		n := 0
+		for h := range src.Grams() {
+			if g[h] {
+				n++
+			}
+		}
+		c := float64(n) / float64(s.total)
+		if i > 0 && c > p && (s.Appearance == nil || c-p > s.Appearance.Fraction) {
+			s.Appearance = &Appearance{FileId: f.Id, Name: f.Name, Time: f.Time, Fraction: c - p}
+		}
+		p = c
+	}
+}
+
+//Sudden checks whether most of the code shared with the other submission
+//appeared in a single snapshot.
+func (s *Side) Sudden() bool {
+	return s.Appearance != nil && s.Appearance.Fraction >= SUDDEN
+}
+
+//Compare creates a Pair for the submissions a and b of project pid by
+//finding the maximal sequences of tokens which they share.
+func Compare(pid bson.ObjectId, a, b *Side, t int64) *Pair {
+	p := &Pair{Id: bson.NewObjectId(), ProjectId: pid, A: a, B: b, Matches: tile(a, b), Time: t}
+	for _, m := range p.Matches {
+		p.Matched += m.Tokens
+	}
+	if n := a.Tokens + b.Tokens; n > 0 {
+		p.Similarity = float64(2*p.Matched) / float64(n)
+	}
+	return p
+}
+
+//tile finds the matches between a and b using Greedy String Tiling. In each
+//iteration the longest sequences of unmarked tokens found in both sources are
+//marked and added as matches until no sequences of at least MIN_MATCH tokens remain.
+func tile(a, b *Side) []*Match {
+	sa, sb := a.source, b.source
+	ma, mb := append([]bool(nil), sa.ignore...), append([]bool(nil), sb.ignore...)
+	idx := make(map[uint64][]int)
+	for q := range sb.tokens {
+		if h, ok := sb.gram(q); ok {
+			idx[h] = append(idx[h], q)
+		}
+	}
+	ms := make([]*Match, 0, 10)
+	for {
+		max := MIN_MATCH
+		var ts [][2]int
+		for p := range sa.tokens {
+			if ma[p] {
+				continue
+			}
+			h, ok := sa.gram(p)
+			if !ok {
+				continue
+			}
+			for _, q := range idx[h] {
+				n := 0
+				for p+n < len(sa.tokens) && q+n < len(sb.tokens) && !ma[p+n] && !mb[q+n] && sa.tokens[p+n] == sb.tokens[q+n] {
+					n++
+				}
+				if n > max {
+					max, ts = n, ts[:0]
+				}
+				if n == max {
+					ts = append(ts, [2]int{p, q})
+				}
+			}
+		}
+		if len(ts) == 0 {
+			return ms
+		}
+		for _, t := range ts {
+			if !unmarked(ma, t[0], max) || !unmarked(mb, t[1], max) {
+				continue
+			}
+			for i := 0; i < max; i++ {
+				ma[t[0]+i], mb[t[1]+i] = true, true
+			}
+			a.addGrams(t[0], max)
+			b.addGrams(t[1], max)
+			ms = append(ms, &Match{Tokens: max, A: sa.region(t[0], max), B: sb.region(t[1], max)})
+		}
+	}
+}
+
+//unmarked checks whether none of the n tokens starting at index i are marked.
+func unmarked(m []bool, i, n int) bool {
+	for _, b := range m[i : i+n] {
+		if b {
+			return false
+		}
+	}
+	return true
+}
+
+//Lines retrieves the lines of data which are part of the Region.
+func (r *Region) Lines(data []byte) string {
+	ls := strings.Split(string(data), "\n")
+	if r.Start < 1 || r.End > len(ls) || r.Start > r.End {
+		return ""
+	}
+	return strings.Join(ls[r.Start-1:r.End], "\n")
+}
+
+//Suspicious checks whether either submission's shared code appeared suddenly.
+func (p *Pair) Suspicious() bool {
+	return p.A.Sudden() || p.B.Sudden()
+}
+
+//Sides retrieves both sides of the Pair.
+func (p *Pair) Sides() []*Side {
+	return []*Side{p.A, p.B}
+}
+
+//Percentage is the Pair's similarity as a percentage.
+func (p *Pair) Percentage() int {
+	return percentage(p.Similarity)
+}
+
+//Percentage is the fraction of shared code which appeared as a percentage.
+func (a *Appearance) Percentage() int {
+	return percentage(a.Fraction)
+}
+
+func percentage(f float64) int {
+	return int(f*100 + 0.5)
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	if v := h.Sum64(); v != 0 {
+		return v
+	}
+	return 1
+}
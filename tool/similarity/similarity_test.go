@@ -0,0 +1,210 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package similarity
+
+import (
+	"github.com/godfried/impendulo/project"
+	"labix.org/v2/mgo/bson"
+
+	"strings"
+	"testing"
+)
+
+var (
+	original = `package triangle;
+
+import java.util.List;
+
+public class Triangle {
+	//The lengths of the triangle's sides.
+	private int[] sides;
+
+	public Triangle(int a, int b, int c) {
+		sides = new int[]{a, b, c};
+	}
+
+	public boolean valid() {
+		for (int i = 0; i < sides.length; i++) {
+			if (sides[i] <= 0) {
+				return false;
+			}
+		}
+		return sides[0] + sides[1] > sides[2] && sides[1] + sides[2] > sides[0];
+	}
+
+	public String kind() {
+		if (sides[0] == sides[1] && sides[1] == sides[2]) {
+			return "equilateral";
+		}
+		return "scalene";
+	}
+}
+`
+	renamed = `package shapes;
+
+/* A copy with different names. */
+public class Triangle {
+	private int[] lengths;
+
+	public Triangle(int x, int y, int z) {
+		lengths = new int[]{x, y, z};
+	}
+
+	public boolean valid() {
+		for (int j = 0; j < lengths.length; j++) {
+			if (lengths[j] <= 1) {
+				return false;
+			}
+		}
+		return lengths[0] + lengths[1] > lengths[2] && lengths[1] + lengths[2] > lengths[0];
+	}
+
+	public String kind() {
+		if (lengths[0] == lengths[1] && lengths[1] == lengths[2]) {
+			return "EQUILATERAL";
+		}
+		return "other";
+	}
+}
+`
+	different = `public class Triangle {
+	private double area;
+
+	public double area(double base, double height) {
+		while (area < 0) {
+			area = base * height / 2;
+		}
+		try {
+			System.out.println("Area: " + area);
+		} catch (Exception e) {
+			throw new RuntimeException(e);
+		}
+		return area;
+	}
+}
+`
+	skeleton = `public class Triangle {
+	private int[] sides;
+
+	public Triangle(int a, int b, int c) {
+		sides = new int[]{a, b, c};
+	}
+}
+`
+)
+
+func TestTokenise(t *testing.T) {
+	ts := Tokenise([]byte("#include <stdio.h>\nimport java.util.List;\n/* a\n comment */ int count = 10; //done\nchar *s = \"a \\\" b\";"))
+	exp := []string{"int", ID, "=", LIT, ";", "char", "*", ID, "=", LIT, ";"}
+	if len(ts) != len(exp) {
+		t.Fatalf("Expected %d tokens but got %d.", len(exp), len(ts))
+	}
+	for i, tk := range ts {
+		if tk.Text != exp[i] {
+			t.Errorf("Expected token %d to be %q but got %q.", i, exp[i], tk.Text)
+		}
+	}
+	if ts[0].Line != 4 || ts[5].Line != 5 {
+		t.Errorf("Expected tokens on lines 4 and 5 but got %d and %d.", ts[0].Line, ts[5].Line)
+	}
+	ops := Tokenise([]byte("a >>>= b && c != d++"))
+	for i, o := range []string{">>>=", "&&", "!=", "++"} {
+		if ops[2*i+1].Text != o {
+			t.Errorf("Expected operator %q but got %q.", o, ops[2*i+1].Text)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	pid := bson.NewObjectId()
+	a, b, c := side("a", original), side("b", renamed), side("c", different)
+	p := Compare(pid, a, b, 1000)
+	if p.Similarity != 1 {
+		t.Errorf("Expected renamed source to be identical but got similarity %f.", p.Similarity)
+	}
+	if len(p.Matches) == 0 || p.Matches[0].A.Name != "a.java" || p.Matches[0].B.Name != "b.java" {
+		t.Fatalf("Invalid matches %v.", p.Matches)
+	}
+	if p.Matches[0].A.Start != 5 || p.Matches[0].B.Start != 4 {
+		t.Errorf("Expected match to start on lines 5 and 4 but got %d and %d.", p.Matches[0].A.Start, p.Matches[0].B.Start)
+	}
+	if d := Compare(pid, a, c, 1000); d.Similarity >= p.Similarity || d.Similarity > THRESHOLD {
+		t.Errorf("Expected different source to have a low similarity but got %f.", d.Similarity)
+	}
+	sk := NewSource()
+	sk.Add(bson.NewObjectId(), "skel.java", []byte(skeleton))
+	a, b = side("a", original), side("b", renamed)
+	a.source.Exclude(sk.Grams())
+	b.source.Exclude(sk.Grams())
+	a.Tokens, b.Tokens = a.source.Len(), b.source.Len()
+	e := Compare(pid, a, b, 1000)
+	if e.Matched >= p.Matched {
+		t.Errorf("Expected skeleton to be excluded but %d tokens were matched.", e.Matched)
+	}
+	for _, m := range e.Matches {
+		if m.A.Start <= 10 {
+			t.Errorf("Expected skeleton's constructor not to be matched but got %v.", m.A)
+		}
+	}
+}
+
+func TestAppear(t *testing.T) {
+	ls := strings.Split(renamed, "\n")
+	fs := []*project.File{
+		snapshot("Triangle.java", strings.Join(ls[:10], "\n")+"\n}", 1),
+		snapshot("Triangle.java", strings.Join(ls[:10], "\n")+"\n}", 2),
+		snapshot("Triangle.java", renamed, 3),
+	}
+	a, b := side("a", original), side("b", renamed)
+	for _, s := range []*Side{a, b} {
+		s.source.files[0].id = fs[2].Id
+	}
+	Compare(bson.NewObjectId(), a, b, 1000)
+	b.Appear(fs[2].Id, fs)
+	if !b.Sudden() {
+		t.Fatalf("Expected shared code to have appeared suddenly but got %v.", b.Appearance)
+	}
+	if b.Appearance.Time != 3 || b.Appearance.FileId != fs[2].Id {
+		t.Errorf("Expected shared code to have appeared in the last snapshot but got %v.", b.Appearance)
+	}
+	gradual := []*project.File{fs[0], snapshot("Triangle.java", strings.Join(ls[:19], "\n")+"\n}}", 2), fs[2]}
+	c := side("c", renamed)
+	c.source.files[0].id = fs[2].Id
+	Compare(bson.NewObjectId(), side("a", original), c, 1000)
+	c.Appear(fs[2].Id, gradual)
+	if c.Sudden() {
+		t.Errorf("Expected shared code to have appeared gradually but got %v.", c.Appearance)
+	}
+}
+
+func side(n, src string) *Side {
+	s := NewSource()
+	s.Add(bson.NewObjectId(), n+".java", []byte(src))
+	return NewSide(bson.NewObjectId(), n, s)
+}
+
+func snapshot(n, src string, t int64) *project.File {
+	return &project.File{Id: bson.NewObjectId(), Name: n, Data: []byte(src), Time: t}
+}
@@ -0,0 +1,223 @@
+//Copyright (c) 2013, The Impendulo Authors
+//All rights reserved.
+//
+//Redistribution and use in source and binary forms, with or without modification,
+//are permitted provided that the following conditions are met:
+//
+//  Redistributions of source code must retain the above copyright notice, this
+//  list of conditions and the following disclaimer.
+//
+//  Redistributions in binary form must reproduce the above copyright notice, this
+//  list of conditions and the following disclaimer in the documentation and/or
+//  other materials provided with the distribution.
+//
+//THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+//ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+//WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+//DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+//ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+//(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+//LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+//ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+//(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//Package similarity detects similar source code in different submissions.
+//Source files are reduced to streams of normalised tokens in which identifiers
+//and literals are replaced by placeholders so that renaming variables or changing
+//constants doesn't hide copied code. The token streams of two submissions are then
+//compared using Greedy String Tiling, as done by JPlag.
+package similarity
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+type (
+	//Token is a normalised lexical token and the line on which it occurs.
+	Token struct {
+		Text string
+		Line int
+	}
+)
+
+const (
+	//ID replaces identifiers.
+	ID = "ID"
+	//LIT replaces string, character and numeric literals.
+	LIT = "LIT"
+)
+
+var (
+	keywords = map[string]bool{
+		"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true,
+		"catch": true, "char": true, "class": true, "const": true, "continue": true, "default": true,
+		"do": true, "double": true, "else": true, "enum": true, "extends": true, "final": true,
+		"finally": true, "float": true, "for": true, "goto": true, "if": true, "implements": true,
+		"import": true, "instanceof": true, "int": true, "interface": true, "long": true, "native": true,
+		"new": true, "package": true, "private": true, "protected": true, "public": true, "return": true,
+		"short": true, "static": true, "strictfp": true, "super": true, "switch": true, "synchronized": true,
+		"this": true, "throw": true, "throws": true, "transient": true, "try": true, "void": true,
+		"volatile": true, "while": true, "true": true, "false": true, "null": true, "auto": true,
+		"extern": true, "register": true, "signed": true, "sizeof": true, "struct": true, "typedef": true,
+		"union": true, "unsigned": true, "inline": true, "NULL": true,
+	}
+	operators = []string{
+		">>>=", "<<=", ">>=", ">>>", "...", "->", "++", "--", "&&", "||", "==", "!=", "<=", ">=",
+		"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "::",
+	}
+	extensions = map[string]bool{".java": true, ".c": true, ".h": true, ".cpp": true, ".cc": true, ".hpp": true}
+)
+
+//IsSource checks whether the file called n contains source code which can be tokenised.
+func IsSource(n string) bool {
+	return extensions[strings.ToLower(filepath.Ext(n))]
+}
+
+//Tokenise reduces the Java or C source in data to a stream of normalised tokens.
+//Comments, preprocessor directives and package and import statements are removed,
+//identifiers are replaced by ID and literals by LIT. Keywords and operators are kept.
+func Tokenise(data []byte) []*Token {
+	ts := make([]*Token, 0, len(data)/4)
+	l, lineStart := 1, true
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch {
+		case b == '\n':
+			l++
+			lineStart = true
+			i++
+			continue
+		case b == ' ' || b == '\t' || b == '\r' || b == '\f':
+			i++
+			continue
+		case b == '/' && i+1 < len(data) && data[i+1] == '/':
+			i = lineEnd(data, i)
+			continue
+		case b == '/' && i+1 < len(data) && data[i+1] == '*':
+			j := skipTo(data, i+2, "*/")
+			l += strings.Count(string(data[i:j]), "\n")
+			i = j
+			continue
+		case b == '#' && lineStart:
+			j := directiveEnd(data, i)
+			l += strings.Count(string(data[i:j]), "\n")
+			i = j
+			continue
+		}
+		lineStart = false
+		t := &Token{Line: l}
+		switch {
+		case b == '"' || b == '\'':
+			j := literalEnd(data, i)
+			l += strings.Count(string(data[i:j]), "\n")
+			t.Text, i = LIT, j
+		case isDigit(b) || (b == '.' && i+1 < len(data) && isDigit(data[i+1])):
+			j := i + 1
+			for j < len(data) && (isLetter(data[j]) || isDigit(data[j]) || data[j] == '.') {
+				j++
+			}
+			t.Text, i = LIT, j
+		case isLetter(b):
+			j := i + 1
+			for j < len(data) && (isLetter(data[j]) || isDigit(data[j])) {
+				j++
+			}
+			if w := string(data[i:j]); keywords[w] {
+				t.Text = w
+			} else {
+				t.Text = ID
+			}
+			i = j
+		default:
+			t.Text = string(b)
+			for _, o := range operators {
+				if strings.HasPrefix(string(data[i:min(i+len(o), len(data))]), o) {
+					t.Text = o
+					break
+				}
+			}
+			i += len(t.Text)
+		}
+		ts = append(ts, t)
+	}
+	return removeImports(ts)
+}
+
+//removeImports removes package and import statements from ts.
+func removeImports(ts []*Token) []*Token {
+	r := ts[:0]
+	skip := false
+	for _, t := range ts {
+		if t.Text == "package" || t.Text == "import" {
+			skip = true
+		}
+		if !skip {
+			r = append(r, t)
+		} else if t.Text == ";" {
+			skip = false
+		}
+	}
+	return r
+}
+
+//skipTo retrieves the index after the first occurrence of s in data from index i
+//or the length of data if s doesn't occur.
+func skipTo(data []byte, i int, s string) int {
+	j := strings.Index(string(data[i:]), s)
+	if j == -1 {
+		return len(data)
+	}
+	return i + j + len(s)
+}
+
+//lineEnd retrieves the index of the first line break in data from index i.
+func lineEnd(data []byte, i int) int {
+	for i < len(data) && data[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+//directiveEnd retrieves the index of the line break ending the preprocessor
+//directive starting at index i, taking line continuations into account.
+func directiveEnd(data []byte, i int) int {
+	for ; i < len(data); i++ {
+		if data[i] == '\n' && (i == 0 || data[i-1] != '\\') {
+			return i
+		}
+	}
+	return i
+}
+
+//literalEnd retrieves the index after the string or character literal starting at index i.
+func literalEnd(data []byte, i int) int {
+	q := data[i]
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case q:
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return i
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_' || b == '$'
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
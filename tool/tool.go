@@ -37,6 +37,7 @@ import (
 
 	"os/exec"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -63,6 +64,14 @@ type (
 	//Result is the result of RunCommand.
 	Result struct {
 		StdOut, StdErr []byte
+		Usage          *Usage
+	}
+
+	//Usage describes the resources used by a command. Memory is
+	//the command's peak resident set size in kilobytes.
+	Usage struct {
+		Wall, CPU time.Duration
+		Memory    int64
 	}
 	Language string
 )
@@ -120,6 +129,7 @@ func RunCommand(args []string, stdin io.Reader, max time.Duration) (*Result, err
 	if e != nil {
 		return nil, &StartError{args, e}
 	}
+	st := time.Now()
 	d := make(chan error)
 	go func() {
 		d <- c.Wait()
@@ -132,6 +142,19 @@ func RunCommand(args []string, stdin io.Reader, max time.Duration) (*Result, err
 		if e != nil {
 			e = &EndError{args, e, string(se.Bytes())}
 		}
-		return &Result{StdOut: so.Bytes(), StdErr: se.Bytes()}, e
+		return &Result{StdOut: so.Bytes(), StdErr: se.Bytes(), Usage: usage(c, time.Since(st))}, e
+	}
+}
+
+//usage retrieves the resources used by a command which has completed.
+func usage(c *exec.Cmd, wall time.Duration) *Usage {
+	u := &Usage{Wall: wall}
+	if c.ProcessState == nil {
+		return u
+	}
+	u.CPU = c.ProcessState.UserTime() + c.ProcessState.SystemTime()
+	if r, ok := c.ProcessState.SysUsage().(*syscall.Rusage); ok {
+		u.Memory = r.Maxrss
 	}
+	return u
 }
@@ -39,9 +39,11 @@ func TestRunCommand(t *testing.T) {
 		t.Error("Command should have failed")
 	}
 	succeedCmd := []string{"ls", "-a", "-l"}
-	_, e = RunCommand(succeedCmd, nil, 30*time.Second)
+	r, e := RunCommand(succeedCmd, nil, 30*time.Second)
 	if e != nil {
 		t.Error(e)
+	} else if r.Usage == nil || r.Usage.Wall <= 0 || r.Usage.Memory <= 0 {
+		t.Errorf("Expected resource usage, got %v", r.Usage)
 	}
 	noCmd := []string{"lsa"}
 	_, e = RunCommand(noCmd, nil, 30*time.Second)
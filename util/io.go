@@ -104,6 +104,27 @@ func SaveFile(n string, d []byte) error {
 	return nil
 }
 
+//SaveFiles saves the files in m to directory d. Each file's path relative
+//to d is a map key and its data is the associated value.
+//No files are saved if any of the paths would resolve outside of d.
+func SaveFiles(d string, m map[string][]byte) error {
+	d = filepath.Clean(d)
+	ps := make(map[string][]byte, len(m))
+	for n, f := range m {
+		p := filepath.Join(d, filepath.FromSlash(n))
+		if !strings.HasPrefix(p, d+string(filepath.Separator)) {
+			return fmt.Errorf("file %s is outside of directory %s", n, d)
+		}
+		ps[p] = f
+	}
+	for p, f := range ps {
+		if e := SaveFile(p, f); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
 //ReadBytes reads bytes from a reader until io.EOF is encountered.
 //If the reader can't be read an empty []byte is returned.
 func ReadBytes(r io.Reader) []byte {
@@ -107,6 +107,30 @@ func tsave(name string, valid bool) error {
 	}
 }
 
+func TestSaveFiles(t *testing.T) {
+	d, e := ioutil.TempDir("", "savefiles")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(d)
+	for _, n := range []string{"../escaped.java", "a/../../escaped.java", "/../escaped.java", ".", ""} {
+		if e = SaveFiles(filepath.Join(d, "src"), map[string][]byte{n: []byte("data")}); e == nil {
+			t.Errorf("Expected error saving %s.", n)
+		}
+	}
+	if Exists(filepath.Join(d, "escaped.java")) {
+		t.Error("File saved outside of directory.")
+	}
+	if e = SaveFiles(filepath.Join(d, "src"), map[string][]byte{"a/b/C.java": []byte("data"), "/D.java": []byte("data")}); e != nil {
+		t.Error(e)
+	}
+	for _, n := range []string{"a/b/C.java", "D.java"} {
+		if !Exists(filepath.Join(d, "src", filepath.FromSlash(n))) {
+			t.Errorf("File %s not saved.", n)
+		}
+	}
+}
+
 func TestReadBytes(t *testing.T) {
 	tests := [][]byte{[]byte("bytes"), nil, []byte{}, []byte(file1)}
 	for _, test := range tests {
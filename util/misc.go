@@ -63,6 +63,15 @@ func InstallPath() (string, error) {
 	return installPath, nil
 }
 
+//SystemUser retrieves the name of the operating system user running Impendulo.
+func SystemUser() string {
+	c, e := user.Current()
+	if e != nil {
+		return ""
+	}
+	return c.Username
+}
+
 //BaseDir retrieves the Impendulo directory.
 func BaseDir() (string, error) {
 	if baseDir != "" {
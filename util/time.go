@@ -30,6 +30,7 @@ import (
 	"github.com/godfried/impendulo/util/errors"
 
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -57,6 +58,28 @@ func Date(m int64) string {
 	return GetTime(m).Format(layout)
 }
 
+//Duration returns a string representation, accurate to the second, of a period of m miliseconds.
+func Duration(m int64) string {
+	return (time.Duration(m/1000) * time.Second).String()
+}
+
+//ParseDate converts a date string to miliseconds. The date may be given
+//in miliseconds or formatted as yyyy-mm-dd hh:mm[:ss] with an optional T
+//separating the date and time.
+func ParseDate(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if m, e := strconv.ParseInt(s, 10, 64); e == nil {
+		return m, nil
+	}
+	s = strings.Replace(s, "T", " ", 1)
+	for _, l := range []string{layout, "2006-01-02 15:04", "2006-01-02"} {
+		if t, e := time.ParseInLocation(l, s, time.Local); e == nil {
+			return GetMilis(t), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid date %s", s)
+}
+
 //CalcTime converts a time string formatted as yyyymmddhhmmssmmm to a time.Time.
 func CalcTime(s string) (time.Time, error) {
 	t := time.Time{}
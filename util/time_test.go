@@ -98,3 +98,34 @@ func TestCalcTime(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDate(t *testing.T) {
+	d := time.Date(2013, time.October, 21, 14, 30, 0, 0, time.Local)
+	tests := map[string]interface{}{
+		"2013-10-21 14:30:00": GetMilis(d), "2013-10-21T14:30": GetMilis(d),
+		"2013-10-21 14:30": GetMilis(d), "1382358600000": int64(1382358600000),
+		"2013-10-21": GetMilis(time.Date(2013, time.October, 21, 0, 0, 0, 0, time.Local)),
+		"21/10/2013": nil, "": nil,
+	}
+	for s, v := range tests {
+		m, e := ParseDate(s)
+		if v == nil {
+			if e == nil {
+				t.Errorf("Expected error parsing %q.", s)
+			}
+		} else if e != nil {
+			t.Error(e)
+		} else if m != v.(int64) {
+			t.Errorf("Expected %d but got %d for %q.", v, m, s)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := map[int64]string{0: "0s", 999: "0s", 61500: "1m1s", 3723000: "1h2m3s"}
+	for m, d := range tests {
+		if s := Duration(m); s != d {
+			t.Errorf("Expected %s but got %s for %d.", d, s, m)
+		}
+	}
+}
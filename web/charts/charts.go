@@ -32,6 +32,7 @@ import (
 	"github.com/godfried/impendulo/db"
 	"github.com/godfried/impendulo/project"
 	"github.com/godfried/impendulo/tool/junit"
+	"github.com/godfried/impendulo/tool/metrics"
 	"github.com/godfried/impendulo/tool/result"
 	"github.com/godfried/impendulo/util"
 	"github.com/godfried/impendulo/util/convert"
@@ -110,17 +111,35 @@ func addAll(c *C, f *project.File) {
 }
 
 func addSingle(c *C, f *project.File) {
-	if _, e := convert.Id(f.Results[c.result.Raw()]); e != nil {
+	id, e := convert.Id(f.Results[c.result.Key()])
+	if e != nil {
 		return
 	}
-	r, e := db.Charter(bson.M{db.ID: f.Results[c.result.Raw()]}, nil)
+	vs, e := chartVals(id, c.result)
 	if e != nil {
 		return
 	}
-	c.Add(f.Time, r.ChartVals())
+	c.Add(f.Time, vs)
 	return
 }
 
+//chartVals retrieves the chart values of the result matching rid which are selected by r.
+//A metrics result's values are restricted to the metric named by r.
+func chartVals(rid bson.ObjectId, r *context.Result) ([]*result.ChartVal, error) {
+	c, e := db.Charter(bson.M{db.ID: rid}, nil)
+	if e != nil {
+		return nil, e
+	}
+	m, ok := c.(*metrics.Result)
+	if !ok || r.Name == "" {
+		return c.ChartVals(), nil
+	}
+	if v := m.ChartVal(r.Name); v != nil {
+		return []*result.ChartVal{v}, nil
+	}
+	return nil, NoValuesError
+}
+
 //Add inserts new coordinates into data used to display a chart.
 func (c *C) Add(t int64, vs []*result.ChartVal) {
 	if len(vs) == 0 {
@@ -341,15 +360,14 @@ func finalScore(s *project.Submission, r *context.Result) (*result.ChartVal, err
 		return nil, e
 	}
 	t := (f.Time - s.Time) / 1000.0
-	return firstVal(rid, t)
+	return firstVal(rid, t, r)
 }
 
-func firstVal(rid bson.ObjectId, t int64) (*result.ChartVal, error) {
-	r, e := db.Charter(bson.M{db.ID: rid}, nil)
+func firstVal(rid bson.ObjectId, t int64, r *context.Result) (*result.ChartVal, error) {
+	vs, e := chartVals(rid, r)
 	if e != nil {
 		return nil, e
 	}
-	vs := r.ChartVals()
 	if len(vs) == 0 || vs[0] == nil {
 		return nil, NoValuesError
 	}
@@ -393,8 +411,8 @@ func averageScore(s *project.Submission, r *context.Result) (*result.ChartVal, e
 	cv := new(avgVal)
 	for i, f := range fs {
 		ft := (f.Time - s.Time) / 1000.0
-		if id, e := convert.GetId(fs[i].Results, r.Raw()); e == nil {
-			v, e := firstVal(id, ft)
+		if id, e := convert.GetId(fs[i].Results, r.Key()); e == nil {
+			v, e := firstVal(id, ft, r)
 			if e == nil {
 				cv.add(v)
 			}
@@ -402,7 +420,7 @@ func averageScore(s *project.Submission, r *context.Result) (*result.ChartVal, e
 		}
 		for _, t := range ts {
 			if id, e := convert.GetId(f.Results, r.Raw()+"-"+t.Id.Hex()); e == nil {
-				v, e := firstVal(id, ft)
+				v, e := firstVal(id, ft, r)
 				if e != nil {
 					continue
 				}
@@ -434,7 +452,7 @@ func lastInfo(sid bson.ObjectId, r *context.Result) (*project.File, bson.ObjectI
 		}
 	}
 	for i, f := range fs {
-		if id, e := convert.GetId(fs[i].Results, r.Raw()); e == nil {
+		if id, e := convert.GetId(fs[i].Results, r.Key()); e == nil {
 			return fs[i], id, nil
 		}
 		for _, t := range ts {
@@ -32,6 +32,7 @@ import (
 
 	"github.com/godfried/impendulo/db"
 	"github.com/godfried/impendulo/project"
+	"github.com/godfried/impendulo/tool/metrics"
 	"github.com/godfried/impendulo/tool/result"
 	"github.com/godfried/impendulo/util"
 	"github.com/godfried/impendulo/util/convert"
@@ -48,6 +49,14 @@ type (
 	C struct {
 		Session *sessions.Session
 		Browse  *Browse
+		//created lists the documents created while handling the current request.
+		created []*Created
+	}
+
+	//Created identifies a document created while handling a request.
+	Created struct {
+		Collection string
+		Id         interface{}
 	}
 
 	//Browse is used to keep track of the user's browsing.
@@ -101,6 +110,17 @@ func (c *C) Save(r *http.Request, w http.ResponseWriter) error {
 	return c.Session.Save(r, w)
 }
 
+//AddCreated records that the document id was created in collection n
+//while handling the current request.
+func (c *C) AddCreated(n string, id interface{}) {
+	c.created = append(c.created, &Created{n, id})
+}
+
+//Created retrieves the documents created while handling the current request.
+func (c *C) Created() []*Created {
+	return c.created
+}
+
 //IsView checks whether the given view matches the user's current view.
 func (c *C) IsView(v string) bool {
 	return c.Browse.View == v
@@ -423,6 +443,15 @@ func (r *Result) Raw() string {
 	return s + "-" + r.FileID.Hex()
 }
 
+//Key retrieves the name under which the result is stored in a file's results.
+//All metrics are stored in a single result so a metric's name isn't part of its key.
+func (r *Result) Key() string {
+	if r.Type == metrics.NAME {
+		return r.Type
+	}
+	return r.Raw()
+}
+
 func (r *Result) HasCode() bool {
 	return r.Name != ""
 }
@@ -37,6 +37,10 @@ import (
 	"github.com/godfried/impendulo/web/context"
 	"github.com/godfried/impendulo/web/webutil"
 
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 
 	"labix.org/v2/mgo/bson"
@@ -55,10 +59,13 @@ var (
 func Downloaders() map[string]Downloader {
 	if downloaders == nil {
 		downloaders = map[string]Downloader{
-			"skeleton.zip": LoadSkeleton,
-			"intlola.zip":  LoadIntlola,
-			"exportdb.zip": ExportData,
-			"test.zip":     LoadTest,
+			"skeleton.zip":  LoadSkeleton,
+			"intlola.zip":   LoadIntlola,
+			"exportdb.zip":  ExportData,
+			"test.zip":      LoadTest,
+			"audit.csv":     ExportAudits,
+			"tree.zip":      LoadSourceTree,
+			"results.sarif": ExportSARIF,
 		}
 	}
 	return downloaders
@@ -117,6 +124,80 @@ func LoadSkeleton(r *http.Request) (string, error) {
 	return util.SaveTemp(s.Data)
 }
 
+//LoadSourceTree makes the source tree of a submission as it was
+//at a specified time available for download.
+func LoadSourceTree(r *http.Request) (string, error) {
+	sid, e := convert.Id(r.FormValue("submission-id"))
+	if e != nil {
+		return "", e
+	}
+	t := util.CurMilis()
+	if v := r.FormValue("time"); v != "" {
+		if t, e = util.ParseDate(v); e != nil {
+			return "", e
+		}
+	}
+	m, e := db.SourceTree(sid, t)
+	if e != nil {
+		return "", e
+	}
+	z, e := util.ZipMap(m)
+	if e != nil {
+		return "", e
+	}
+	return util.SaveTemp(z)
+}
+
+//ExportSARIF makes the analysis results of a project, submission or
+//snapshot available for download in the SARIF format.
+func ExportSARIF(r *http.Request) (string, error) {
+	m, e := sarifMatcher(r)
+	if e != nil {
+		return "", e
+	}
+	l, e := db.SARIF(m)
+	if e != nil {
+		return "", e
+	}
+	d, e := l.JSON()
+	if e != nil {
+		return "", e
+	}
+	return util.SaveTemp(d)
+}
+
+//sarifMatcher creates a matcher for the files whose results should be exported
+//based on the requested scope.
+func sarifMatcher(r *http.Request) (bson.M, error) {
+	switch r.FormValue("scope") {
+	case "snapshot":
+		id, e := convert.Id(r.FormValue("file-id"))
+		if e != nil {
+			return nil, e
+		}
+		return bson.M{db.ID: id}, nil
+	case "submission":
+		sid, e := convert.Id(r.FormValue("submission-id"))
+		if e != nil {
+			return nil, e
+		}
+		return bson.M{db.SUBID: sid}, nil
+	}
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return nil, e
+	}
+	ss, e := db.Submissions(bson.M{db.PROJECTID: pid}, bson.M{db.ID: 1})
+	if e != nil {
+		return nil, e
+	}
+	ids := make([]bson.ObjectId, len(ss))
+	for i, s := range ss {
+		ids[i] = s.Id
+	}
+	return bson.M{db.SUBID: bson.M{db.IN: ids}}, nil
+}
+
 //ExportData
 func ExportData(r *http.Request) (string, error) {
 	n, e := webutil.String(r, "db")
@@ -134,6 +215,44 @@ func ExportData(r *http.Request) (string, error) {
 	return p, nil
 }
 
+//ExportAudits makes the audit trail entries matching a search available for download as csv.
+func ExportAudits(r *http.Request) (string, error) {
+	as, e := db.Audits(auditMatcher(r), nil, 0)
+	if e != nil {
+		return "", e
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"time", "user", "action", "collection", "target", "before", "after"})
+	for _, a := range as {
+		t := ""
+		if a.Target != nil {
+			t = fmt.Sprint(a.Target)
+			if id, ok := a.Target.(bson.ObjectId); ok {
+				t = id.Hex()
+			}
+		}
+		w.Write([]string{util.Date(a.Time), a.User, a.Action, a.Collection, t, auditValues(a.Before), auditValues(a.After)})
+	}
+	w.Flush()
+	if e = w.Error(); e != nil {
+		return "", e
+	}
+	return util.SaveTemp(b.Bytes())
+}
+
+//auditValues formats an audit trail entry's before or after values.
+func auditValues(m bson.M) string {
+	if len(m) == 0 {
+		return ""
+	}
+	d, e := json.Marshal(m)
+	if e != nil {
+		return fmt.Sprint(m)
+	}
+	return string(d)
+}
+
 func LoadIntlola(r *http.Request) (string, error) {
 	p, e := config.INTLOLA.Path()
 	if e != nil {
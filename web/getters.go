@@ -28,6 +28,7 @@ import (
 	"code.google.com/p/gorilla/pat"
 
 	"github.com/godfried/impendulo/db"
+	"github.com/godfried/impendulo/tool/similarity"
 	"github.com/godfried/impendulo/util"
 	"github.com/godfried/impendulo/util/convert"
 	"github.com/godfried/impendulo/web/context"
@@ -35,6 +36,7 @@ import (
 	"labix.org/v2/mgo/bson"
 
 	"net/http"
+	"strings"
 )
 
 type (
@@ -43,6 +45,17 @@ type (
 	Getter func(r *http.Request, c *context.C) (Args, string, error)
 )
 
+const (
+	//AUDIT_LIMIT is the maximum number of audit trail entries displayed at once.
+	AUDIT_LIMIT = 500
+	//ISSUE_LIMIT is the maximum number of issues displayed at once.
+	ISSUE_LIMIT = 500
+	//SIMILARITY_LIMIT is the maximum number of similar pairs of submissions displayed at once.
+	SIMILARITY_LIMIT = 200
+	//VALIDATION_LIMIT is the maximum number of validations displayed at once.
+	VALIDATION_LIMIT = 20
+)
+
 var (
 	getters map[string]Getter
 )
@@ -61,6 +74,9 @@ func defaultGetters() map[string]Getter {
 		"configview":    configView,
 		"displayresult": displayResult, "getfiles": getFiles,
 		"submissionschartview": submissionsChartView, "getsubmissions": getSubmissions,
+		"auditview": auditView, "issueview": issueView, "issuetrackview": issueTrackView,
+		"similarityview": similarityView, "similaritypairview": similarityPairView,
+		"validationview": validationView, "testcaseview": testCaseView, "flakytestview": flakyTestView,
 	}
 }
 
@@ -110,6 +126,255 @@ func configView(r *http.Request, c *context.C) (Args, string, error) {
 	return Args{"tool": t, "templates": []string{"configview", toolTemplate(t)}}, "", nil
 }
 
+//auditView displays the audit trail entries matching a search.
+func auditView(r *http.Request, c *context.C) (Args, string, error) {
+	as, e := db.Audits(auditMatcher(r), nil, AUDIT_LIMIT)
+	if e != nil {
+		return nil, "Could not load audit trail.", e
+	}
+	return Args{"audits": as, "search": r.URL.Query(), "templates": []string{"auditview"}}, "", nil
+}
+
+//auditMatcher creates a matcher for audit trail entries from a request's search fields.
+func auditMatcher(r *http.Request) bson.M {
+	m := bson.M{}
+	for f, k := range map[string]string{"audit-user": db.USER, "audit-action": db.ACTION, "audit-collection": db.COLLECTION} {
+		if v, e := webutil.String(r, f); e == nil {
+			m[k] = v
+		}
+	}
+	if t, e := webutil.String(r, "audit-target"); e == nil {
+		m[db.TARGET] = auditTarget(t)
+	}
+	return m
+}
+
+//issueView displays the issues found by all tools in a project's submissions
+//matching a search along with the number of issues per category and tool.
+func issueView(r *http.Request, c *context.C) (Args, string, error) {
+	a := Args{"search": r.URL.Query(), "templates": []string{"issueview"}}
+	m, e := issueMatcher(r)
+	if e != nil {
+		return a, "", nil
+	}
+	is, e := db.Issues(m, nil, ISSUE_LIMIT)
+	if e != nil {
+		return nil, "Could not load issues.", e
+	}
+	cs, e := db.IssueCounts(m, db.CATEGORY)
+	if e != nil {
+		return nil, "Could not count issues.", e
+	}
+	ts, e := db.IssueCounts(m, db.TOOL)
+	if e != nil {
+		return nil, "Could not count issues.", e
+	}
+	a["issues"], a["categories"], a["tools"] = is, cs, ts
+	return a, "", nil
+}
+
+//issueTrackView displays how long the issues found in a project's submissions matching
+//a search existed for before they were fixed, along with a summary for each rule.
+func issueTrackView(r *http.Request, c *context.C) (Args, string, error) {
+	a := Args{"search": r.URL.Query(), "templates": []string{"issuetrackview"}}
+	m, e := issueMatcher(r)
+	if e != nil {
+		return a, "", nil
+	}
+	switch r.FormValue("issue-status") {
+	case "fixed":
+		m[db.FIXED] = bson.M{db.GT: 0}
+	case "open":
+		m[db.FIXED] = 0
+	}
+	ts, e := db.IssueTracks(m, nil, ISSUE_LIMIT)
+	if e != nil {
+		return nil, "Could not load issues.", e
+	}
+	ss, e := db.IssueSummaries(m)
+	if e != nil {
+		return nil, "Could not summarise issues.", e
+	}
+	a["tracks"], a["summaries"] = ts, ss
+	return a, "", nil
+}
+
+//issueMatcher creates a matcher for issues and issue tracks from a request's search fields.
+//A project must be specified and the search can be restricted to a single user's submissions.
+func issueMatcher(r *http.Request) (bson.M, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return nil, e
+	}
+	sm := bson.M{db.PROJECTID: pid}
+	if u, e := webutil.String(r, "issue-user"); e == nil {
+		sm[db.USER] = u
+	}
+	ss, e := db.Submissions(sm, bson.M{db.ID: 1})
+	if e != nil {
+		return nil, e
+	}
+	ids := make([]bson.ObjectId, len(ss))
+	for i, s := range ss {
+		ids[i] = s.Id
+	}
+	m := bson.M{db.SUBID: bson.M{db.IN: ids}}
+	for f, k := range map[string]string{"issue-tool": db.TOOL, "issue-category": db.CATEGORY, "issue-severity": db.SEVERITY, "issue-rule": db.RULE, "issue-file": db.NAME} {
+		if v, e := webutil.String(r, f); e == nil {
+			m[k] = v
+		}
+	}
+	return m, nil
+}
+
+//testCaseView displays the outcomes of the JUnit test cases run on each snapshot of the
+//submissions matching a search along with a summary of how long each test case took to solve.
+func testCaseView(r *http.Request, c *context.C) (Args, string, error) {
+	a := Args{"search": r.URL.Query(), "templates": []string{"testcaseview"}}
+	m, e := testMatcher(r)
+	if e != nil {
+		return a, "", nil
+	}
+	hs, e := db.TestHistories(m)
+	if e != nil {
+		return nil, "Could not load test case outcomes.", e
+	}
+	a["histories"], a["summaries"] = hs, db.NewTestCaseSummaries(hs)
+	return a, "", nil
+}
+
+//flakyTestView displays the test cases whose outcomes most often differed
+//when they were rerun on the submissions matching a search.
+func flakyTestView(r *http.Request, c *context.C) (Args, string, error) {
+	a := Args{"search": r.URL.Query(), "templates": []string{"flakytestview"}}
+	m, e := testMatcher(r)
+	if e != nil {
+		return a, "", nil
+	}
+	fs, e := db.FlakyTests(m)
+	if e != nil {
+		return nil, "Could not load flaky tests.", e
+	}
+	a["flaky"] = fs
+	return a, "", nil
+}
+
+//testMatcher creates a matcher for the test case outcomes of a project's submissions
+//which match a search. The search can be restricted to a single submission.
+func testMatcher(r *http.Request) (bson.M, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return nil, e
+	}
+	var m bson.M
+	if sid, e := convert.Id(r.FormValue("submission-id")); e == nil {
+		m = bson.M{db.SUBID: sid}
+	} else {
+		sm := bson.M{db.PROJECTID: pid}
+		if u, e := webutil.String(r, "test-user"); e == nil {
+			sm[db.USER] = u
+		}
+		ss, e := db.Submissions(sm, bson.M{db.ID: 1})
+		if e != nil {
+			return nil, e
+		}
+		ids := make([]bson.ObjectId, len(ss))
+		for i, s := range ss {
+			ids[i] = s.Id
+		}
+		m = bson.M{db.SUBID: bson.M{db.IN: ids}}
+	}
+	for f, k := range map[string]string{"test-name": db.TEST, "test-case": db.CASE} {
+		if v, e := webutil.String(r, f); e == nil {
+			m[k] = v
+		}
+	}
+	return m, nil
+}
+
+//similarityView displays the pairs of a project's submissions with the most similar source.
+//The search can be restricted to a single user's submissions and to a minimum similarity.
+func similarityView(r *http.Request, c *context.C) (Args, string, error) {
+	a := Args{"search": r.URL.Query(), "templates": []string{"similarityview"}}
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return a, "", nil
+	}
+	m := bson.M{db.PROJECTID: pid}
+	if u, e := webutil.String(r, "similarity-user"); e == nil {
+		m[db.OR] = []interface{}{bson.M{"a." + db.USER: u}, bson.M{"b." + db.USER: u}}
+	}
+	if v, e := convert.Int(r.FormValue("similarity-min")); e == nil {
+		m[db.SIMILARITY] = bson.M{db.GTE: float64(v) / 100}
+	}
+	ps, e := db.Similarities(m, bson.M{db.MATCHES: 0}, SIMILARITY_LIMIT)
+	if e != nil {
+		return nil, "Could not load similar submissions.", e
+	}
+	a["pairs"] = ps
+	return a, "", nil
+}
+
+//validationView displays the most recent validations of a project's tests and
+//tool configurations against its reference solution.
+func validationView(r *http.Request, c *context.C) (Args, string, error) {
+	a := Args{"search": r.URL.Query(), "templates": []string{"validationview"}}
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return a, "", nil
+	}
+	vs, e := db.Validations(bson.M{db.PROJECTID: pid}, nil, VALIDATION_LIMIT)
+	if e != nil {
+		return nil, "Could not load validations.", e
+	}
+	a["validations"] = vs
+	a["reference"] = hasReference(pid)
+	a["pending"] = db.HasPending(pid)
+	return a, "", nil
+}
+
+//similarityPairView displays the code shared by a pair of similar submissions side by side.
+func similarityPairView(r *http.Request, c *context.C) (Args, string, error) {
+	id, e := convert.Id(r.FormValue("pair-id"))
+	if e != nil {
+		return nil, "Could not read pair id.", e
+	}
+	p, e := db.Similarity(bson.M{db.ID: id}, nil)
+	if e != nil {
+		return nil, "Could not load similar submissions.", e
+	}
+	pr, e := db.Project(bson.M{db.ID: p.ProjectId}, bson.M{db.LANG: 1})
+	if e != nil {
+		return nil, "Could not load project.", e
+	}
+	data := make(map[bson.ObjectId][]byte)
+	code := func(rg *similarity.Region) (string, error) {
+		d, ok := data[rg.FileId]
+		if !ok {
+			f, e := db.File(bson.M{db.ID: rg.FileId}, bson.M{db.DATA: 1})
+			if e != nil {
+				return "", e
+			}
+			d = f.Data
+			data[rg.FileId] = d
+		}
+		return rg.Lines(d), nil
+	}
+	ms := make([]Args, len(p.Matches))
+	for i, m := range p.Matches {
+		ca, e := code(m.A)
+		if e != nil {
+			return nil, "Could not load matched code.", e
+		}
+		cb, e := code(m.B)
+		if e != nil {
+			return nil, "Could not load matched code.", e
+		}
+		ms[i] = Args{"match": m, "a": ca, "b": cb}
+	}
+	return Args{"pair": p, "matches": ms, "lang": strings.ToLower(pr.Lang), "templates": []string{"similaritypairview"}}, "", nil
+}
+
 //getSubmissions displays a list of submissions.
 func getSubmissions(r *http.Request, c *context.C) (Args, string, error) {
 	if e := c.Browse.Update(r); e != nil {
@@ -33,6 +33,7 @@ import (
 	"github.com/godfried/impendulo/processor/mq"
 	"github.com/godfried/impendulo/project"
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/junit"
 	"github.com/godfried/impendulo/tool/mongo"
 	"github.com/godfried/impendulo/user"
 	"github.com/godfried/impendulo/util"
@@ -42,16 +43,34 @@ import (
 	"labix.org/v2/mgo/bson"
 
 	"net/http"
+	"reflect"
 )
 
 type (
 	//A function used to fullfill a POST request.
 	Poster func(*http.Request, *context.C) (string, error)
+
+	//auditEntry is a target of a post request along with its document
+	//as it was before the request was handled.
+	auditEntry struct {
+		collection string
+		target     interface{}
+		before     bson.M
+	}
 )
 
 var (
-	indexPosters map[string]bool
-	posters      map[string]Poster
+	indexPosters     map[string]bool
+	unauditedPosters map[string]bool
+	posters          map[string]Poster
+	//auditTargets maps the form fields which identify the target
+	//of an audited poster to the target's collection.
+	auditTargets = []struct{ field, collection string }{
+		{"file-id", db.FILES}, {"submission-id", db.SUBMISSIONS}, {"test-id", db.TESTS},
+		{"skeleton-id", db.SKELETONS}, {"external-id", db.EXTERNAL}, {"iotest-id", db.IOTESTS},
+		{"workload-id", db.BENCHMARKS}, {"project-id", db.PROJECTS}, {"user-id", db.USERS},
+		{"trash-id", db.TRASH},
+	}
 )
 
 //Posters retrieves all posters
@@ -74,7 +93,9 @@ func defaultPosters() map[string]Poster {
 		"deletesubmissions": DeleteSubmissions, "deleteresults": DeleteResults, "deleteskeletons": DeleteSkeletons,
 		"importdata": ImportData, "renamefiles": RenameFiles, "login": Login, "register": Register,
 		"logout": Logout, "editproject": EditProject, "edituser": EditUser, "editsubmission": EditSubmission,
-		"editfile": EditFile, "edittest": EditTest,
+		"editfile": EditFile, "edittest": EditTest, "archiveprojects": ArchiveProjects,
+		"restoreprojects": RestoreProjects, "editretention": EditRetention, "restoretrash": RestoreTrash,
+		"purgetrash": PurgeTrash, "comparesubmissions": CompareSubmissions,
 	}
 }
 
@@ -89,17 +110,38 @@ func IndexPosters() map[string]bool {
 	return indexPosters
 }
 
+//UnauditedPosters loads the posters which should not be recorded in the audit trail
+//by CreatePost. These either don't modify any data or record their changes themselves.
+func UnauditedPosters() map[string]bool {
+	if unauditedPosters == nil {
+		unauditedPosters = map[string]bool{
+			"login": true, "logout": true,
+			"editproject": true, "editsubmission": true, "editfile": true,
+			"edittest": true, "edituser": true, "renamefiles": true,
+		}
+	}
+	return unauditedPosters
+}
+
 //GeneratePosts loads post request handlers and adds them to the router.
-func GeneratePosts(router *pat.Router, posts map[string]Poster, indexPosts map[string]bool) {
+func GeneratePosts(router *pat.Router, posts map[string]Poster, indexPosts, unaudited map[string]bool) {
 	for n, f := range posts {
-		router.Add("POST", "/"+n, Handler(f.CreatePost(indexPosts[n]))).Name(n)
+		router.Add("POST", "/"+n, Handler(f.CreatePost(n, indexPosts[n], !unaudited[n]))).Name(n)
 	}
 }
 
 //CreatePost loads a post request handler.
-func (p Poster) CreatePost(index bool) Handler {
+//Successful requests are recorded in the audit trail if audit is set.
+func (p Poster) CreatePost(name string, index, audit bool) Handler {
 	return func(w http.ResponseWriter, r *http.Request, c *context.C) error {
+		var as []*auditEntry
+		if audit {
+			as = auditBefore(r)
+		}
 		m, e := p(r, c)
+		if e == nil && audit {
+			auditPost(r, c, name, as)
+		}
 		c.AddMessage(m, e != nil)
 		if e == nil && index {
 			http.Redirect(w, r, getRoute("index"), http.StatusSeeOther)
@@ -110,6 +152,75 @@ func (p Poster) CreatePost(index bool) Handler {
 	}
 }
 
+//auditBefore loads the documents targeted by a post request before it is handled.
+//Targets which don't exist yet have no before document.
+func auditBefore(r *http.Request) []*auditEntry {
+	var as []*auditEntry
+	for _, a := range auditTargets {
+		if r.FormValue(a.field) == "" {
+			continue
+		}
+		for _, v := range r.Form[a.field] {
+			t := auditTarget(v)
+			d, _ := db.AuditDocument(a.collection, t)
+			as = append(as, &auditEntry{a.collection, t, d})
+		}
+	}
+	return as
+}
+
+//auditPost records a successful post request in the audit trail.
+//Each document created by the request is stored as it is after the request.
+//Each target's document before the request and after it, if it still exists,
+//is stored unless the target was only the parent of a created document and
+//remained unchanged. Requests without a created document or a target store
+//their form values, excluding passwords, as the after values instead.
+func auditPost(r *http.Request, c *context.C, action string, as []*auditEntry) {
+	cs := c.Created()
+	for _, k := range cs {
+		d, _ := db.AuditDocument(k.Collection, k.Id)
+		audit(c, action, k.Collection, k.Id, nil, d)
+	}
+	for _, a := range as {
+		d, _ := db.AuditDocument(a.collection, a.target)
+		if len(cs) > 0 && reflect.DeepEqual(a.before, d) {
+			continue
+		}
+		audit(c, action, a.collection, a.target, a.before, d)
+	}
+	if len(cs) > 0 || len(as) > 0 {
+		return
+	}
+	vs := bson.M{}
+	for k, v := range r.Form {
+		if k == "password" {
+			continue
+		}
+		if len(v) == 1 {
+			vs[k] = v[0]
+		} else {
+			vs[k] = v
+		}
+	}
+	audit(c, action, "", nil, nil, vs)
+}
+
+//auditTarget converts a form value identifying an audit trail entry's target to its id.
+func auditTarget(v string) interface{} {
+	if id, e := convert.Id(v); e == nil {
+		return id
+	}
+	return v
+}
+
+//audit records an action performed by the current user in the audit trail.
+func audit(c *context.C, action, n string, target interface{}, before, after bson.M) {
+	u, _ := c.Username()
+	if e := db.AddAudit(db.NewAudit(u, action, n, target, before, after)); e != nil {
+		util.Log(e)
+	}
+}
+
 func AddSkeleton(r *http.Request, c *context.C) (string, error) {
 	pid, e := convert.Id(r.FormValue("project-id"))
 	if e != nil {
@@ -123,9 +234,11 @@ func AddSkeleton(r *http.Request, c *context.C) (string, error) {
 	if e != nil {
 		return "Could not read skeleton file.", e
 	}
-	if e = db.Add(db.SKELETONS, project.NewSkeleton(pid, n, s)); e != nil {
+	sk := project.NewSkeleton(pid, n, s)
+	if e = db.Add(db.SKELETONS, sk); e != nil {
 		return "Could not add skeleton.", e
 	}
+	c.AddCreated(db.SKELETONS, sk.Id)
 	return "Successfully added skeleton.", nil
 }
 
@@ -149,8 +262,9 @@ func SubmitArchive(r *http.Request, c *context.C) (string, error) {
 	if e = db.Add(db.SUBMISSIONS, s); e != nil {
 		return "Could not create submission.", e
 	}
+	c.AddCreated(db.SUBMISSIONS, s.Id)
 	f := project.NewArchive(s.Id, a)
-	if e = db.Add(db.FILES, f); e != nil {
+	if e = db.AddFile(f); e != nil {
 		return "Could not store archive.", e
 	}
 	k, e := mq.StartSubmission(s.Id)
@@ -184,43 +298,108 @@ func AddProject(r *http.Request, c *context.C) (string, error) {
 	if e != nil {
 		return "Could not read description.", e
 	}
-	if e = db.Add(db.PROJECTS, project.New(n, un, l, d)); e != nil {
+	p := project.New(n, un, l, d)
+	p.WholeProject = r.FormValue("whole-check") == "true"
+	if e = db.Add(db.PROJECTS, p); e != nil {
 		return "Could not add project.", e
 	}
+	c.AddCreated(db.PROJECTS, p.Id)
 	return "Successfully added project.", nil
 }
 
-//DeleteProjects removes a project and all data associated with it from the system.
+//DeleteProjects moves a project and all data associated with it to the trash.
 func DeleteProjects(r *http.Request, c *context.C) (string, error) {
 	pids, e := webutil.Strings(r, "project-id")
 	if e != nil {
 		return "Could not read projects.", e
 	}
+	u, e := c.Username()
+	if e != nil {
+		return "Could not retrieve user.", e
+	}
 	for _, p := range pids {
 		id, e := convert.Id(p)
 		if e != nil {
 			util.Log(e)
 			continue
 		}
-		if e = db.RemoveProjectById(id); e != nil {
+		if _, e = db.TrashProject(id, u); e != nil {
+			util.Log(e)
+		}
+	}
+	return "Successfully moved project to trash.", nil
+}
+
+//ArchiveProjects moves projects' data to cold storage.
+func ArchiveProjects(r *http.Request, c *context.C) (string, error) {
+	pids, e := webutil.Strings(r, "project-id")
+	if e != nil {
+		return "Could not read projects.", e
+	}
+	for _, p := range pids {
+		id, e := convert.Id(p)
+		if e != nil {
 			util.Log(e)
+			continue
+		}
+		if _, e = db.ArchiveProject(id); e != nil {
+			return "Could not archive project.", e
 		}
 	}
-	return "Successfully deleted project.", nil
+	return "Successfully archived project.", nil
 }
 
-//DeleteUsers removes users and all data associated with them from the system.
+//RestoreProjects restores archived projects' data from cold storage.
+func RestoreProjects(r *http.Request, c *context.C) (string, error) {
+	pids, e := webutil.Strings(r, "project-id")
+	if e != nil {
+		return "Could not read projects.", e
+	}
+	for _, p := range pids {
+		id, e := convert.Id(p)
+		if e != nil {
+			util.Log(e)
+			continue
+		}
+		if e = db.RestoreProject(id); e != nil {
+			return "Could not restore project.", e
+		}
+	}
+	return "Successfully restored project.", nil
+}
+
+//EditRetention sets the number of days a project is kept online before it is archived.
+func EditRetention(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	d, e := convert.Int(r.FormValue("retention-days"))
+	if e != nil {
+		return "Could not read retention period.", e
+	}
+	if e = db.SetRetention(pid, d); e != nil {
+		return "Could not set retention period.", e
+	}
+	return "Successfully set retention period.", nil
+}
+
+//DeleteUsers moves users and all data associated with them to the trash.
 func DeleteUsers(r *http.Request, c *context.C) (string, error) {
 	us, e := webutil.Strings(r, "user-id")
 	if e != nil {
 		return "Could not read user.", e
 	}
-	for _, u := range us {
-		if e = db.RemoveUserById(u); e != nil {
+	u, e := c.Username()
+	if e != nil {
+		return "Could not retrieve user.", e
+	}
+	for _, id := range us {
+		if _, e = db.TrashUser(id, u); e != nil {
 			util.Log(e)
 		}
 	}
-	return "Successfully deleted users.", nil
+	return "Successfully moved users to trash.", nil
 }
 
 func DeleteSubmissions(r *http.Request, c *context.C) (string, error) {
@@ -228,17 +407,21 @@ func DeleteSubmissions(r *http.Request, c *context.C) (string, error) {
 	if e != nil {
 		return "Could not read submissions.", e
 	}
+	u, e := c.Username()
+	if e != nil {
+		return "Could not retrieve user.", e
+	}
 	for _, s := range ss {
 		id, e := convert.Id(s)
 		if e != nil {
 			util.Log(e)
 			continue
 		}
-		if e = db.RemoveSubmissionById(id); e != nil {
+		if _, e = db.TrashSubmission(id, u); e != nil {
 			util.Log(e)
 		}
 	}
-	return "Successfully deleted submissions.", nil
+	return "Successfully moved submissions to trash.", nil
 }
 
 func DeleteSkeletons(r *http.Request, c *context.C) (string, error) {
@@ -246,52 +429,82 @@ func DeleteSkeletons(r *http.Request, c *context.C) (string, error) {
 	if e != nil {
 		return "Could not read skeletons.", e
 	}
+	u, e := c.Username()
+	if e != nil {
+		return "Could not retrieve user.", e
+	}
 	for _, sk := range sks {
 		id, e := convert.Id(sk)
 		if e != nil {
 			util.Log(e)
 			continue
 		}
-		if e = db.RemoveById(db.SKELETONS, id); e != nil {
+		if _, e = db.TrashSkeleton(id, u); e != nil {
 			util.Log(e)
 		}
 	}
-	return "Successfully deleted skeletons.", nil
+	return "Successfully moved skeletons to trash.", nil
 }
 
-//DeleteResults removes all results for a specic project.
+//DeleteResults moves all results for a specic submission to the trash.
 func DeleteResults(r *http.Request, c *context.C) (string, error) {
 	ss, e := webutil.Strings(r, "submission-id")
 	if e != nil {
 		return "Could not read submissions.", e
 	}
+	u, e := c.Username()
+	if e != nil {
+		return "Could not retrieve user.", e
+	}
 	for _, s := range ss {
 		sid, e := convert.Id(s)
 		if e != nil {
 			util.Log(e)
 			continue
 		}
-		fs, e := db.Files(bson.M{db.SUBID: sid}, bson.M{db.DATA: 0}, 0)
+		if _, e = db.TrashResults(sid, u); e != nil {
+			util.Log(e)
+		}
+	}
+	return "Successfully moved results to trash.", nil
+}
+
+//RestoreTrash restores deleted data from the trash.
+func RestoreTrash(r *http.Request, c *context.C) (string, error) {
+	ts, e := webutil.Strings(r, "trash-id")
+	if e != nil {
+		return "Could not read trash.", e
+	}
+	for _, t := range ts {
+		id, e := convert.Id(t)
 		if e != nil {
 			util.Log(e)
 			continue
 		}
-		for _, f := range fs {
-			for _, r := range f.Results {
-				id, ok := r.(bson.ObjectId)
-				if !ok {
-					continue
-				}
-				if e = db.RemoveById(db.RESULTS, id); e != nil {
-					util.Log(e)
-				}
-			}
-			if e = db.Update(db.FILES, bson.M{db.ID: f.Id}, bson.M{db.SET: bson.M{db.RESULTS: bson.M{}}}); e != nil {
-				util.Log(e)
-			}
+		if e = db.RestoreTrash(id); e != nil {
+			return "Could not restore trash.", e
 		}
 	}
-	return "Successfully deleted results.", nil
+	return "Successfully restored trash.", nil
+}
+
+//PurgeTrash permanently removes deleted data from the trash.
+func PurgeTrash(r *http.Request, c *context.C) (string, error) {
+	ts, e := webutil.Strings(r, "trash-id")
+	if e != nil {
+		return "Could not read trash.", e
+	}
+	for _, t := range ts {
+		id, e := convert.Id(t)
+		if e != nil {
+			util.Log(e)
+			continue
+		}
+		if e = db.PurgeTrash(id); e != nil {
+			return "Could not purge trash.", e
+		}
+	}
+	return "Successfully purged trash.", nil
 }
 
 //EditProject is used to modify a project's metadata.
@@ -317,10 +530,14 @@ func EditProject(r *http.Request, c *context.C) (string, error) {
 	if d, e := webutil.String(r, "project-description"); e == nil && p.Description != d {
 		sm[db.DESCRIPTION] = d
 	}
+	if w := r.FormValue("project-whole") == "true"; p.WholeProject != w {
+		sm[db.WHOLE] = w
+	}
 	if len(sm) == 0 {
 		return "Nothing to update", nil
 	}
-	if e = db.Update(db.PROJECTS, bson.M{db.ID: pid}, bson.M{db.SET: sm}); e != nil {
+	u, _ := c.Username()
+	if e = db.AuditedUpdate(u, "editproject", db.PROJECTS, pid, sm); e != nil {
 		return "Could not edit project.", e
 	}
 	return "Successfully edited project.", nil
@@ -346,7 +563,8 @@ func EditSubmission(r *http.Request, c *context.C) (string, error) {
 	if len(sm) == 0 {
 		return "Nothing to update", nil
 	}
-	if e = db.Update(db.SUBMISSIONS, bson.M{db.ID: sid}, bson.M{db.SET: sm}); e != nil {
+	u, _ := c.Username()
+	if e = db.AuditedUpdate(u, "editsubmission", db.SUBMISSIONS, sid, sm); e != nil {
 		return "Could not edit submission.", e
 	}
 	return "Successfully edited submission.", nil
@@ -372,7 +590,8 @@ func EditFile(r *http.Request, c *context.C) (string, error) {
 	if len(sm) == 0 {
 		return "Nothing to update", nil
 	}
-	if e = db.Update(db.FILES, bson.M{db.ID: fid}, bson.M{db.SET: sm}); e != nil {
+	u, _ := c.Username()
+	if e = db.AuditedUpdate(u, "editfile", db.FILES, fid, sm); e != nil {
 		return "Could not edit file.", e
 	}
 	return "Successfully edited file.", nil
@@ -407,8 +626,47 @@ func EditTest(r *http.Request, c *context.C) (string, error) {
 	if len(sm) == 0 {
 		return "Nothing to update", nil
 	}
-	if e = db.Update(db.TESTS, bson.M{db.ID: tid}, bson.M{db.SET: sm}); e != nil {
-		return "Could not edit file.", e
+	pid, n := t.ProjectId, t.Name
+	if p, ok := sm[db.PROJECTID]; ok {
+		pid = p.(bson.ObjectId)
+	}
+	if v, ok := sm[db.NAME]; ok {
+		n = v.(string)
+	}
+	u, _ := c.Username()
+	if t.Pending || t.Type == junit.USER || !hasReference(pid) {
+		if e = db.AuditedUpdate(u, "edittest", db.TESTS, tid, sm); e != nil {
+			return "Could not edit test.", e
+		}
+		if t.Pending && validate(c, pid, "JUnit test "+n, db.TestResults(n), false) {
+			return "Successfully edited test. " + PENDING, nil
+		}
+		return "Successfully edited test.", nil
+	}
+	//Edits to live tests are held back in a pending copy until they have been validated
+	//so that submissions are still run with the live test in the meantime.
+	before := bson.M{db.PROJECTID: t.ProjectId, db.NAME: t.Name, db.PKG: t.Package, db.TARGET: t.Target}
+	p := *t
+	p.Id, p.ProjectId, p.Name, p.Time, p.Pending, p.Replaces = bson.NewObjectId(), pid, n, util.CurMilis(), true, t.Id
+	if v, ok := sm[db.PKG]; ok {
+		p.Package = v.(string)
+	}
+	if v, ok := sm[db.TARGET]; ok {
+		p.Target = v.(*tool.Target)
+	}
+	if e = db.AddJUnitTest(&p); e != nil {
+		return "Could not edit test.", e
+	}
+	//A test moved to another project is no longer used by its original project.
+	if pid != t.ProjectId {
+		if e = db.RemoveById(db.TESTS, t.Id); e != nil {
+			return "Could not move test.", e
+		}
+	}
+	sm[db.PENDING] = true
+	audit(c, "edittest", db.TESTS, p.Id, before, sm)
+	if validate(c, pid, "JUnit test "+n, db.TestResults(n), false) {
+		return "Successfully edited test. " + PENDING, nil
 	}
 	return "Successfully edited test.", nil
 }
@@ -440,6 +698,7 @@ func Register(r *http.Request, c *context.C) (string, error) {
 	if e = db.Add(db.USERS, user.New(un, p)); e != nil {
 		return fmt.Sprintf("User %s already exists.", un), e
 	}
+	c.AddCreated(db.USERS, un)
 	c.AddUser(un)
 	return "Registered successfully.", nil
 }
@@ -476,13 +735,15 @@ func EditUser(r *http.Request, c *context.C) (string, error) {
 	if id == n && u.Access == p {
 		return "Nothing to update.", nil
 	}
+	cu, _ := c.Username()
 	if id != n {
 		if e = db.RenameUser(id, n); e != nil {
 			return fmt.Sprintf("could not rename user %s to %s.", id, n), e
 		}
+		audit(c, "edituser", db.USERS, n, bson.M{db.ID: id}, bson.M{db.ID: n})
 	}
 	if u.Access != p {
-		if e = db.Update(db.USERS, bson.M{db.ID: n}, bson.M{db.SET: bson.M{user.ACCESS: a}}); e != nil {
+		if e = db.AuditedUpdate(cu, "edituser", db.USERS, n, bson.M{user.ACCESS: a}); e != nil {
 			return "Could not edit user.", e
 		}
 	}
@@ -534,10 +795,11 @@ func RenameFiles(r *http.Request, c *context.C) (string, error) {
 				return "Could not retrieve files.", e
 			}
 			f.Rename(newName)
-			if e := db.Update(db.FILES, bson.M{db.ID: f.Id}, bson.M{db.SET: bson.M{db.DATA: f.Data, db.NAME: f.Name}}); e != nil {
+			if e := db.UpdateFileData(f, bson.M{db.NAME: f.Name}); e != nil {
 				return "Could not update file name.", e
 			}
 		}
 	}
+	audit(c, "renamefiles", db.PROJECTS, pid, bson.M{db.NAME: oldName}, bson.M{db.NAME: newName})
 	return fmt.Sprintf("Succesfully renamed files to %s.", newName), nil
 }
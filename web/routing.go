@@ -62,6 +62,9 @@ var (
 	teacher = []string{
 		"skeletonview", "addskeleton", "projectview",
 		"addproject", "runtoolsview", "runtools", "configview",
+		"treedownloadview", "tree.zip", "sarifdownloadview", "results.sarif",
+		"issueview", "issuetrackview", "similarityview", "similaritypairview",
+		"comparesubmissions", "validationview", "testcaseview", "flakytestview",
 	}
 	admin = []string{
 		"deleteprojects", "deleteusers", "deleteresults", "deleteview",
@@ -70,6 +73,8 @@ var (
 		"evaluatesubmissions", "logs", "editdbview", "loadproject", "editproject",
 		"loaduser", "edituser", "loadsubmission", "editsubmission", "loadfile",
 		"editfile", "edittest", "renamefiles", "renameview",
+		"retentionview", "archiveprojects", "restoreprojects", "editretention",
+		"trashview", "restoretrash", "purgetrash", "auditview", "audit.csv",
 	}
 
 	homeViews = []string{
@@ -83,12 +88,13 @@ var (
 		"configview",
 	}
 	registerViews = []string{"registerview"}
-	downloadViews = []string{"projectdownloadview", "intloladownloadview", "testdownloadview"}
+	downloadViews = []string{"projectdownloadview", "intloladownloadview", "testdownloadview", "treedownloadview", "sarifdownloadview"}
 	statusViews   = []string{"statusview"}
-	toolViews     = []string{"runtoolsview", "evaluatesubmissionsview"}
+	toolViews     = []string{"runtoolsview", "evaluatesubmissionsview", "issueview", "issuetrackview", "similarityview", "similaritypairview", "validationview", "testcaseview", "flakytestview"}
 	dataViews     = []string{
 		"importdataview", "exportdataview", "editdbview", "renameview",
 		"loadproject", "loadsubmission", "loadfile", "loaduser", "deleteview",
+		"retentionview", "trashview", "auditview",
 	}
 )
 
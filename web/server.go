@@ -55,7 +55,7 @@ func init() {
 	router = pat.New()
 	GenerateDownloads(router, Downloaders())
 	GenerateGets(router, Getters(), Views())
-	GeneratePosts(router, Posters(), IndexPosters())
+	GeneratePosts(router, Posters(), IndexPosters(), UnauditedPosters())
 	GenerateViews(router, Views())
 	GenerateAJAX(router)
 	router.Add("GET", "/static/", FileHandler(StaticDir()))
@@ -33,9 +33,20 @@ import (
 	"github.com/godfried/impendulo/processor/mq"
 	"github.com/godfried/impendulo/project"
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/benchmark"
+	"github.com/godfried/impendulo/tool/checkstyle"
+	"github.com/godfried/impendulo/tool/diagnostic"
+	"github.com/godfried/impendulo/tool/external"
+	"github.com/godfried/impendulo/tool/findbugs"
+	"github.com/godfried/impendulo/tool/gcc"
+	"github.com/godfried/impendulo/tool/iotest"
+	"github.com/godfried/impendulo/tool/javac"
 	"github.com/godfried/impendulo/tool/result"
+	"github.com/godfried/impendulo/tool/sarif"
+	"github.com/godfried/impendulo/user"
 	"github.com/godfried/impendulo/util"
 	"github.com/godfried/impendulo/util/convert"
+	"github.com/godfried/impendulo/web/context"
 
 	"html/template"
 
@@ -95,6 +106,51 @@ var (
 		"empty":     func(s string) bool { return strings.TrimSpace(s) == "" },
 		"sortFiles": sortFiles,
 		"project":   func(id bson.ObjectId) (*project.Project, error) { return db.Project(bson.M{db.ID: id}, nil) },
+		"usage":     db.ProjectUsage,
+		"archive":   projectArchive,
+		"retention": retentionDays,
+		"byteSize":  byteSize,
+		"trash":     func() ([]*db.Trash, error) { return db.TrashItems(nil, nil) },
+
+		"configtools":     configTools,
+		"externaltools":   func() ([]*external.Config, error) { return db.ExternalTools(nil, nil) },
+		"externalformats": external.Formats,
+		"externalcharts":  external.Charts,
+		"placeholders":    external.Placeholders,
+		"issuecategories": result.Categories,
+		"issuelevels":     func() []string { return []string{sarif.ERROR, sarif.WARNING, sarif.NOTE} },
+		"duration":        util.Duration,
+		"iotestcases":     func() ([]*iotest.Case, error) { return db.IOTestCases(nil, nil) },
+		"iotestmodes":     iotest.Modes,
+		"isTeacher":       isTeacher,
+		"workloads":       func() ([]*benchmark.Workload, error) { return db.Workloads(nil, nil) },
+		"reference":       reference,
+		"checkstyleconfigs": func() ([]*checkstyle.Config, error) {
+			return db.CheckstyleConfigs(nil, nil)
+		},
+		"checkstylechecks":     checkstyle.Checks,
+		"checkstyleprofiles":   checkstyle.Profiles,
+		"checkstyleseverities": checkstyle.Severities,
+		"findbugsconfigs": func() ([]*findbugs.Config, error) {
+			return db.FindbugsConfigs(nil, nil)
+		},
+		"findbugsefforts":     findbugs.Efforts,
+		"findbugsconfidences": findbugs.Confidences,
+		"findbugscategories":  findbugs.Categories,
+		"javacconfigs": func() ([]*javac.Config, error) {
+			return db.JavacConfigs(nil, bson.M{"jars.data": 0})
+		},
+		"javaclevels": javac.Levels,
+		"javaclints":  javac.Lints,
+		"gccconfigs": func() ([]*gcc.Config, error) {
+			return db.GCCConfigs(nil, nil)
+		},
+		"gccstandards": gcc.Standards,
+		"gccflags":     func() string { return strings.Join(gcc.DEFAULT_FLAGS, " ") },
+		"explanations": func() ([]*diagnostic.Explanation, error) {
+			return db.Explanations(nil, nil)
+		},
+		"defaultexplanations": diagnostic.Defaults,
 	}
 	templateDir      string
 	baseTemplates    []string
@@ -168,6 +224,35 @@ func projects() ([]*project.Project, error) {
 	return db.Projects(nil, nil, db.NAME)
 }
 
+//projectArchive retrieves a project's archive or nil if it is not archived.
+func projectArchive(pid bson.ObjectId) *db.Archive {
+	a, e := db.ProjectArchive(pid)
+	if e != nil {
+		return nil
+	}
+	return a
+}
+
+//retentionDays retrieves the number of days a project is kept online.
+func retentionDays(pid bson.ObjectId) int {
+	r, e := db.ProjectRetention(pid)
+	if e != nil {
+		return 0
+	}
+	return r.Days
+}
+
+//byteSize formats a size in bytes using the largest suitable unit.
+func byteSize(n int64) string {
+	us := []string{"B", "KB", "MB", "GB"}
+	f := float64(n)
+	i := 0
+	for ; f >= 1024 && i < len(us)-1; i++ {
+		f /= 1024
+	}
+	return fmt.Sprintf("%.1f %s", f, us[i])
+}
+
 //isError checks whether a result is an ErrorResult.
 func isError(i interface{}) bool {
 	_, ok := i.(*result.Error)
@@ -183,6 +268,22 @@ func args(values ...interface{}) (Args, error) {
 	return insert(a, values...)
 }
 
+//reference retrieves a project's reference solution without its data.
+//nil is returned if the project has no reference solution.
+func reference(pid bson.ObjectId) *project.Reference {
+	r, e := db.Reference(bson.M{db.PROJECTID: pid}, bson.M{db.NAME: 1, db.USER: 1, db.TIME: 1})
+	if e != nil {
+		return nil
+	}
+	return r
+}
+
+//isTeacher checks whether the user viewing a page has teacher permissions.
+func isTeacher(c *context.C) bool {
+	u, e := c.Username()
+	return e == nil && checkUserPermission(u, user.TEACHER)
+}
+
 //insert adds a key-value pair to the specified map.
 func insert(a Args, values ...interface{}) (Args, error) {
 	if len(values)%2 != 0 {
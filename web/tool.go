@@ -29,18 +29,25 @@ import (
 	"fmt"
 
 	"github.com/godfried/impendulo/db"
+	"github.com/godfried/impendulo/processor"
 	"github.com/godfried/impendulo/processor/mq"
 	"github.com/godfried/impendulo/project"
 	"github.com/godfried/impendulo/tool"
+	"github.com/godfried/impendulo/tool/benchmark"
 	"github.com/godfried/impendulo/tool/checkstyle"
+	"github.com/godfried/impendulo/tool/diagnostic"
 	"github.com/godfried/impendulo/tool/diff"
+	"github.com/godfried/impendulo/tool/external"
 	"github.com/godfried/impendulo/tool/findbugs"
 	"github.com/godfried/impendulo/tool/gcc"
+	"github.com/godfried/impendulo/tool/iotest"
 	"github.com/godfried/impendulo/tool/jacoco"
 	"github.com/godfried/impendulo/tool/javac"
 	"github.com/godfried/impendulo/tool/jpf"
 	"github.com/godfried/impendulo/tool/junit"
 	mk "github.com/godfried/impendulo/tool/make"
+	"github.com/godfried/impendulo/tool/metrics"
+	"github.com/godfried/impendulo/tool/mutation"
 	"github.com/godfried/impendulo/tool/pmd"
 	"github.com/godfried/impendulo/tool/result"
 	"github.com/godfried/impendulo/user"
@@ -52,6 +59,7 @@ import (
 
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -64,9 +72,21 @@ var (
 		findbugs.NAME:   "findbugsconfig",
 		checkstyle.NAME: "checkstyleconfig",
 		mk.NAME:         "makeconfig",
+		javac.NAME:      "javacconfig",
+		gcc.NAME:        "gccconfig",
+		diagnostic.NAME: "explanationconfig",
+		external.NAME:   "externalconfig",
+		iotest.NAME:     "iotestconfig",
+		benchmark.NAME:  "benchmarkconfig",
 		"none":          "noconfig",
 	}
-	JPFKeyError = errors.New("JPF key cannot be empty")
+	JPFKeyError      = errors.New("JPF key cannot be empty")
+	NoReferenceError = errors.New("project has no reference solution")
+)
+
+const (
+	VALIDATING = "It is being validated against the reference solution."
+	PENDING    = "It will be run on submissions once it has been validated against the reference solution and published."
 )
 
 //toolTemplate
@@ -77,25 +97,65 @@ func toolTemplate(tool string) string {
 //toolPermissions
 func toolPermissions() map[string]user.Permission {
 	return map[string]user.Permission{
-		"createjpf":        user.TEACHER,
-		"createpmd":        user.TEACHER,
-		"createjunit":      user.TEACHER,
-		"createfindbugs":   user.TEACHER,
-		"createcheckstyle": user.TEACHER,
-		"createmake":       user.TEACHER,
+		"createjpf":         user.TEACHER,
+		"createpmd":         user.TEACHER,
+		"createjunit":       user.TEACHER,
+		"createfindbugs":    user.TEACHER,
+		"createcheckstyle":  user.TEACHER,
+		"createmake":        user.TEACHER,
+		"createjavac":       user.TEACHER,
+		"creategcc":         user.TEACHER,
+		"createexplanation": user.TEACHER,
+		"deleteexplanation": user.TEACHER,
+		"createexternal":    user.TEACHER,
+		"deleteexternal":    user.TEACHER,
+		"createiotest":      user.TEACHER,
+		"deleteiotest":      user.TEACHER,
+		"createworkload":    user.TEACHER,
+		"deleteworkload":    user.TEACHER,
+		"addreference":      user.TEACHER,
+		"validateproject":   user.TEACHER,
+		"publishtests":      user.TEACHER,
+		"reevaluate":        user.TEACHER,
 	}
 }
 
 //toolRequesters
 func toolPosters() map[string]Poster {
 	return map[string]Poster{
-		"createpmd":        CreatePMD,
-		"createjpf":        CreateJPF,
-		"createjunit":      CreateJUnit,
-		"createfindbugs":   CreateFindbugs,
-		"createcheckstyle": CreateCheckstyle,
-		"createmake":       CreateMake,
+		"createpmd":         CreatePMD,
+		"createjpf":         CreateJPF,
+		"createjunit":       CreateJUnit,
+		"createfindbugs":    CreateFindbugs,
+		"createcheckstyle":  CreateCheckstyle,
+		"createmake":        CreateMake,
+		"createjavac":       CreateJavac,
+		"creategcc":         CreateGCC,
+		"createexplanation": CreateExplanation,
+		"deleteexplanation": DeleteExplanation,
+		"createexternal":    CreateExternal,
+		"deleteexternal":    DeleteExternal,
+		"createiotest":      CreateIOTest,
+		"deleteiotest":      DeleteIOTest,
+		"createworkload":    CreateWorkload,
+		"deleteworkload":    DeleteWorkload,
+		"addreference":      AddReference,
+		"validateproject":   ValidateProject,
+		"publishtests":      PublishTests,
+		"reevaluate":        Reevaluate,
+	}
+}
+
+//configTools retrieves the names of all configurable tools.
+func configTools() []string {
+	ts := make([]string, 0, len(templates))
+	for t := range templates {
+		if t != "none" {
+			ts = append(ts, t)
+		}
 	}
+	sort.Strings(ts)
+	return ts
 }
 
 //tools
@@ -104,35 +164,126 @@ func tools(pid bson.ObjectId) ([]string, error) {
 	if e != nil {
 		return nil, e
 	}
+	var ts []string
 	switch tool.Language(p.Lang) {
 	case tool.JAVA:
-		ts := []string{pmd.NAME, findbugs.NAME, checkstyle.NAME, javac.NAME}
+		ts = []string{pmd.NAME, findbugs.NAME, checkstyle.NAME, javac.NAME, metrics.NAME}
 		if _, e := db.JPFConfig(bson.M{db.PROJECTID: pid}, bson.M{db.ID: 1}); e == nil {
 			ts = append(ts, jpf.NAME)
 		}
-		if js, e := db.JUnitTests(bson.M{db.PROJECTID: pid}, bson.M{db.NAME: 1}); e == nil {
+		if js, e := db.JUnitTests(bson.M{db.PROJECTID: pid}, bson.M{db.NAME: 1, db.TYPE: 1}); e == nil {
 			for _, j := range js {
 				n, _ := util.Extension(j.Name)
 				ts = append(ts, jacoco.NAME+":"+n, junit.NAME+":"+n)
+				if j.Type == junit.USER {
+					ts = append(ts, mutation.NAME+":"+n)
+				}
 			}
 		}
-		sort.Strings(ts)
-		return ts, nil
 	case tool.C:
-		return []string{mk.NAME, gcc.NAME}, nil
+		ts = []string{mk.NAME, gcc.NAME, metrics.NAME}
 	default:
 		return nil, fmt.Errorf("unknown language %s", p.Lang)
 	}
+	if db.Contains(db.IOTESTS, bson.M{db.PROJECTID: pid}) {
+		ts = append(ts, iotest.NAME)
+	}
+	if db.Contains(db.BENCHMARKS, bson.M{db.PROJECTID: pid}) {
+		ts = append(ts, benchmark.NAME)
+	}
+	if xs, e := db.ExternalTools(bson.M{db.PROJECTID: pid}, bson.M{db.NAME: 1}); e == nil {
+		for _, x := range xs {
+			ts = append(ts, external.NAME+":"+x.Name)
+		}
+	}
+	sort.Strings(ts)
+	return ts, nil
 }
 
-//CreateCheckstyle
+//CreateCheckstyle creates a Checkstyle configuration for a project either from
+//a built-in profile or from the checks, severities and properties chosen.
 func CreateCheckstyle(r *http.Request, c *context.C) (string, error) {
-	return "", nil
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	var k *checkstyle.Config
+	if p, e := webutil.String(r, "profile"); e == nil {
+		k, e = checkstyle.NewProfile(pid, p)
+		if e != nil {
+			return "Could not create Checkstyle configuration.", e
+		}
+	} else {
+		cs, e := checkstyleChecks(r)
+		if e != nil {
+			return "Could not read Checkstyle checks.", e
+		}
+		if k, e = checkstyle.NewConfig(pid, cs); e != nil {
+			return "Could not create Checkstyle configuration.", e
+		}
+	}
+	if e = db.AddCheckstyleConfig(k); e != nil {
+		return "Could not add Checkstyle configuration.", e
+	}
+	c.AddCreated(db.CHECKSTYLE, k.Id)
+	return "Successfully added Checkstyle configuration.", nil
+}
+
+//checkstyleChecks reads the enabled Checkstyle checks along with their severities
+//and properties from a request.
+func checkstyleChecks(r *http.Request) ([]*checkstyle.Check, error) {
+	a, e := checkstyle.Checks()
+	if e != nil {
+		return nil, e
+	}
+	cs := make([]*checkstyle.Check, 0, len(a))
+	for _, k := range a {
+		if r.FormValue("check-"+k.Name) != "true" {
+			continue
+		}
+		c := &checkstyle.Check{Name: k.Name, Severity: r.FormValue("severity-" + k.Name), Properties: make([]*checkstyle.Property, 0, len(k.Properties))}
+		for _, p := range k.Properties {
+			if v, ok := r.Form["property-"+k.Name+"-"+p.Name]; ok && len(v) > 0 {
+				c.Properties = append(c.Properties, &checkstyle.Property{Name: p.Name, Value: v[0]})
+			}
+		}
+		cs = append(cs, c)
+	}
+	if len(cs) == 0 {
+		return nil, errors.New("no Checkstyle checks enabled")
+	}
+	return cs, nil
 }
 
-//CreateFindbugs
+//CreateFindbugs creates a Findbugs configuration for a project from the chosen
+//effort, thresholds, bug categories, bug patterns and excluded classes.
 func CreateFindbugs(r *http.Request, c *context.C) (string, error) {
-	return "", nil
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	rk, e := convert.Int(r.FormValue("rank"))
+	if e != nil {
+		return "Could not read rank.", e
+	}
+	cs, e := webutil.Strings(r, "categories")
+	if e != nil {
+		return "Could not read categories.", e
+	}
+	xs, e := webutil.Strings(r, "excluded")
+	if e != nil {
+		return "Could not read excluded categories.", e
+	}
+	fc, e := findbugs.NewConfig(pid, r.FormValue("effort"), r.FormValue("confidence"), rk, r.FormValue("relaxed") == "true", cs, xs,
+		strings.Split(r.FormValue("patterns"), ","), strings.Split(r.FormValue("exclude-patterns"), ","), strings.Split(r.FormValue("classes"), "\n"))
+	if e != nil {
+		return "Could not create Findbugs configuration.", e
+	}
+	if e = db.AddFindbugsConfig(fc); e != nil {
+		return "Could not add Findbugs configuration.", e
+	}
+	c.AddCreated(db.FINDBUGS, fc.Id)
+	return "Successfully added Findbugs configuration.", nil
 }
 
 //CreateMake
@@ -146,12 +297,390 @@ func CreateMake(r *http.Request, c *context.C) (string, error) {
 		return "Could not read Makefile.", e
 	}
 	mf := mk.NewMakefile(pid, d)
+	mf.Pending = hasReference(pid)
 	if e = db.AddMakefile(mf); e != nil {
 		return "Could not create Makefile.", e
 	}
+	c.AddCreated(db.MAKE, mf.Id)
+	if mf.Pending && validate(c, pid, "Makefile", nil, true) {
+		return "Successfully created Makefile. " + PENDING, nil
+	}
 	return "Successfully created Makefile.", nil
 }
 
+//CreateJavac creates a javac configuration for a project from the chosen language levels,
+//lint categories, flags and jar files. The project's current jar files are kept if none are provided.
+func CreateJavac(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	ls, e := webutil.Strings(r, "lint")
+	if e != nil {
+		return "Could not read lint categories.", e
+	}
+	js, e := jars(r)
+	if e != nil {
+		return "Could not read jar files.", e
+	}
+	if len(js) == 0 {
+		if o, e := db.JavacConfig(db.Current(db.JAVAC, pid, true), nil); e == nil {
+			js = o.Jars
+		}
+	}
+	jc, e := javac.NewConfig(pid, r.FormValue("source"), r.FormValue("target"), ls, strings.Fields(r.FormValue("flags")), js)
+	if e != nil {
+		return "Could not create javac configuration.", e
+	}
+	jc.Pending = hasReference(pid)
+	if e = db.AddJavacConfig(jc); e != nil {
+		return "Could not add javac configuration.", e
+	}
+	c.AddCreated(db.JAVAC, jc.Id)
+	if jc.Pending && validate(c, pid, "Compiler options", nil, true) {
+		return "Successfully added javac configuration. " + PENDING, nil
+	}
+	return "Successfully added javac configuration.", nil
+}
+
+//jars reads the jar files uploaded in a request.
+func jars(r *http.Request) ([]*javac.Jar, error) {
+	if r.MultipartForm == nil {
+		if e := r.ParseMultipartForm(32 << 20); e != nil {
+			return nil, e
+		}
+	}
+	hs := r.MultipartForm.File["jars"]
+	js := make([]*javac.Jar, 0, len(hs))
+	for _, h := range hs {
+		f, e := h.Open()
+		if e != nil {
+			return nil, e
+		}
+		js = append(js, &javac.Jar{Name: h.Filename, Data: util.ReadBytes(f)})
+		f.Close()
+	}
+	return js, nil
+}
+
+//CreateGCC creates a gcc configuration for a project from the chosen
+//language standard, flags, warnings and libraries.
+func CreateGCC(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	gc, e := gcc.NewConfig(pid, r.FormValue("standard"), strings.Fields(r.FormValue("flags")),
+		strings.Fields(r.FormValue("warnings")), strings.Fields(r.FormValue("libraries")))
+	if e != nil {
+		return "Could not create gcc configuration.", e
+	}
+	gc.Pending = hasReference(pid)
+	if e = db.AddGCCConfig(gc); e != nil {
+		return "Could not add gcc configuration.", e
+	}
+	c.AddCreated(db.GCC, gc.Id)
+	if gc.Pending && validate(c, pid, "Compiler options", nil, true) {
+		return "Successfully added gcc configuration. " + PENDING, nil
+	}
+	return "Successfully added gcc configuration.", nil
+}
+
+//CreateExplanation adds a plain-language explanation of a compiler message to a project's
+//catalogue or replaces the project's explanation of the same kind.
+func CreateExplanation(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	p, e := db.Project(bson.M{db.ID: pid}, bson.M{db.LANG: 1})
+	if e != nil {
+		return "Could not load project.", e
+	}
+	x, e := diagnostic.NewExplanation(pid, tool.Language(p.Lang), r.FormValue("explanation-kind"), r.FormValue("explanation-pattern"),
+		r.FormValue("explanation-text"), r.FormValue("explanation-hint"))
+	if e != nil {
+		return "Could not create explanation.", e
+	}
+	if e = db.AddExplanation(x); e != nil {
+		return "Could not add explanation.", e
+	}
+	c.AddCreated(db.EXPLANATIONS, x.Id)
+	return "Successfully added explanation.", nil
+}
+
+//DeleteExplanation removes an explanation from a project's catalogue.
+func DeleteExplanation(r *http.Request, c *context.C) (string, error) {
+	id, e := convert.Id(r.FormValue("explanation-id"))
+	if e != nil {
+		return "Could not read explanation id.", e
+	}
+	if e = db.RemoveById(db.EXPLANATIONS, id); e != nil {
+		return "Could not delete explanation.", e
+	}
+	return "Successfully deleted explanation.", nil
+}
+
+//CreateExternal adds a new external tool to a project or replaces
+//the project's external tool with the same name.
+func CreateExternal(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	p, e := db.Project(bson.M{db.ID: pid}, bson.M{db.LANG: 1})
+	if e != nil {
+		return "Could not load project.", e
+	}
+	n, e := webutil.String(r, "external-name")
+	if e != nil {
+		return "Could not read tool name.", e
+	}
+	cmd, e := webutil.String(r, "external-command")
+	if e != nil {
+		return "Could not read tool command.", e
+	}
+	t, e := strconv.Atoi(r.FormValue("external-timeout"))
+	if e != nil {
+		t = external.DEFAULT_TIMEOUT
+	}
+	f := external.Format(r.FormValue("external-format"))
+	ch := external.Chart(r.FormValue("external-chart"))
+	x, e := external.NewConfig(pid, n, p.Lang, cmd, f, r.FormValue("external-pattern"), ch, t)
+	if e != nil {
+		return e.Error(), e
+	}
+	if e = db.AddExternalTool(x); e != nil {
+		return "Could not create external tool.", e
+	}
+	c.AddCreated(db.EXTERNAL, x.Id)
+	return "Successfully created external tool.", nil
+}
+
+//DeleteExternal removes an external tool from its project.
+func DeleteExternal(r *http.Request, c *context.C) (string, error) {
+	id, e := convert.Id(r.FormValue("external-id"))
+	if e != nil {
+		return "Could not read external tool id.", e
+	}
+	if e = db.RemoveById(db.EXTERNAL, id); e != nil {
+		return "Could not delete external tool.", e
+	}
+	return "Successfully deleted external tool.", nil
+}
+
+//CreateIOTest adds a new input/output test case to a project or replaces
+//the project's case with the same name. The input and expected output can
+//either be typed in or uploaded as files.
+func CreateIOTest(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	p, e := db.Project(bson.M{db.ID: pid}, bson.M{db.LANG: 1})
+	if e != nil {
+		return "Could not load project.", e
+	}
+	n, e := webutil.String(r, "iotest-name")
+	if e != nil {
+		return "Could not read case name.", e
+	}
+	in := r.FormValue("iotest-input")
+	if _, d, e := webutil.File(r, "iotest-input-file"); e == nil {
+		in = string(d)
+	}
+	x := r.FormValue("iotest-expected")
+	if _, d, e := webutil.File(r, "iotest-expected-file"); e == nil {
+		x = string(d)
+	}
+	//Missing exit codes and tolerances default to zero.
+	ec, _ := strconv.Atoi(r.FormValue("iotest-exit"))
+	t, _ := strconv.ParseFloat(r.FormValue("iotest-tolerance"), 64)
+	m := iotest.Mode(r.FormValue("iotest-mode"))
+	h := r.FormValue("iotest-hidden") == "true"
+	ic, e := iotest.NewCase(pid, n, p.Lang, r.FormValue("iotest-file"), r.FormValue("iotest-args"), in, x, ec, m, t, h)
+	if e != nil {
+		return e.Error(), e
+	}
+	ic.Pending = hasReference(pid)
+	if e = db.AddIOTestCase(ic); e != nil {
+		return "Could not create test case.", e
+	}
+	c.AddCreated(db.IOTESTS, ic.Id)
+	if ic.Pending && validate(c, pid, "Test case "+n, []string{iotest.NAME}, false) {
+		return "Successfully created test case. " + PENDING, nil
+	}
+	return "Successfully created test case.", nil
+}
+
+//DeleteIOTest removes an input/output test case from its project.
+func DeleteIOTest(r *http.Request, c *context.C) (string, error) {
+	id, e := convert.Id(r.FormValue("iotest-id"))
+	if e != nil {
+		return "Could not read test case id.", e
+	}
+	if e = db.RemoveById(db.IOTESTS, id); e != nil {
+		return "Could not delete test case.", e
+	}
+	return "Successfully deleted test case.", nil
+}
+
+//CreateWorkload adds a new benchmark workload to a project or replaces
+//the project's workload with the same name.
+func CreateWorkload(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	p, e := db.Project(bson.M{db.ID: pid}, bson.M{db.LANG: 1})
+	if e != nil {
+		return "Could not load project.", e
+	}
+	n, e := webutil.String(r, "workload-name")
+	if e != nil {
+		return "Could not read workload name.", e
+	}
+	in := r.FormValue("workload-input")
+	if _, d, e := webutil.File(r, "workload-input-file"); e == nil {
+		in = string(d)
+	}
+	//Defaults are used for missing values.
+	rs, _ := strconv.Atoi(r.FormValue("workload-runs"))
+	t, _ := strconv.Atoi(r.FormValue("workload-timeout"))
+	w, e := benchmark.NewWorkload(pid, n, p.Lang, r.FormValue("workload-file"), r.FormValue("workload-args"), in, rs, t)
+	if e != nil {
+		return e.Error(), e
+	}
+	if e = db.AddWorkload(w); e != nil {
+		return "Could not create workload.", e
+	}
+	c.AddCreated(db.BENCHMARKS, w.Id)
+	return "Successfully created workload.", nil
+}
+
+//DeleteWorkload removes a benchmark workload from its project.
+func DeleteWorkload(r *http.Request, c *context.C) (string, error) {
+	id, e := convert.Id(r.FormValue("workload-id"))
+	if e != nil {
+		return "Could not read workload id.", e
+	}
+	if e = db.RemoveById(db.BENCHMARKS, id); e != nil {
+		return "Could not delete workload.", e
+	}
+	return "Successfully deleted workload.", nil
+}
+
+//AddReference replaces a project's reference solution with an uploaded zip archive of its source tree.
+func AddReference(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	n, d, e := webutil.File(r, "reference")
+	if e != nil {
+		return "Could not read reference solution.", e
+	}
+	if _, e = util.UnzipToMap(d); e != nil {
+		return "Reference solution is not a valid zip archive.", e
+	}
+	u, e := c.Username()
+	if e != nil {
+		return "Could not retrieve user.", e
+	}
+	rf := project.NewReference(pid, n, u, d)
+	if e = db.AddReference(rf); e != nil {
+		return "Could not add reference solution.", e
+	}
+	c.AddCreated(db.REFERENCES, rf.Id)
+	if validate(c, pid, "Reference solution "+n, nil, false) {
+		return "Successfully added reference solution. " + VALIDATING, nil
+	}
+	return "Successfully added reference solution.", nil
+}
+
+//hasReference checks whether project pid has a reference solution.
+func hasReference(pid bson.ObjectId) bool {
+	return db.Contains(db.REFERENCES, bson.M{db.PROJECTID: pid})
+}
+
+//validate starts validating project pid's tests against its reference solution
+//after trigger changed. tools are the results which need to be recalculated
+//because of the change. false is returned if the project has no reference solution.
+func validate(c *context.C, pid bson.ObjectId, trigger string, tools []string, all bool) bool {
+	if !hasReference(pid) {
+		return false
+	}
+	u, _ := c.Username()
+	v := project.NewValidation(pid, trigger, u, tools, all)
+	go func() {
+		if e := processor.Validate(v); e != nil {
+			util.Log(e)
+		}
+	}()
+	return true
+}
+
+//ValidateProject validates a project's tests against its reference solution.
+func ValidateProject(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	if !validate(c, pid, "Manual validation", nil, false) {
+		return "Project has no reference solution.", NoReferenceError
+	}
+	return "Successfully started validation.", nil
+}
+
+//PublishTests makes a project's pending tests live. Existing submissions
+//are re-evaluated with the published tests if requested.
+func PublishTests(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	ts, all, e := db.Publish(pid)
+	if e != nil {
+		return "Could not publish tests.", e
+	}
+	if r.FormValue("reevaluate-check") != "true" || (len(ts) == 0 && !all) {
+		return "Successfully published tests.", nil
+	}
+	if e = reevaluate(pid, ts, all); e != nil {
+		return "Could not re-evaluate submissions.", e
+	}
+	return "Successfully published tests and started re-evaluating submissions.", nil
+}
+
+//Reevaluate recalculates the results affected by the change which triggered a validation
+//for all the project's submissions.
+func Reevaluate(r *http.Request, c *context.C) (string, error) {
+	id, e := convert.Id(r.FormValue("validation-id"))
+	if e != nil {
+		return "Could not read validation id.", e
+	}
+	v, e := db.Validation(bson.M{db.ID: id}, nil)
+	if e != nil {
+		return "Could not load validation.", e
+	}
+	if !v.Reevaluate() {
+		return "Nothing to re-evaluate.", nil
+	}
+	if e = reevaluate(v.ProjectId, v.Tools, v.All); e != nil {
+		return "Could not re-evaluate submissions.", e
+	}
+	return "Successfully started re-evaluating submissions.", nil
+}
+
+func reevaluate(pid bson.ObjectId, tools []string, all bool) error {
+	ss, e := db.Submissions(bson.M{db.PROJECTID: pid}, bson.M{db.ID: 1})
+	if e != nil {
+		return e
+	}
+	redoSubmissions(ss, tools, true, all)
+	return nil
+}
+
 //CreateJUnit adds a new JUnit test for a given project.
 func CreateJUnit(r *http.Request, c *context.C) (string, error) {
 	pid, e := convert.Id(r.FormValue("project-id"))
@@ -180,7 +709,21 @@ func CreateJUnit(r *http.Request, c *context.C) (string, error) {
 	} else {
 		d = make([]byte, 0)
 	}
-	return "", db.AddJUnitTest(junit.NewTest(pid, n, tipe, t, b, d))
+	jt := junit.NewTest(pid, n, tipe, t, b, d)
+	rs, _ := strconv.Atoi(r.FormValue("reruns"))
+	if e = jt.SetReruns(rs); e != nil {
+		return e.Error(), e
+	}
+	//User tests are written by students so they can't be validated.
+	jt.Pending = tipe != junit.USER && hasReference(pid)
+	if e = db.AddJUnitTest(jt); e != nil {
+		return "Could not add JUnit test.", e
+	}
+	c.AddCreated(db.TESTS, jt.Id)
+	if jt.Pending && validate(c, pid, "JUnit test "+n, db.TestResults(n), false) {
+		return "Successfully added JUnit test. " + PENDING, nil
+	}
+	return "", nil
 }
 
 //CreateJPF replaces a project's JPF configuration with a new, provided configuration.
@@ -203,9 +746,15 @@ func CreateJPF(r *http.Request, c *context.C) (string, error) {
 	if e != nil {
 		return "Could not create JPF configuration.", e
 	}
-	if e = db.AddJPFConfig(jpf.NewConfig(pid, t, d)); e != nil {
+	jc := jpf.NewConfig(pid, t, d)
+	jc.Pending = hasReference(pid)
+	if e = db.AddJPFConfig(jc); e != nil {
 		return "Could not create JPF configuration.", e
 	}
+	c.AddCreated(db.JPF, jc.Id)
+	if jc.Pending && validate(c, pid, "JPF configuration", []string{jpf.NAME}, false) {
+		return "Successfully created JPF configuration. " + PENDING, nil
+	}
 	return "Successfully created JPF configuration.", nil
 }
 
@@ -299,6 +848,7 @@ func CreatePMD(r *http.Request, c *context.C) (string, error) {
 	if e = db.AddPMDRules(rs); e != nil {
 		return "Could not add rules.", e
 	}
+	c.AddCreated(db.PMD, rs.Id)
 	return "Successfully added rules.", nil
 }
 
@@ -328,6 +878,21 @@ func RunTools(r *http.Request, c *context.C) (string, error) {
 	return "Successfully started running tools on submissions.", nil
 }
 
+//CompareSubmissions starts comparing the source of all submissions in a project
+//to find similar code. Previous comparisons of the project are replaced.
+func CompareSubmissions(r *http.Request, c *context.C) (string, error) {
+	pid, e := convert.Id(r.FormValue("project-id"))
+	if e != nil {
+		return "Could not read project id.", e
+	}
+	go func() {
+		if e := db.CompareSubmissions(pid); e != nil {
+			util.Log(e)
+		}
+	}()
+	return "Successfully started comparing submissions.", nil
+}
+
 func addUserTools(sid bson.ObjectId, tools []string) []string {
 	ts, e := db.Files(bson.M{db.SUBID: sid, db.TYPE: project.TEST}, bson.M{db.ID: 1, db.NAME: 1}, 0)
 	if e != nil {
@@ -393,7 +958,7 @@ func runAllTools(f *project.File, allFiles bool) {
 		if e != nil {
 			continue
 		}
-		if e := db.RemoveById(db.RESULTS, rid); e != nil {
+		if e := db.RemoveResult(rid); e != nil {
 			util.Log(e)
 		}
 	}
@@ -417,7 +982,7 @@ func runTools(f *project.File, ts []string, allFiles bool) {
 		if !isId {
 			continue
 		}
-		if e := db.RemoveById(db.RESULTS, rid); e != nil {
+		if e := db.RemoveResult(rid); e != nil {
 			util.Log(e)
 		}
 	}
@@ -446,8 +1011,10 @@ func GetResult(r *context.Result, fileId bson.ObjectId) (result.Displayer, error
 		return result.NewCode(fileId, p.Lang, f.Data), nil
 	case diff.NAME:
 		return diff.NewResult(f), nil
+	case db.ISSUE_RESULT:
+		return db.NewIssueResult(fileId)
 	default:
-		ival, ok := f.Results[r.Raw()]
+		ival, ok := f.Results[r.Key()]
 		if !ok {
 			return result.NewError(result.NORESULT, r.Format()), nil
 		}